@@ -4,6 +4,7 @@ package main
 import (
 	"flag"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 
@@ -26,6 +27,11 @@ func main() {
 	var keyFile string
 	var enableTLS bool
 	var logLevel string
+	var allowUsers string
+	var allowGroups string
+	var outputLimit int64
+	var outputDir string
+	var outputKeepDays int
 	flag.BoolVar(&allowRoot, "allow-root", false, "./gocron-node -allow-root")
 	flag.StringVar(&serverAddr, "s", "0.0.0.0:5921", "./gocron-node -s ip:port")
 	flag.BoolVar(&version, "v", false, "./gocron-node -v")
@@ -34,6 +40,11 @@ func main() {
 	flag.StringVar(&certFile, "cert-file", "", "./gocron-node -cert-file path")
 	flag.StringVar(&keyFile, "key-file", "", "./gocron-node -key-file path")
 	flag.StringVar(&logLevel, "log-level", "info", "-log-level error")
+	flag.StringVar(&allowUsers, "allow-users", "", "./gocron-node -allow-root -allow-users www,deploy")
+	flag.StringVar(&allowGroups, "allow-groups", "", "./gocron-node -allow-root -allow-groups www,deploy")
+	flag.Int64Var(&outputLimit, "output-limit", 1024, "./gocron-node -output-limit 1024 (KB)")
+	flag.StringVar(&outputDir, "output-dir", filepath.Join(os.TempDir(), "gocron-output"), "./gocron-node -output-dir path")
+	flag.IntVar(&outputKeepDays, "output-keep-days", 7, "./gocron-node -output-keep-days 7")
 	flag.Parse()
 	level, err := log.ParseLevel(logLevel)
 	if err != nil {
@@ -71,5 +82,30 @@ func main() {
 		return
 	}
 
-	server.Start(serverAddr, enableTLS, certificate)
+	// 任务切换执行用户需要以root运行
+	runAs := server.RunAsPolicy{
+		Users:  splitList(allowUsers),
+		Groups: splitList(allowGroups),
+	}
+
+	// 任务输出超过上限时保留开头和结尾, 完整输出可保存到文件
+	output := server.OutputPolicy{
+		Limit:    outputLimit * 1024,
+		Dir:      strings.TrimSpace(outputDir),
+		KeepDays: outputKeepDays,
+	}
+
+	server.Start(serverAddr, enableTLS, certificate, server.Server{Version: AppVersion, RunAs: runAs, Output: output})
+}
+
+// 解析逗号分隔的列表, 忽略空项
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
 }
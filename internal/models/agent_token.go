@@ -11,6 +11,7 @@ type AgentToken struct {
 	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
 	Used      bool       `json:"used" gorm:"default:false"`
 	UsedAt    *time.Time `json:"used_at" gorm:"default:null"`
+	Labels    string     `json:"labels" gorm:"type:varchar(512);not null;default:''"` // 注册时设置到新建主机的标签
 	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
 }
 
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const CalendarDateFormat = "2006-01-02"
+
+// 日期范围分隔符, 如 2025-01-28~2025-02-04
+const CalendarRangeSeparator = "~"
+
+type TaskCalendarMode int8
+
+const (
+	TaskCalendarModeExclude TaskCalendarMode = 1 // 日历中的日期不执行
+	TaskCalendarModeInclude TaskCalendarMode = 2 // 仅在日历中的日期执行
+)
+
+// 日历(节假日、封网期等)
+type Calendar struct {
+	Id        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"type:varchar(32);not null"`
+	Dates     string    `json:"dates" gorm:"type:text;not null"` // 每行一个日期或日期范围
+	Remark    string    `json:"remark" gorm:"type:varchar(100);not null;default:''"`
+	CreatedAt time.Time `json:"created" gorm:"column:created;autoCreateTime"`
+	BaseModel `json:"-" gorm:"-"`
+}
+
+// 日期范围, 包含首尾两天
+type CalendarDateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (r CalendarDateRange) String() string {
+	if r.Start.Equal(r.End) {
+		return r.Start.Format(CalendarDateFormat)
+	}
+
+	return r.Start.Format(CalendarDateFormat) + CalendarRangeSeparator + r.End.Format(CalendarDateFormat)
+}
+
+// ParseCalendarDates 解析日历日期, 每行一个日期(2025-01-01)或日期范围(2025-01-01~2025-01-03), #之后为注释
+func ParseCalendarDates(dates string) ([]CalendarDateRange, error) {
+	ranges := make([]CalendarDateRange, 0)
+	for i, line := range strings.Split(dates, "\n") {
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, CalendarRangeSeparator, 2)
+		start, err := time.ParseInLocation(CalendarDateFormat, strings.TrimSpace(fields[0]), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行日期格式错误: %s", i+1, line)
+		}
+		end := start
+		if len(fields) == 2 {
+			end, err = time.ParseInLocation(CalendarDateFormat, strings.TrimSpace(fields[1]), time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("第%d行日期格式错误: %s", i+1, line)
+			}
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("第%d行结束日期早于开始日期: %s", i+1, line)
+		}
+		ranges = append(ranges, CalendarDateRange{Start: start, End: end})
+	}
+
+	return ranges, nil
+}
+
+// Contains 判断时间所在日期是否在日历中, 日期解析失败时返回错误
+func (calendar *Calendar) Contains(t time.Time) (bool, error) {
+	ranges, err := ParseCalendarDates(calendar.Dates)
+	if err != nil {
+		return false, err
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	for _, r := range ranges {
+		if !day.Before(r.Start) && !day.After(r.End) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Allow 按日历模式判断任务在指定时间是否允许执行, 日历无法解析时返回错误, 由调用方按不允许处理
+func (calendar *Calendar) Allow(mode TaskCalendarMode, t time.Time) (bool, error) {
+	contains, err := calendar.Contains(t)
+	if err != nil {
+		return false, err
+	}
+	if mode == TaskCalendarModeInclude {
+		return contains, nil
+	}
+
+	return !contains, nil
+}
+
+// 新增
+func (calendar *Calendar) Create() (insertId int, err error) {
+	result := Db.Create(calendar)
+	if result.Error == nil {
+		insertId = calendar.Id
+	}
+
+	return insertId, result.Error
+}
+
+func (calendar *Calendar) UpdateBean(id int) (int64, error) {
+	result := Db.Model(&Calendar{}).Where("id = ?", id).
+		Select("name", "dates", "remark").
+		Updates(calendar)
+	return result.RowsAffected, result.Error
+}
+
+// 删除
+func (calendar *Calendar) Delete(id int) (int64, error) {
+	result := Db.Delete(&Calendar{}, id)
+	return result.RowsAffected, result.Error
+}
+
+func (calendar *Calendar) Find(id int) error {
+	return Db.First(calendar, id).Error
+}
+
+func (calendar *Calendar) NameExists(name string, id int) (bool, error) {
+	var count int64
+	query := Db.Model(&Calendar{}).Where("name = ?", name)
+	if id != 0 {
+		query = query.Where("id != ?", id)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (calendar *Calendar) List(params CommonMap) ([]Calendar, error) {
+	calendar.parsePageAndPageSize(params)
+	list := make([]Calendar, 0)
+	query := Db.Order("id DESC")
+	calendar.parseWhere(query, params)
+	err := query.Limit(calendar.PageSize).Offset(calendar.pageLimitOffset()).Find(&list).Error
+
+	return list, err
+}
+
+func (calendar *Calendar) AllList() ([]Calendar, error) {
+	list := make([]Calendar, 0)
+	err := Db.Order("id DESC").Find(&list).Error
+
+	return list, err
+}
+
+func (calendar *Calendar) Total(params CommonMap) (int64, error) {
+	var count int64
+	query := Db.Model(&Calendar{})
+	calendar.parseWhere(query, params)
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// 判断日历是否被任务引用
+func (calendar *Calendar) InUse(id int) (bool, error) {
+	var count int64
+	err := Db.Model(&Task{}).Where("calendar_id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// 解析where
+func (calendar *Calendar) parseWhere(query *gorm.DB, params CommonMap) {
+	if len(params) == 0 {
+		return
+	}
+	name, ok := params["Name"]
+	if ok && name.(string) != "" {
+		query.Where("name LIKE ?", "%"+name.(string)+"%")
+	}
+}
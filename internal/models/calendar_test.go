@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCalendarDates(t *testing.T) {
+	ranges, err := ParseCalendarDates("# 节假日\n2025-01-01 # 元旦\n\n 2025-01-28 ~ 2025-02-04 \n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ranges) != 2 {
+		t.Fatalf("expected 2 ranges, got %d", len(ranges))
+	}
+	if ranges[0].String() != "2025-01-01" || ranges[1].String() != "2025-01-28~2025-02-04" {
+		t.Fatalf("unexpected ranges: %v", ranges)
+	}
+
+	for _, dates := range []string{"2025/01/01", "2025-01-05~2025-01-01", "2025-01-01~x"} {
+		if _, err := ParseCalendarDates(dates); err == nil {
+			t.Fatalf("expected error for %q", dates)
+		}
+	}
+}
+
+func TestCalendarAllow(t *testing.T) {
+	calendar := Calendar{Dates: "2025-01-01\n2025-01-28~2025-02-04"}
+	holiday := time.Date(2025, 2, 4, 23, 59, 0, 0, time.Local)
+	workday := time.Date(2025, 2, 5, 9, 0, 0, 0, time.Local)
+
+	allow := func(mode TaskCalendarMode, day time.Time) bool {
+		allowed, err := calendar.Allow(mode, day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return allowed
+	}
+	if allow(TaskCalendarModeExclude, holiday) || !allow(TaskCalendarModeExclude, workday) {
+		t.Fatal("exclude mode should skip calendar dates only")
+	}
+	if !allow(TaskCalendarModeInclude, holiday) || allow(TaskCalendarModeInclude, workday) {
+		t.Fatal("include mode should run on calendar dates only")
+	}
+}
+
+func TestCalendarAllowInvalidDates(t *testing.T) {
+	calendar := Calendar{Dates: "2025-01-01\n2025-13-01"}
+	day := time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local)
+	for _, mode := range []TaskCalendarMode{TaskCalendarModeExclude, TaskCalendarModeInclude} {
+		allowed, err := calendar.Allow(mode, day)
+		if err == nil || allowed {
+			t.Fatalf("mode %d: invalid calendar should not allow run, got allowed=%v err=%v", mode, allowed, err)
+		}
+	}
+	if _, err := calendar.Contains(day); err == nil {
+		t.Fatal("expected error for invalid calendar dates")
+	}
+}
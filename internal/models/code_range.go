@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 数值范围, 用于配置退出码、HTTP状态码, 如 1,2,500-599,2xx
+type CodeRange struct {
+	Min int
+	Max int
+}
+
+type CodeRanges []CodeRange
+
+// ParseCodeRanges 解析逗号分隔的数值或范围
+func ParseCodeRanges(value string) (CodeRanges, error) {
+	ranges := make(CodeRanges, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		// 2xx 形式的状态码段
+		if len(item) == 3 && strings.HasSuffix(strings.ToLower(item), "xx") && item[0] >= '1' && item[0] <= '9' {
+			min := int(item[0]-'0') * 100
+			ranges = append(ranges, CodeRange{Min: min, Max: min + 99})
+			continue
+		}
+		fields := strings.SplitN(item, "-", 2)
+		// 负数退出码(Windows)不支持范围写法
+		if strings.HasPrefix(item, "-") {
+			fields = []string{item}
+		}
+		min, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("无效的数值: %s", item)
+		}
+		max := min
+		if len(fields) == 2 {
+			max, err = strconv.Atoi(strings.TrimSpace(fields[1]))
+			if err != nil || max < min {
+				return nil, fmt.Errorf("无效的范围: %s", item)
+			}
+		}
+		ranges = append(ranges, CodeRange{Min: min, Max: max})
+	}
+
+	return ranges, nil
+}
+
+// Contains 判断数值是否在任一范围内
+func (ranges CodeRanges) Contains(code int) bool {
+	for _, r := range ranges {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+
+	return false
+}
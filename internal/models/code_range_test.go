@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestParseCodeRanges(t *testing.T) {
+	ranges, err := ParseCodeRanges(" 1, 429 ,500-599,-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, code := range []int{1, 429, 500, 550, 599, -1} {
+		if !ranges.Contains(code) {
+			t.Fatalf("expected %d to match", code)
+		}
+	}
+	for _, code := range []int{0, 2, 499, 600} {
+		if ranges.Contains(code) {
+			t.Fatalf("expected %d not to match", code)
+		}
+	}
+
+	ranges, err = ParseCodeRanges("2xx,304")
+	if err != nil || !ranges.Contains(200) || !ranges.Contains(299) || !ranges.Contains(304) || ranges.Contains(300) {
+		t.Fatalf("unexpected ranges %v, %v", ranges, err)
+	}
+
+	for _, value := range []string{"abc", "599-500", "1-x", "0xx"} {
+		if _, err := ParseCodeRanges(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
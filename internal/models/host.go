@@ -1,18 +1,61 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+type HostSSHAuthType int8
+
+const (
+	HostSSHAuthNone       HostSSHAuthType = 0 // 未配置SSH
+	HostSSHAuthPassword   HostSSHAuthType = 1 // 密码认证
+	HostSSHAuthPrivateKey HostSSHAuthType = 2 // 私钥认证
+)
+
+// 主机在线状态, 由心跳检测更新
+type HostStatus int8
+
+const (
+	HostStatusUnknown HostStatus = 0 // 未检测
+	HostOnline        HostStatus = 1 // 在线
+	HostOffline       HostStatus = 2 // 离线
+)
+
 // 主机
 type Host struct {
-	Id        int16  `json:"id" gorm:"primaryKey;autoIncrement;type:smallint"`
-	Name      string `json:"name" gorm:"type:varchar(64);not null"`
-	Alias     string `json:"alias" gorm:"type:varchar(32);not null;default:''"`
-	Port      int    `json:"port" gorm:"not null;default:5921"`
-	Remark    string `json:"remark" gorm:"type:varchar(100);not null;default:''"`
-	BaseModel `json:"-" gorm:"-"`
-	Selected  bool `json:"-" gorm:"-"`
+	Id               int16           `json:"id" gorm:"primaryKey;autoIncrement;type:smallint"`
+	Name             string          `json:"name" gorm:"type:varchar(64);not null"`
+	Alias            string          `json:"alias" gorm:"type:varchar(32);not null;default:''"`
+	Port             int             `json:"port" gorm:"not null;default:5921"`
+	Remark           string          `json:"remark" gorm:"type:varchar(100);not null;default:''"`
+	SshPort          int             `json:"ssh_port" gorm:"not null;default:22"`
+	SshUser          string          `json:"ssh_user" gorm:"type:varchar(64);not null;default:''"`
+	SshAuthType      HostSSHAuthType `json:"ssh_auth_type" gorm:"type:tinyint;not null;default:0"`
+	SshPassword      string          `json:"-" gorm:"type:varchar(512);not null;default:''"` // 加密存储
+	SshPasswordSet   bool            `json:"ssh_password_set" gorm:"-"`
+	SshPrivateKey    string          `json:"-" gorm:"type:text"` // 加密存储
+	SshPrivateKeySet bool            `json:"ssh_private_key_set" gorm:"-"`
+	SshHostKey       string          `json:"ssh_host_key" gorm:"type:varchar(1024);not null;default:''"` // 主机公钥, 用于校验主机身份
+	Labels           string          `json:"labels" gorm:"type:varchar(512);not null;default:''"`        // 主机标签, 如role=web,env=prod, 任务可按标签选择主机
+	Status           HostStatus      `json:"status" gorm:"type:tinyint;not null;default:0"`
+	LastSeen         *time.Time      `json:"last_seen" gorm:"default:null"` // 最后一次心跳成功的时间
+	NodeVersion      string          `json:"node_version" gorm:"type:varchar(32);not null;default:''"`
+	Os               string          `json:"os" gorm:"type:varchar(32);not null;default:''"`
+	Arch             string          `json:"arch" gorm:"type:varchar(32);not null;default:''"`
+	CpuCount         int             `json:"cpu_count" gorm:"type:smallint;not null;default:0"`
+	Load1            float64         `json:"load1" gorm:"not null;default:0"`
+	Load5            float64         `json:"load5" gorm:"not null;default:0"`
+	Load15           float64         `json:"load15" gorm:"not null;default:0"`
+	MemTotal         int64           `json:"mem_total" gorm:"not null;default:0"` // 字节
+	MemAvailable     int64           `json:"mem_available" gorm:"not null;default:0"`
+	DiskTotal        int64           `json:"disk_total" gorm:"not null;default:0"`
+	DiskFree         int64           `json:"disk_free" gorm:"not null;default:0"`
+	RunningJobs      int             `json:"running_jobs" gorm:"not null;default:0"`
+	Uptime           int64           `json:"uptime" gorm:"not null;default:0"` // gocron-node已运行的秒数
+	BaseModel        `json:"-" gorm:"-"`
+	Selected         bool `json:"-" gorm:"-"`
 }
 
 // 新增
@@ -27,7 +70,8 @@ func (host *Host) Create() (insertId int16, err error) {
 
 func (host *Host) UpdateBean(id int16) (int64, error) {
 	result := Db.Model(&Host{}).Where("id = ?", id).
-		Select("name", "alias", "port", "remark").
+		Select("name", "alias", "port", "remark", "ssh_port", "ssh_user", "ssh_auth_type",
+			"ssh_password", "ssh_private_key", "ssh_host_key", "labels").
 		Updates(host)
 	return result.RowsAffected, result.Error
 }
@@ -49,7 +93,16 @@ func (host *Host) Delete(id int) (int64, error) {
 }
 
 func (host *Host) Find(id int) error {
-	return Db.First(host, id).Error
+	err := Db.First(host, id).Error
+	host.SshPasswordSet = host.SshPassword != ""
+	host.SshPrivateKeySet = host.SshPrivateKey != ""
+
+	return err
+}
+
+// HasSSH 是否配置了SSH连接信息
+func (host *Host) HasSSH() bool {
+	return host.SshAuthType != HostSSHAuthNone && host.SshUser != ""
 }
 
 func (host *Host) NameExists(name string, id int16) (bool, error) {
@@ -72,6 +125,24 @@ func (host *Host) List(params CommonMap) ([]Host, error) {
 	return list, err
 }
 
+// ListBySelector 标签匹配选择器的主机, 未设置标签的主机不参与匹配
+func (host *Host) ListBySelector(selector LabelSelector) ([]Host, error) {
+	list := make([]Host, 0)
+	err := Db.Select("id", "name", "alias", "port", "labels").Where("labels != ''").Order("id ASC").Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]Host, 0, len(list))
+	for _, item := range list {
+		labels, err := ParseHostLabels(item.Labels)
+		if err == nil && selector.Matches(labels) {
+			matched = append(matched, item)
+		}
+	}
+
+	return matched, nil
+}
+
 func (host *Host) AllList() ([]Host, error) {
 	list := make([]Host, 0)
 	err := Db.Select("name", "port").Order("id DESC").Find(&list).Error
@@ -100,4 +171,8 @@ func (host *Host) parseWhere(query *gorm.DB, params CommonMap) {
 	if ok && name.(string) != "" {
 		query.Where("name = ?", name)
 	}
+	status, ok := params["Status"]
+	if ok && status.(int) > 0 {
+		query.Where("status = ?", status)
+	}
 }
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 标签名由字母、数字及._/-组成, 以字母或数字开头和结尾, 标签值可为空
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{0,63}$`)
+)
+
+// HostLabels 主机标签, 格式为key=value, 多个标签以逗号分隔, 如role=web,env=prod
+type HostLabels map[string]string
+
+func ParseHostLabels(value string) (HostLabels, error) {
+	labels := make(HostLabels)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, labelValue, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		labelValue = strings.TrimSpace(labelValue)
+		if !ok || !labelKeyPattern.MatchString(key) || !labelValuePattern.MatchString(labelValue) {
+			return nil, fmt.Errorf("invalid label: %s", item)
+		}
+		labels[key] = labelValue
+	}
+
+	return labels, nil
+}
+
+// 按标签名排序后格式化, 用于存储
+func (labels HostLabels) String() string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	items := make([]string, len(keys))
+	for i, key := range keys {
+		items[i] = key + "=" + labels[key]
+	}
+
+	return strings.Join(items, ",")
+}
+
+type labelOperator int8
+
+const (
+	labelEquals    labelOperator = 1 // key=value
+	labelNotEquals labelOperator = 2 // key!=value, 未设置该标签的主机也匹配
+	labelExists    labelOperator = 3 // key
+)
+
+type labelRequirement struct {
+	key      string
+	operator labelOperator
+	value    string
+}
+
+// LabelSelector 主机标签选择器, 多个条件以逗号分隔, 需同时满足, 如role=web,env!=dev,gpu
+type LabelSelector []labelRequirement
+
+func ParseLabelSelector(value string) (LabelSelector, error) {
+	var selector LabelSelector
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		requirement := labelRequirement{operator: labelExists, key: item}
+		if key, labelValue, ok := strings.Cut(item, "!="); ok {
+			requirement = labelRequirement{key: key, operator: labelNotEquals, value: labelValue}
+		} else if key, labelValue, ok = strings.Cut(item, "="); ok {
+			requirement = labelRequirement{key: key, operator: labelEquals, value: labelValue}
+		}
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if !labelKeyPattern.MatchString(requirement.key) || !labelValuePattern.MatchString(requirement.value) {
+			return nil, fmt.Errorf("invalid label selector: %s", item)
+		}
+		selector = append(selector, requirement)
+	}
+	if len(selector) == 0 {
+		return nil, fmt.Errorf("label selector is empty")
+	}
+
+	return selector, nil
+}
+
+func (selector LabelSelector) Matches(labels HostLabels) bool {
+	for _, requirement := range selector {
+		value, ok := labels[requirement.key]
+		switch requirement.operator {
+		case labelEquals:
+			if !ok || value != requirement.value {
+				return false
+			}
+		case labelNotEquals:
+			if ok && value == requirement.value {
+				return false
+			}
+		case labelExists:
+			if !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,51 @@
+package models
+
+import "testing"
+
+func TestParseHostLabels(t *testing.T) {
+	labels, err := ParseHostLabels(" role=web, env = prod,zone=,role=api ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels.String() != "env=prod,role=api,zone=" {
+		t.Fatalf("unexpected labels: %s", labels.String())
+	}
+	if labels, err = ParseHostLabels(""); err != nil || len(labels) != 0 {
+		t.Fatalf("unexpected labels %v, %v", labels, err)
+	}
+
+	for _, value := range []string{"role", "=web", "role=we b", "-role=web", "role=web;rm"} {
+		if _, err := ParseHostLabels(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	selector, err := ParseLabelSelector("role=web, env!=dev,gpu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		labels string
+		match  bool
+	}{
+		{"role=web,env=prod,gpu=", true},
+		{"role=web,gpu=a100", true},
+		{"role=web,env=dev,gpu=a100", false},
+		{"role=api,env=prod,gpu=a100", false},
+		{"role=web,env=prod", false},
+	}
+	for _, tt := range tests {
+		labels, _ := ParseHostLabels(tt.labels)
+		if selector.Matches(labels) != tt.match {
+			t.Fatalf("selector match %q: expected %v", tt.labels, tt.match)
+		}
+	}
+
+	for _, value := range []string{"", " , ", "role==web", "role=we b", "!role"} {
+		if _, err := ParseLabelSelector(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
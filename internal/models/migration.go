@@ -13,7 +13,8 @@ type Migration struct{}
 func (migration *Migration) Install(dbName string) error {
 	setting := new(Setting)
 	tables := []interface{}{
-		&User{}, &Task{}, &TaskLog{}, &Host{}, setting, &LoginLog{}, &TaskHost{}, &AgentToken{},
+		&User{}, &Task{}, &TaskLog{}, &Host{}, setting, &LoginLog{}, &TaskHost{}, &AgentToken{}, &Calendar{}, &TaskLogAttempt{},
+		&TaskScriptVersion{},
 	}
 
 	for _, table := range tables {
@@ -46,7 +47,7 @@ func (migration *Migration) Upgrade(oldVersionId int) {
 		return
 	}
 
-	versionIds := []int{110, 122, 130, 140, 150, 151, 152, 153, 154}
+	versionIds := []int{110, 122, 130, 140, 150, 151, 152, 153, 154, 160}
 	upgradeFuncs := []func(*gorm.DB) error{
 		migration.upgradeFor110,
 		migration.upgradeFor122,
@@ -57,6 +58,7 @@ func (migration *Migration) Upgrade(oldVersionId int) {
 		migration.upgradeFor152,
 		migration.upgradeFor153,
 		migration.upgradeFor154,
+		migration.upgradeFor160,
 	}
 
 	startIndex := -1
@@ -460,6 +462,146 @@ func (m *Migration) upgradeFor154(tx *gorm.DB) error {
 	return nil
 }
 
+// 升级到v1.6.0版本
+func (m *Migration) upgradeFor160(tx *gorm.DB) error {
+	logger.Info("开始升级到v1.6.0")
+
+	// task表spec字段扩容, 支持配置多个cron表达式
+	if err := tx.Migrator().AlterColumn(&Task{}, "spec"); err != nil {
+		return err
+	}
+
+	// task表增加字段
+	// valid_from    有效期开始时间
+	// valid_until   有效期结束时间
+	// max_run_count 最大调度执行次数
+	// run_count     已调度执行次数
+	// calendar_id   关联日历
+	// calendar_mode 日历模式 1:排除日历中的日期 2:仅在日历中的日期执行
+	// delay_seconds  启动延迟秒数
+	// jitter_seconds 随机抖动窗口秒数
+	// retry_backoff      重试间隔策略 1:固定间隔 2:指数退避
+	// retry_multiplier   指数退避倍数
+	// retry_max_interval 重试间隔上限(秒)
+	// retry_jitter       重试间隔随机抖动(秒)
+	// retry_on           重试条件 按位组合 1:连接错误 2:超时
+	// retry_exit_codes   满足重试条件的退出码
+	// retry_status_codes 满足重试条件的HTTP状态码
+	// retry_output_regex 输出匹配该正则时重试
+	// dispatch_mode      主机分发模式 1:所有主机 2:单台健康主机
+	// http_headers       HTTP请求头 JSON
+	// http_query         HTTP查询参数 JSON
+	// http_body          HTTP请求体
+	// http_content_type  HTTP请求体类型
+	// http_auth_type     HTTP认证方式 0:无 1:Basic 2:Bearer
+	// http_auth_user     HTTP Basic认证用户名
+	// http_auth_secret   HTTP认证密码或Token, 加密存储
+	// http_success_codes HTTP成功状态码
+	// http_body_contains 响应内容需包含的字符串
+	// http_body_regex    响应内容需匹配的正则
+	// http_json_path     响应JSON路径
+	// http_json_value    响应JSON路径的期望值
+	// http_header_checks 响应头检查 JSON
+	// http_ca_cert       自定义CA证书
+	// http_client_cert   客户端证书
+	// http_client_key    客户端私钥, 加密存储
+	// http_skip_verify   跳过证书校验
+	// http_no_redirect   禁止重定向
+	// http_proxy         HTTP代理
+	// http_async         异步执行, 等待目标回调完成
+	// http_async_timeout 等待回调超时时间(秒)
+	taskColumns := []string{"valid_from", "valid_until", "max_run_count", "run_count", "calendar_id", "calendar_mode",
+		"delay_seconds", "jitter_seconds", "retry_backoff", "retry_multiplier", "retry_max_interval", "retry_jitter",
+		"retry_on", "retry_exit_codes", "retry_status_codes", "retry_output_regex", "dispatch_mode",
+		"http_headers", "http_query", "http_body", "http_content_type", "http_auth_type", "http_auth_user", "http_auth_secret",
+		"http_success_codes", "http_body_contains", "http_body_regex", "http_json_path", "http_json_value", "http_header_checks",
+		"http_ca_cert", "http_client_cert", "http_client_key", "http_skip_verify", "http_no_redirect", "http_proxy",
+		"http_async", "http_async_timeout", "sql_connection_id", "sql_max_rows", "sql_fail_on_zero",
+		"executor", "executor_config", "script_interpreter", "script", "script_version",
+		"env", "work_dir", "run_as_user", "run_as_group", "success_exit_codes", "fail_on_stderr", "notify_exit_codes",
+		"output_limit", "save_output", "stop_signal", "stop_grace_period", "detached", "host_selector"}
+	for _, column := range taskColumns {
+		if tx.Migrator().HasColumn(&Task{}, column) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(&Task{}, column); err != nil {
+			return err
+		}
+	}
+
+	// host表增加SSH连接信息及心跳检测上报的节点信息
+	hostColumns := []string{"ssh_port", "ssh_user", "ssh_auth_type", "ssh_password", "ssh_private_key", "ssh_host_key",
+		"status", "last_seen", "node_version", "os", "arch", "cpu_count", "load1", "load5", "load15",
+		"mem_total", "mem_available", "disk_total", "disk_free", "running_jobs", "uptime", "labels"}
+	for _, column := range hostColumns {
+		if tx.Migrator().HasColumn(&Host{}, column) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(&Host{}, column); err != nil {
+			return err
+		}
+	}
+
+	// agent_token表增加注册时设置的主机标签
+	if !tx.Migrator().HasColumn(&AgentToken{}, "labels") {
+		if err := tx.Migrator().AddColumn(&AgentToken{}, "labels"); err != nil {
+			return err
+		}
+	}
+
+	// 创建日历表
+	if err := tx.AutoMigrate(&Calendar{}); err != nil {
+		return err
+	}
+
+	// 创建任务执行尝试记录表
+	if err := tx.AutoMigrate(&TaskLogAttempt{}); err != nil {
+		return err
+	}
+
+	// 创建脚本版本表
+	if err := tx.AutoMigrate(&TaskScriptVersion{}); err != nil {
+		return err
+	}
+
+	// task_log表spec字段与task表保持一致, 增加启动偏移字段
+	if err := tx.Migrator().AlterColumn(&TaskLog{}, "spec"); err != nil {
+		return err
+	}
+	if !tx.Migrator().HasColumn(&TaskLog{}, "start_offset") {
+		if err := tx.Migrator().AddColumn(&TaskLog{}, "start_offset"); err != nil {
+			return err
+		}
+	}
+	// 异步HTTP任务回调令牌
+	if !tx.Migrator().HasColumn(&TaskLog{}, "callback_token") {
+		if err := tx.Migrator().AddColumn(&TaskLog{}, "callback_token"); err != nil {
+			return err
+		}
+	}
+	// 脚本任务执行时的脚本版本号
+	if !tx.Migrator().HasColumn(&TaskLog{}, "script_version") {
+		if err := tx.Migrator().AddColumn(&TaskLog{}, "script_version"); err != nil {
+			return err
+		}
+	}
+	// 命令执行的退出码、标准输出、标准错误、资源占用、完整输出文件、结束信号及执行任务的主机
+	taskLogColumns := []string{"exit_code", "stdout", "stderr", "wall_time", "user_time", "system_time", "max_rss",
+		"truncated", "output_files", "end_signal", "host_ids"}
+	for _, column := range taskLogColumns {
+		if tx.Migrator().HasColumn(&TaskLog{}, column) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(&TaskLog{}, column); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("已升级到v1.6.0\n")
+
+	return nil
+}
+
 // contains 检查字符串是否包含子串
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsMiddle(s, substr)))
@@ -488,13 +630,27 @@ func (m *Migration) fixSQLiteAutoIncrement() {
 				id INTEGER PRIMARY KEY AUTOINCREMENT,
 				task_id integer NOT NULL DEFAULT 0,
 				name varchar(32) NOT NULL,
-				spec varchar(64) NOT NULL,
+				spec varchar(512) NOT NULL,
 				protocol tinyint NOT NULL,
 				command varchar(256) NOT NULL,
 				timeout mediumint NOT NULL DEFAULT 0,
 				retry_times tinyint NOT NULL DEFAULT 0,
 				hostname varchar(128) NOT NULL DEFAULT '',
+				host_ids varchar(512) NOT NULL DEFAULT '',
 				start_time datetime,
+				start_offset integer NOT NULL DEFAULT 0,
+				callback_token varchar(64) NOT NULL DEFAULT '',
+				script_version integer NOT NULL DEFAULT 0,
+				exit_code integer,
+				stdout mediumtext,
+				stderr mediumtext,
+				wall_time integer NOT NULL DEFAULT 0,
+				user_time integer NOT NULL DEFAULT 0,
+				system_time integer NOT NULL DEFAULT 0,
+				max_rss integer NOT NULL DEFAULT 0,
+				truncated tinyint NOT NULL DEFAULT 0,
+				output_files text,
+				end_signal varchar(16) NOT NULL DEFAULT '',
 				end_time datetime,
 				status tinyint NOT NULL DEFAULT 1,
 				result mediumtext NOT NULL
@@ -516,7 +672,29 @@ func (m *Migration) fixSQLiteAutoIncrement() {
 				name varchar(64) NOT NULL,
 				alias varchar(32) NOT NULL DEFAULT '',
 				port integer NOT NULL DEFAULT 5921,
-				remark varchar(100) NOT NULL DEFAULT ''
+				remark varchar(100) NOT NULL DEFAULT '',
+				ssh_port integer NOT NULL DEFAULT 22,
+				ssh_user varchar(64) NOT NULL DEFAULT '',
+				ssh_auth_type tinyint NOT NULL DEFAULT 0,
+				ssh_password varchar(512) NOT NULL DEFAULT '',
+				ssh_private_key text,
+				ssh_host_key varchar(1024) NOT NULL DEFAULT '',
+				status tinyint NOT NULL DEFAULT 0,
+				last_seen datetime,
+				node_version varchar(32) NOT NULL DEFAULT '',
+				os varchar(32) NOT NULL DEFAULT '',
+				arch varchar(32) NOT NULL DEFAULT '',
+				cpu_count smallint NOT NULL DEFAULT 0,
+				load1 real NOT NULL DEFAULT 0,
+				load5 real NOT NULL DEFAULT 0,
+				load15 real NOT NULL DEFAULT 0,
+				mem_total integer NOT NULL DEFAULT 0,
+				mem_available integer NOT NULL DEFAULT 0,
+				disk_total integer NOT NULL DEFAULT 0,
+				disk_free integer NOT NULL DEFAULT 0,
+				running_jobs integer NOT NULL DEFAULT 0,
+				uptime integer NOT NULL DEFAULT 0,
+				labels varchar(512) NOT NULL DEFAULT ''
 			);
 		`)
 		Db.Exec(`DROP TABLE host;`)
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -155,6 +156,27 @@ func CreateTmpDb(setting *setting.Setting) (*gorm.DB, error) {
 	return gorm.Open(dialector, &gorm.Config{})
 }
 
+// OpenSQLConnection 打开SQL任务使用的数据库连接, 复用gocron已链接的数据库驱动
+func OpenSQLConnection(engine, dsn string) (*sql.DB, error) {
+	var dialector gorm.Dialector
+	switch strings.ToLower(engine) {
+	case "mysql":
+		dialector = mysql.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	case "sqlite":
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", engine)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+
+	return db.DB()
+}
+
 // 获取数据库引擎DSN  mysql,postgres
 func getDbEngineDSN(setting *setting.Setting) string {
 	engine := strings.ToLower(setting.Db.Engine)
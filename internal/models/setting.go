@@ -53,13 +53,22 @@ const (
 	WebhookUrlKey      = "url"
 )
 
+const (
+	SQLCode          = "sql"
+	SQLConnectionKey = "connection"
+)
+
 const (
 	SystemCode          = "system"
 	LogRetentionDaysKey = "log_retention_days"
 	LogCleanupTimeKey   = "log_cleanup_time"
 	LogFileSizeLimitKey = "log_file_size_limit"
+	HostAlertKey        = "host_alert"
 )
 
+// 未配置时主机连续心跳检测失败多少次后标记为离线
+const defaultHostOfflineThreshold = 3
+
 // region slack配置
 
 type Slack struct {
@@ -250,6 +259,103 @@ func (setting *Setting) UpdateWebHook(url, template string) error {
 
 // endregion
 
+// region SQL连接配置
+
+// SQL任务使用的数据库连接
+type SQLConnection struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Engine string `json:"engine"`
+	Dsn    string `json:"-"` // 加密存储
+	DsnSet bool   `json:"dsn_set"`
+}
+
+// 存储在setting表中的连接配置
+type sqlConnectionValue struct {
+	Name   string `json:"name"`
+	Engine string `json:"engine"`
+	Dsn    string `json:"dsn"`
+}
+
+func (setting *Setting) SQLConnections() ([]SQLConnection, error) {
+	list := make([]Setting, 0)
+	err := Db.Where("code = ? AND `key` = ?", SQLCode, SQLConnectionKey).Order("id ASC").Find(&list).Error
+	connections := make([]SQLConnection, 0, len(list))
+	if err != nil {
+		return connections, err
+	}
+	for _, v := range list {
+		connections = append(connections, setting.formatSQLConnection(v))
+	}
+
+	return connections, nil
+}
+
+func (setting *Setting) SQLConnection(id int) (SQLConnection, error) {
+	var s Setting
+	err := Db.Where("code = ? AND `key` = ? AND id = ?", SQLCode, SQLConnectionKey, id).First(&s).Error
+	if err != nil {
+		return SQLConnection{}, err
+	}
+
+	return setting.formatSQLConnection(s), nil
+}
+
+func (setting *Setting) formatSQLConnection(s Setting) SQLConnection {
+	value := sqlConnectionValue{}
+	if s.Value != "" {
+		_ = json.Unmarshal([]byte(s.Value), &value)
+	}
+
+	return SQLConnection{
+		Id:     s.Id,
+		Name:   value.Name,
+		Engine: value.Engine,
+		Dsn:    value.Dsn,
+		DsnSet: value.Dsn != "",
+	}
+}
+
+func (setting *Setting) IsSQLConnectionNameExist(name string, id int) (bool, error) {
+	connections, err := setting.SQLConnections()
+	if err != nil {
+		return false, err
+	}
+	for _, connection := range connections {
+		if connection.Name == name && connection.Id != id {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// 新增或更新SQL连接, id为0时新增
+func (setting *Setting) SaveSQLConnection(id int, connection SQLConnection) (int, error) {
+	jsonByte, err := json.Marshal(sqlConnectionValue{connection.Name, connection.Engine, connection.Dsn})
+	if err != nil {
+		return 0, err
+	}
+	if id > 0 {
+		result := Db.Model(&Setting{}).Where("code = ? AND `key` = ? AND id = ?", SQLCode, SQLConnectionKey, id).
+			Update("value", string(jsonByte))
+		return id, result.Error
+	}
+	setting.Code = SQLCode
+	setting.Key = SQLConnectionKey
+	setting.Value = string(jsonByte)
+	result := Db.Create(setting)
+
+	return setting.Id, result.Error
+}
+
+func (setting *Setting) RemoveSQLConnection(id int) (int64, error) {
+	result := Db.Where("code = ? AND `key` = ? AND id = ?", SQLCode, SQLConnectionKey, id).Delete(&Setting{})
+	return result.RowsAffected, result.Error
+}
+
+// endregion
+
 // region 系统配置
 func (setting *Setting) GetLogRetentionDays() int {
 	var s Setting
@@ -339,4 +445,43 @@ func (setting *Setting) UpdateLogFileSizeLimit(size int) error {
 	return result.Error
 }
 
+// HostAlert 主机离线及恢复通知配置, NotifyType与任务通知一致, 0不发送通知
+type HostAlert struct {
+	Threshold        int    `json:"threshold"` // 连续心跳检测失败多少次后标记为离线
+	NotifyType       int8   `json:"notify_type"`
+	NotifyReceiverId string `json:"notify_receiver_id"`
+}
+
+func (setting *Setting) GetHostAlert() HostAlert {
+	alert := HostAlert{Threshold: defaultHostOfflineThreshold}
+	var s Setting
+	err := Db.Where("code = ? AND `key` = ?", SystemCode, HostAlertKey).First(&s).Error
+	if err == nil && s.Value != "" {
+		_ = json.Unmarshal([]byte(s.Value), &alert)
+	}
+	if alert.Threshold <= 0 {
+		alert.Threshold = defaultHostOfflineThreshold
+	}
+
+	return alert
+}
+
+func (setting *Setting) UpdateHostAlert(alert HostAlert) error {
+	jsonByte, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	var s Setting
+	err = Db.Where("code = ? AND `key` = ?", SystemCode, HostAlertKey).First(&s).Error
+	if err != nil {
+		s.Code = SystemCode
+		s.Key = HostAlertKey
+		s.Value = string(jsonByte)
+		result := Db.Create(&s)
+		return result.Error
+	}
+	result := Db.Model(&Setting{}).Where("code = ? AND `key` = ?", SystemCode, HostAlertKey).Update("value", string(jsonByte))
+	return result.Error
+}
+
 // endregion
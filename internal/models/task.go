@@ -3,6 +3,9 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,8 +16,12 @@ import (
 type TaskProtocol int8
 
 const (
-	TaskHTTP TaskProtocol = iota + 1 // HTTP协议
-	TaskRPC                          // RPC方式执行命令
+	TaskHTTP     TaskProtocol = iota + 1 // HTTP协议
+	TaskRPC                              // RPC方式执行命令
+	TaskLocal                            // 在gocron服务端本机执行命令
+	TaskSSH                              // 通过SSH在主机上执行命令
+	TaskSQL                              // 在配置的数据库连接上执行SQL
+	TaskExecutor                         // 通过注册的扩展执行器执行
 )
 
 type TaskLevel int8
@@ -34,8 +41,105 @@ const (
 type TaskHTTPMethod int8
 
 const (
-	TaskHTTPMethodGet  TaskHTTPMethod = 1
-	TaskHttpMethodPost TaskHTTPMethod = 2
+	TaskHTTPMethodGet    TaskHTTPMethod = 1
+	TaskHttpMethodPost   TaskHTTPMethod = 2
+	TaskHTTPMethodPut    TaskHTTPMethod = 3
+	TaskHTTPMethodPatch  TaskHTTPMethod = 4
+	TaskHTTPMethodDelete TaskHTTPMethod = 5
+	TaskHTTPMethodHead   TaskHTTPMethod = 6
+)
+
+// String 对应的HTTP请求方法
+func (method TaskHTTPMethod) String() string {
+	switch method {
+	case TaskHttpMethodPost:
+		return http.MethodPost
+	case TaskHTTPMethodPut:
+		return http.MethodPut
+	case TaskHTTPMethodPatch:
+		return http.MethodPatch
+	case TaskHTTPMethodDelete:
+		return http.MethodDelete
+	case TaskHTTPMethodHead:
+		return http.MethodHead
+	default:
+		return http.MethodGet
+	}
+}
+
+type TaskHTTPAuthType int8
+
+const (
+	TaskHTTPAuthNone   TaskHTTPAuthType = 0
+	TaskHTTPAuthBasic  TaskHTTPAuthType = 1
+	TaskHTTPAuthBearer TaskHTTPAuthType = 2
+)
+
+// HTTP请求头或查询参数
+type HTTPParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ParseHTTPParams 解析JSON格式的请求头或查询参数, 如 [{"name":"X-Token","value":"abc"}]
+func ParseHTTPParams(value string) ([]HTTPParam, error) {
+	params := make([]HTTPParam, 0)
+	if strings.TrimSpace(value) == "" {
+		return params, nil
+	}
+	if err := json.Unmarshal([]byte(value), &params); err != nil {
+		return nil, err
+	}
+	for _, param := range params {
+		if strings.TrimSpace(param.Name) == "" {
+			return nil, errors.New("参数名称不能为空")
+		}
+	}
+
+	return params, nil
+}
+
+type TaskDispatchMode int8
+
+const (
+	TaskDispatchAll    TaskDispatchMode = 1 // 在所有主机上执行
+	TaskDispatchSingle TaskDispatchMode = 2 // 选择一台健康主机执行, 不可达时切换到其他主机
+)
+
+type TaskRetryBackoff int8
+
+const (
+	TaskRetryBackoffFixed       TaskRetryBackoff = 1 // 固定间隔, 未设置间隔时每次递增1分钟
+	TaskRetryBackoffExponential TaskRetryBackoff = 2 // 指数退避
+)
+
+// 重试条件, 按位组合, 与退出码、状态码、输出正则任一满足即重试, 均未设置时任何错误都重试
+type TaskRetryOn int8
+
+const (
+	TaskRetryOnConnectionError TaskRetryOn = 1 << iota // 连接错误
+	TaskRetryOnTimeout                                 // 执行超时
+)
+
+// 多个cron表达式之间的分隔符
+const TaskSpecSeparator = ";"
+
+// 单个任务最多允许配置的cron表达式数量
+const TaskMaxSpecs = 10
+
+// 启动延迟、随机抖动窗口最大秒数
+const TaskMaxStartOffset = 3600
+
+// 异步HTTP任务等待回调的默认及最大超时时间(秒)
+const (
+	TaskDefaultAsyncTimeout = 3600
+	TaskMaxAsyncTimeout     = 86400
+)
+
+// SQL任务默认及最多记录的结果行数
+const (
+	TaskDefaultSQLMaxRows = 10
+	TaskMaxSQLMaxRows     = 1000
 )
 
 // NextRunTime 自定义时间类型，零值时序列化为空字符串
@@ -68,31 +172,92 @@ func (t *NextRunTime) UnmarshalJSON(data []byte) error {
 
 // 任务
 type Task struct {
-	Id               int                  `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name             string               `json:"name" gorm:"type:varchar(32);not null"`
-	Level            TaskLevel            `json:"level" gorm:"type:tinyint;not null;index;default:1"`
-	DependencyTaskId string               `json:"dependency_task_id" gorm:"type:varchar(64);not null;default:''"`
-	DependencyStatus TaskDependencyStatus `json:"dependency_status" gorm:"type:tinyint;not null;default:1"`
-	Spec             string               `json:"spec" gorm:"type:varchar(64);not null"`
-	Protocol         TaskProtocol         `json:"protocol" gorm:"type:tinyint;not null;index"`
-	Command          string               `json:"command" gorm:"type:varchar(256);not null"`
-	HttpMethod       TaskHTTPMethod       `json:"http_method" gorm:"type:tinyint;not null;default:1"`
-	Timeout          int                  `json:"timeout" gorm:"type:mediumint;not null;default:0"`
-	Multi            int8                 `json:"multi" gorm:"type:tinyint;not null;default:1"`
-	RetryTimes       int8                 `json:"retry_times" gorm:"type:tinyint;not null;default:0"`
-	RetryInterval    int16                `json:"retry_interval" gorm:"type:smallint;not null;default:0"`
-	NotifyStatus     int8                 `json:"notify_status" gorm:"type:tinyint;not null;default:1"`
-	NotifyType       int8                 `json:"notify_type" gorm:"type:tinyint;not null;default:0"`
-	NotifyReceiverId string               `json:"notify_receiver_id" gorm:"type:varchar(256);not null;default:''"`
-	NotifyKeyword    string               `json:"notify_keyword" gorm:"type:varchar(128);not null;default:''"`
-	Tag              string               `json:"tag" gorm:"type:varchar(32);not null;default:''"`
-	Remark           string               `json:"remark" gorm:"type:varchar(100);not null;default:''"`
-	Status           Status               `json:"status" gorm:"type:tinyint;not null;index;default:0"`
-	CreatedAt        time.Time            `json:"created" gorm:"column:created;autoCreateTime"`
-	DeletedAt        *time.Time           `json:"deleted" gorm:"column:deleted;index"`
-	BaseModel        `json:"-" gorm:"-"`
-	Hosts            []TaskHostDetail `json:"hosts" gorm:"-"`
-	NextRunTime      NextRunTime      `json:"next_run_time" gorm:"-"`
+	Id                int                  `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name              string               `json:"name" gorm:"type:varchar(32);not null"`
+	Level             TaskLevel            `json:"level" gorm:"type:tinyint;not null;index;default:1"`
+	DependencyTaskId  string               `json:"dependency_task_id" gorm:"type:varchar(64);not null;default:''"`
+	DependencyStatus  TaskDependencyStatus `json:"dependency_status" gorm:"type:tinyint;not null;default:1"`
+	Spec              string               `json:"spec" gorm:"type:varchar(512);not null"`
+	Protocol          TaskProtocol         `json:"protocol" gorm:"type:tinyint;not null;index"`
+	Command           string               `json:"command" gorm:"type:varchar(256);not null"`
+	HttpMethod        TaskHTTPMethod       `json:"http_method" gorm:"type:tinyint;not null;default:1"`
+	HttpHeaders       string               `json:"http_headers" gorm:"type:varchar(2048);not null;default:''"`
+	HttpQuery         string               `json:"http_query" gorm:"type:varchar(1024);not null;default:''"`
+	HttpBody          string               `json:"http_body" gorm:"type:text"`
+	HttpContentType   string               `json:"http_content_type" gorm:"type:varchar(128);not null;default:''"`
+	HttpAuthType      TaskHTTPAuthType     `json:"http_auth_type" gorm:"type:tinyint;not null;default:0"`
+	HttpAuthUser      string               `json:"http_auth_user" gorm:"type:varchar(64);not null;default:''"`
+	HttpAuthSecret    string               `json:"-" gorm:"type:varchar(512);not null;default:''"` // 加密存储
+	HttpAuthSecretSet bool                 `json:"http_auth_secret_set" gorm:"-"`
+	HttpCaCert        string               `json:"http_ca_cert" gorm:"type:text"`
+	HttpClientCert    string               `json:"http_client_cert" gorm:"type:text"`
+	HttpClientKey     string               `json:"-" gorm:"type:text"` // 加密存储
+	HttpClientKeySet  bool                 `json:"http_client_key_set" gorm:"-"`
+	HttpSkipVerify    int8                 `json:"http_skip_verify" gorm:"type:tinyint;not null;default:0"`
+	HttpNoRedirect    int8                 `json:"http_no_redirect" gorm:"type:tinyint;not null;default:0"`
+	HttpProxy         string               `json:"http_proxy" gorm:"type:varchar(256);not null;default:''"`
+	HttpAsync         int8                 `json:"http_async" gorm:"type:tinyint;not null;default:0"`           // 异步执行, 等待目标回调完成
+	HttpAsyncTimeout  int                  `json:"http_async_timeout" gorm:"type:mediumint;not null;default:0"` // 等待回调超时时间(秒)
+	HttpSuccessCodes  string               `json:"http_success_codes" gorm:"type:varchar(128);not null;default:''"`
+	HttpBodyContains  string               `json:"http_body_contains" gorm:"type:varchar(256);not null;default:''"`
+	HttpBodyRegex     string               `json:"http_body_regex" gorm:"type:varchar(256);not null;default:''"`
+	HttpJsonPath      string               `json:"http_json_path" gorm:"type:varchar(128);not null;default:''"`
+	HttpJsonValue     string               `json:"http_json_value" gorm:"type:varchar(256);not null;default:''"`
+	HttpHeaderChecks  string               `json:"http_header_checks" gorm:"type:varchar(1024);not null;default:''"`
+	SqlConnectionId   int                  `json:"sql_connection_id" gorm:"not null;default:0"`
+	SqlMaxRows        int                  `json:"sql_max_rows" gorm:"type:smallint;not null;default:0"`           // 查询结果最多记录的行数
+	SqlFailOnZero     int8                 `json:"sql_fail_on_zero" gorm:"type:tinyint;not null;default:0"`        // 返回或影响行数为0时视为失败
+	ScriptInterpreter string               `json:"script_interpreter" gorm:"type:varchar(16);not null;default:''"` // 脚本解释器, 为空时执行命令
+	Script            string               `json:"script" gorm:"type:text"`
+	ScriptVersion     int                  `json:"script_version" gorm:"not null;default:0"`              // 当前脚本版本号
+	Executor          string               `json:"executor" gorm:"type:varchar(64);not null;default:''"`  // 扩展执行器名称
+	ExecutorConfig    string               `json:"executor_config" gorm:"type:text"`                      // 扩展执行器配置, JSON对象
+	Env               string               `json:"env" gorm:"type:text"`                                  // 环境变量, 每行一个KEY=VALUE
+	WorkDir           string               `json:"work_dir" gorm:"type:varchar(256);not null;default:''"` // 工作目录
+	RunAsUser         string               `json:"run_as_user" gorm:"type:varchar(32);not null;default:''"`
+	RunAsGroup        string               `json:"run_as_group" gorm:"type:varchar(32);not null;default:''"`
+	SuccessExitCodes  string               `json:"success_exit_codes" gorm:"type:varchar(128);not null;default:''"` // 视为成功的非0退出码
+	FailOnStderr      int8                 `json:"fail_on_stderr" gorm:"type:tinyint;not null;default:0"`           // 标准错误不为空时视为失败
+	OutputLimit       int                  `json:"output_limit" gorm:"not null;default:0"`                          // 输出保留的最大KB, 超过时保留开头和结尾, 0使用默认值
+	SaveOutput        int8                 `json:"save_output" gorm:"type:tinyint;not null;default:0"`              // 完整输出保存到gocron-node上的文件
+	StopSignal        string               `json:"stop_signal" gorm:"type:varchar(16);not null;default:''"`         // 超时或停止时先发送的信号, 为空时为SIGTERM
+	StopGracePeriod   int                  `json:"stop_grace_period" gorm:"type:smallint;not null;default:0"`       // 发送停止信号后等待的秒数, 超过后发送SIGKILL, 0使用默认值
+	Detached          int8                 `json:"detached" gorm:"type:tinyint;not null;default:0"`                 // 与gocron-node的连接中断后继续执行, 重新连接后获取执行结果
+	HostSelector      string               `json:"host_selector" gorm:"type:varchar(256);not null;default:''"`      // 主机标签选择器, 执行时匹配的主机与关联的主机一起执行
+	Timeout           int                  `json:"timeout" gorm:"type:mediumint;not null;default:0"`
+	Multi             int8                 `json:"multi" gorm:"type:tinyint;not null;default:1"`
+	RetryTimes        int8                 `json:"retry_times" gorm:"type:tinyint;not null;default:0"`
+	RetryInterval     int16                `json:"retry_interval" gorm:"type:smallint;not null;default:0"`
+	NotifyStatus      int8                 `json:"notify_status" gorm:"type:tinyint;not null;default:1"`
+	NotifyType        int8                 `json:"notify_type" gorm:"type:tinyint;not null;default:0"`
+	NotifyReceiverId  string               `json:"notify_receiver_id" gorm:"type:varchar(256);not null;default:''"`
+	NotifyKeyword     string               `json:"notify_keyword" gorm:"type:varchar(128);not null;default:''"`
+	NotifyExitCodes   string               `json:"notify_exit_codes" gorm:"type:varchar(128);not null;default:''"` // 退出码匹配时通知
+	Tag               string               `json:"tag" gorm:"type:varchar(32);not null;default:''"`
+	Remark            string               `json:"remark" gorm:"type:varchar(100);not null;default:''"`
+	ValidFrom         *LocalTime           `json:"valid_from" gorm:"column:valid_from"`
+	ValidUntil        *LocalTime           `json:"valid_until" gorm:"column:valid_until"`
+	MaxRunCount       int                  `json:"max_run_count" gorm:"not null;default:0"`
+	RunCount          int                  `json:"run_count" gorm:"not null;default:0"`
+	CalendarId        int                  `json:"calendar_id" gorm:"not null;default:0"`
+	CalendarMode      TaskCalendarMode     `json:"calendar_mode" gorm:"type:tinyint;not null;default:1"`
+	DelaySeconds      int                  `json:"delay_seconds" gorm:"type:mediumint;not null;default:0"`
+	JitterSeconds     int                  `json:"jitter_seconds" gorm:"type:mediumint;not null;default:0"`
+	DispatchMode      TaskDispatchMode     `json:"dispatch_mode" gorm:"type:tinyint;not null;default:1"`
+	RetryBackoff      TaskRetryBackoff     `json:"retry_backoff" gorm:"type:tinyint;not null;default:1"`
+	RetryMultiplier   float64              `json:"retry_multiplier" gorm:"not null;default:2"`
+	RetryMaxInterval  int                  `json:"retry_max_interval" gorm:"type:mediumint;not null;default:0"`
+	RetryJitter       int                  `json:"retry_jitter" gorm:"type:mediumint;not null;default:0"`
+	RetryOn           TaskRetryOn          `json:"retry_on" gorm:"type:tinyint;not null;default:0"`
+	RetryExitCodes    string               `json:"retry_exit_codes" gorm:"type:varchar(128);not null;default:''"`
+	RetryStatusCodes  string               `json:"retry_status_codes" gorm:"type:varchar(128);not null;default:''"`
+	RetryOutputRegex  string               `json:"retry_output_regex" gorm:"type:varchar(256);not null;default:''"`
+	Status            Status               `json:"status" gorm:"type:tinyint;not null;index;default:0"`
+	CreatedAt         time.Time            `json:"created" gorm:"column:created;autoCreateTime"`
+	DeletedAt         *time.Time           `json:"deleted" gorm:"column:deleted;index"`
+	BaseModel         `json:"-" gorm:"-"`
+	Hosts             []TaskHostDetail `json:"hosts" gorm:"-"`
+	NextRunTime       NextRunTime      `json:"next_run_time" gorm:"-"`
 }
 
 // 新增
@@ -110,7 +275,20 @@ func (task *Task) UpdateBean(id int) (int64, error) {
 		Select("name", "spec", "protocol", "command", "timeout", "multi",
 			"retry_times", "retry_interval", "remark", "notify_status",
 			"notify_type", "notify_receiver_id", "dependency_task_id",
-			"dependency_status", "tag", "http_method", "notify_keyword").
+			"dependency_status", "tag", "http_method", "notify_keyword",
+			"valid_from", "valid_until", "max_run_count", "calendar_id", "calendar_mode",
+			"delay_seconds", "jitter_seconds", "retry_backoff", "retry_multiplier",
+			"retry_max_interval", "retry_jitter", "retry_on", "retry_exit_codes",
+			"retry_status_codes", "retry_output_regex", "dispatch_mode",
+			"http_headers", "http_query", "http_body", "http_content_type",
+			"http_auth_type", "http_auth_user", "http_auth_secret", "http_success_codes",
+			"http_body_contains", "http_body_regex", "http_json_path", "http_json_value",
+			"http_header_checks", "http_ca_cert", "http_client_cert", "http_client_key",
+			"http_skip_verify", "http_no_redirect", "http_proxy", "http_async", "http_async_timeout",
+			"sql_connection_id", "sql_max_rows", "sql_fail_on_zero", "executor", "executor_config",
+			"script_interpreter", "script", "env", "work_dir", "run_as_user", "run_as_group",
+			"success_exit_codes", "fail_on_stderr", "notify_exit_codes", "output_limit", "save_output",
+			"stop_signal", "stop_grace_period", "detached", "host_selector").
 		Updates(task)
 	return result.RowsAffected, result.Error
 }
@@ -125,6 +303,184 @@ func (task *Task) Update(id int, data CommonMap) (int64, error) {
 	return result.RowsAffected, result.Error
 }
 
+// 调度执行次数加1, 返回累计执行次数
+func (task *Task) IncrRunCount(id int) (int, error) {
+	err := Db.Model(&Task{}).Where("id = ?", id).
+		UpdateColumn("run_count", gorm.Expr("run_count + ?", 1)).Error
+	if err != nil {
+		return 0, err
+	}
+	var runCount int
+	err = Db.Model(&Task{}).Where("id = ?", id).Select("run_count").Scan(&runCount).Error
+
+	return runCount, err
+}
+
+// Specs 解析任务配置的所有cron表达式
+func (task *Task) Specs() []string {
+	spec := strings.ReplaceAll(task.Spec, "\n", TaskSpecSeparator)
+	specs := make([]string, 0)
+	for _, item := range strings.Split(spec, TaskSpecSeparator) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			specs = append(specs, item)
+		}
+	}
+
+	return specs
+}
+
+// InValidPeriod 判断给定时间是否处于任务有效期内
+func (task *Task) InValidPeriod(t time.Time) bool {
+	if task.ValidFrom != nil && t.Before(time.Time(*task.ValidFrom)) {
+		return false
+	}
+	if task.ValidUntil != nil && t.After(time.Time(*task.ValidUntil)) {
+		return false
+	}
+
+	return true
+}
+
+// Expired 任务有效期已结束
+func (task *Task) Expired(t time.Time) bool {
+	return task.ValidUntil != nil && t.After(time.Time(*task.ValidUntil))
+}
+
+// RunCountExhausted 调度执行次数已达到上限
+func (task *Task) RunCountExhausted() bool {
+	return task.MaxRunCount > 0 && task.RunCount >= task.MaxRunCount
+}
+
+// HasHTTPOptions 是否配置了请求头、查询参数、请求体、认证或传输等HTTP选项
+func (task *Task) HasHTTPOptions() bool {
+	return task.HttpHeaders != "" || task.HttpQuery != "" || task.HttpBody != "" ||
+		task.HttpContentType != "" || task.HttpAuthType != TaskHTTPAuthNone ||
+		(task.HttpMethod != TaskHTTPMethodGet && task.HttpMethod != TaskHttpMethodPost) ||
+		task.HttpCaCert != "" || task.HttpClientCert != "" || task.HttpSkipVerify > 0 ||
+		task.HttpNoRedirect > 0 || task.HttpProxy != "" || task.HttpAsync > 0
+}
+
+// IsHTTPAsync 是否为等待回调完成的异步HTTP任务
+func (task *Task) IsHTTPAsync() bool {
+	return task.Protocol == TaskHTTP && task.HttpAsync > 0
+}
+
+// AsyncTimeout 等待回调的超时时间
+func (task *Task) AsyncTimeout() time.Duration {
+	timeout := task.HttpAsyncTimeout
+	if timeout <= 0 || timeout > TaskMaxAsyncTimeout {
+		timeout = TaskDefaultAsyncTimeout
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// UseHosts 是否在关联的主机上执行
+func (task *Task) UseHosts() bool {
+	return task.Protocol == TaskRPC || task.Protocol == TaskSSH
+}
+
+// ResolveHosts 按主机标签选择器匹配主机, 与关联的主机合并, 执行时调用以包含后续新增的主机
+func (task *Task) ResolveHosts() error {
+	if task.HostSelector == "" || !task.UseHosts() {
+		return nil
+	}
+	selector, err := ParseLabelSelector(task.HostSelector)
+	if err != nil {
+		return err
+	}
+	hostModel := new(Host)
+	hosts, err := hostModel.ListBySelector(selector)
+	if err != nil {
+		return err
+	}
+	task.Hosts = mergeTaskHosts(task.Id, task.Hosts, hosts)
+
+	return nil
+}
+
+// 合并关联的主机与标签匹配的主机, 同一主机只保留一次
+func mergeTaskHosts(taskId int, taskHosts []TaskHostDetail, hosts []Host) []TaskHostDetail {
+	merged := make([]TaskHostDetail, 0, len(taskHosts)+len(hosts))
+	exists := make(map[int16]bool)
+	for _, item := range taskHosts {
+		exists[item.HostId] = true
+		merged = append(merged, item)
+	}
+	for _, host := range hosts {
+		if exists[host.Id] {
+			continue
+		}
+		exists[host.Id] = true
+		merged = append(merged, TaskHostDetail{
+			TaskHost: TaskHost{TaskId: taskId, HostId: host.Id},
+			Name:     host.Name,
+			Port:     host.Port,
+			Alias:    host.Alias,
+		})
+	}
+
+	return merged
+}
+
+// IsScript 是否为脚本任务, 执行时将脚本写入临时文件后使用解释器执行, 命令作为脚本参数
+func (task *Task) IsScript() bool {
+	return task.ScriptInterpreter != "" && task.Script != ""
+}
+
+// 环境变量名称
+var taskEnvNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseTaskEnv 解析每行一个KEY=VALUE的环境变量, 忽略空行及#开头的注释
+func ParseTaskEnv(value string) ([]string, error) {
+	var env []string
+	for i, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, _, ok := strings.Cut(line, "=")
+		if !ok || !taskEnvNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("第%d行环境变量格式错误, 应为KEY=VALUE", i+1)
+		}
+		env = append(env, line)
+	}
+
+	return env, nil
+}
+
+// SQLMaxRows 查询结果最多记录的行数
+func (task *Task) SQLMaxRows() int {
+	if task.SqlMaxRows <= 0 {
+		return TaskDefaultSQLMaxRows
+	}
+	if task.SqlMaxRows > TaskMaxSQLMaxRows {
+		return TaskMaxSQLMaxRows
+	}
+
+	return task.SqlMaxRows
+}
+
+// SQLConnectionInUse 判断SQL连接是否被任务引用
+func (task *Task) SQLConnectionInUse(connectionId int) (bool, error) {
+	var count int64
+	err := Db.Model(&Task{}).Where("sql_connection_id = ?", connectionId).Count(&count).Error
+	return count > 0, err
+}
+
+// HasHTTPAssertions 是否配置了HTTP成功条件
+func (task *Task) HasHTTPAssertions() bool {
+	return task.HttpSuccessCodes != "" || task.HttpBodyContains != "" || task.HttpBodyRegex != "" ||
+		task.HttpJsonPath != "" || task.HttpHeaderChecks != ""
+}
+
+// HasRetryCondition 是否配置了重试条件
+func (task *Task) HasRetryCondition() bool {
+	return task.RetryOn != 0 || strings.TrimSpace(task.RetryExitCodes) != "" ||
+		strings.TrimSpace(task.RetryStatusCodes) != "" || task.RetryOutputRegex != ""
+}
+
 // 删除
 func (task *Task) Delete(id int) (int64, error) {
 	result := Db.Delete(&Task{}, id)
@@ -241,6 +597,8 @@ func (task *Task) Detail(id int) (Task, error) {
 		return t, err
 	}
 
+	t.HttpAuthSecretSet = t.HttpAuthSecret != ""
+	t.HttpClientKeySet = t.HttpClientKey != ""
 	taskHostModel := new(TaskHost)
 	t.Hosts, err = taskHostModel.GetHostIdsByTaskId(id)
 
@@ -2,7 +2,10 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -45,21 +48,90 @@ type TaskType int8
 
 // 任务执行日志
 type TaskLog struct {
-	Id         int64        `json:"id" gorm:"primaryKey;autoIncrement;type:bigint"`
-	TaskId     int          `json:"task_id" gorm:"not null;index;default:0"`
-	Name       string       `json:"name" gorm:"type:varchar(32);not null"`
-	Spec       string       `json:"spec" gorm:"type:varchar(64);not null"`
-	Protocol   TaskProtocol `json:"protocol" gorm:"type:tinyint;not null;index"`
-	Command    string       `json:"command" gorm:"type:varchar(256);not null"`
-	Timeout    int          `json:"timeout" gorm:"type:mediumint;not null;default:0"`
-	RetryTimes int8         `json:"retry_times" gorm:"type:tinyint;not null;default:0"`
-	Hostname   string       `json:"hostname" gorm:"type:varchar(128);not null;default:''"`
-	StartTime  LocalTime    `json:"start_time" gorm:"column:start_time;autoCreateTime"`
-	EndTime    LocalTime    `json:"end_time" gorm:"column:end_time;autoUpdateTime"`
-	Status     Status       `json:"status" gorm:"type:tinyint;not null;index;default:1"`
-	Result     string       `json:"result" gorm:"type:mediumtext;not null"`
-	TotalTime  int          `json:"total_time" gorm:"-"`
-	BaseModel  `json:"-" gorm:"-"`
+	Id            int64        `json:"id" gorm:"primaryKey;autoIncrement;type:bigint"`
+	TaskId        int          `json:"task_id" gorm:"not null;index;default:0"`
+	Name          string       `json:"name" gorm:"type:varchar(32);not null"`
+	Spec          string       `json:"spec" gorm:"type:varchar(512);not null"`
+	Protocol      TaskProtocol `json:"protocol" gorm:"type:tinyint;not null;index"`
+	Command       string       `json:"command" gorm:"type:varchar(256);not null"`
+	Timeout       int          `json:"timeout" gorm:"type:mediumint;not null;default:0"`
+	RetryTimes    int8         `json:"retry_times" gorm:"type:tinyint;not null;default:0"`
+	Hostname      string       `json:"hostname" gorm:"type:varchar(128);not null;default:''"`
+	HostIds       string       `json:"host_ids" gorm:"type:varchar(512);not null;default:''"` // 执行任务的主机ID, 逗号分隔
+	StartTime     LocalTime    `json:"start_time" gorm:"column:start_time;autoCreateTime"`
+	StartOffset   int          `json:"start_offset" gorm:"not null;default:0"` // 启动偏移(毫秒), 固定延迟加随机抖动
+	EndTime       LocalTime    `json:"end_time" gorm:"column:end_time;autoUpdateTime"`
+	Status        Status       `json:"status" gorm:"type:tinyint;not null;index;default:1"`
+	Result        string       `json:"result" gorm:"type:mediumtext;not null"`
+	CallbackToken string       `json:"-" gorm:"type:varchar(64);not null;default:''"` // 异步HTTP任务回调令牌的SHA-256摘要
+	ScriptVersion int          `json:"script_version" gorm:"not null;default:0"`      // 脚本任务执行时的脚本版本号
+	ExitCode      *int         `json:"exit_code"`                                     // 退出码, 未返回退出码的任务为空
+	Stdout        string       `json:"stdout" gorm:"type:mediumtext"`
+	Stderr        string       `json:"stderr" gorm:"type:mediumtext"`
+	WallTime      int64        `json:"wall_time" gorm:"not null;default:0"`                    // 命令执行耗时(毫秒)
+	UserTime      int64        `json:"user_time" gorm:"not null;default:0"`                    // 用户态CPU时间(毫秒)
+	SystemTime    int64        `json:"system_time" gorm:"not null;default:0"`                  // 内核态CPU时间(毫秒)
+	MaxRss        int64        `json:"max_rss" gorm:"not null;default:0"`                      // 峰值内存(KB)
+	Truncated     int8         `json:"truncated" gorm:"type:tinyint;not null;default:0"`       // 输出超过限制, 已省略中间部分
+	OutputFiles   string       `json:"output_files" gorm:"type:text"`                          // gocron-node上保存的完整输出文件, JSON数组
+	EndSignal     string       `json:"end_signal" gorm:"type:varchar(16);not null;default:''"` // 超时或停止时结束任务的信号
+	TotalTime     int          `json:"total_time" gorm:"-"`
+	BaseModel     `json:"-" gorm:"-"`
+}
+
+// TaskLogOutputFile 保存在gocron-node上的完整输出文件
+type TaskLogOutputFile struct {
+	HostId int16  `json:"host_id"`
+	Host   string `json:"host"`
+	File   string `json:"file"`
+}
+
+// ParseOutputFiles 解析保存的完整输出文件列表
+func (taskLog *TaskLog) ParseOutputFiles() []TaskLogOutputFile {
+	var files []TaskLogOutputFile
+	if taskLog.OutputFiles == "" {
+		return files
+	}
+	if err := json.Unmarshal([]byte(taskLog.OutputFiles), &files); err != nil {
+		return nil
+	}
+
+	return files
+}
+
+// FormatHostIds 任务日志中记录的主机ID
+func FormatHostIds(hosts []TaskHostDetail) string {
+	ids := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		ids = append(ids, strconv.Itoa(int(host.HostId)))
+	}
+
+	return strings.Join(ids, ",")
+}
+
+// ExecHosts 执行任务的主机, 已删除的主机不返回, 未记录主机ID的旧日志返回空
+func (taskLog *TaskLog) ExecHosts() ([]TaskHostDetail, error) {
+	hosts := make([]TaskHostDetail, 0)
+	ids := make([]int, 0)
+	for _, item := range strings.Split(taskLog.HostIds, ",") {
+		if id, err := strconv.Atoi(item); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return hosts, nil
+	}
+	list := make([]Host, 0)
+	if err := Db.Select("id", "name", "alias", "port").Where("id IN ?", ids).Order("id ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		host := TaskHostDetail{Name: item.Name, Port: item.Port, Alias: item.Alias}
+		host.HostId = item.Id
+		hosts = append(hosts, host)
+	}
+
+	return hosts, nil
 }
 
 func (taskLog *TaskLog) Create() (insertId int64, err error) {
@@ -81,6 +153,23 @@ func (taskLog *TaskLog) Update(id int64, data CommonMap) (int64, error) {
 	return result.RowsAffected, result.Error
 }
 
+// 更新运行中的日志, 已结束的日志不再更新
+func (taskLog *TaskLog) UpdateRunning(id int64, data CommonMap) (int64, error) {
+	updateData := make(map[string]interface{})
+	for k, v := range data {
+		updateData[k] = v
+	}
+	result := Db.Model(&TaskLog{}).Where("id = ? AND status = ?", id, Running).UpdateColumns(updateData)
+	return result.RowsAffected, result.Error
+}
+
+func (taskLog *TaskLog) Detail(id int64) (TaskLog, error) {
+	log := TaskLog{}
+	err := Db.Where("id = ?", id).First(&log).Error
+
+	return log, err
+}
+
 func (taskLog *TaskLog) List(params CommonMap) ([]TaskLog, error) {
 	taskLog.parsePageAndPageSize(params)
 	list := make([]TaskLog, 0)
@@ -102,9 +191,20 @@ func (taskLog *TaskLog) List(params CommonMap) ([]TaskLog, error) {
 	return list, err
 }
 
+// RunningList 指定协议运行中的任务日志
+func (taskLog *TaskLog) RunningList(protocol TaskProtocol) ([]TaskLog, error) {
+	list := make([]TaskLog, 0)
+	err := Db.Where("status = ? AND protocol = ?", Running, protocol).Order("id ASC").Find(&list).Error
+
+	return list, err
+}
+
 // 清空表
 func (taskLog *TaskLog) Clear() (int64, error) {
 	result := Db.Where("1=1").Delete(&TaskLog{})
+	if result.Error == nil {
+		_, _ = new(TaskLogAttempt).Clear()
+	}
 	return result.RowsAffected, result.Error
 }
 
@@ -112,6 +212,9 @@ func (taskLog *TaskLog) Clear() (int64, error) {
 func (taskLog *TaskLog) Remove(id int) (int64, error) {
 	t := time.Now().AddDate(0, -id, 0)
 	result := Db.Where("start_time <= ?", t.Format(DefaultTimeFormat)).Delete(&TaskLog{})
+	if result.Error == nil {
+		_, _ = new(TaskLogAttempt).RemoveBefore(t)
+	}
 	return result.RowsAffected, result.Error
 }
 
@@ -122,6 +225,9 @@ func (taskLog *TaskLog) RemoveByDays(days int) (int64, error) {
 	}
 	t := time.Now().AddDate(0, 0, -days)
 	result := Db.Where("start_time < ?", t).Delete(&TaskLog{})
+	if result.Error == nil {
+		_, _ = new(TaskLogAttempt).RemoveBefore(t)
+	}
 	return result.RowsAffected, result.Error
 }
 
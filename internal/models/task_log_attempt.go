@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// 任务单次执行尝试记录, 开启重试的任务每次执行(含重试)记录一条
+type TaskLogAttempt struct {
+	Id        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskLogId int64     `json:"task_log_id" gorm:"type:bigint;not null;index;default:0"`
+	Attempt   int       `json:"attempt" gorm:"type:smallint;not null;default:1"`
+	Delay     int       `json:"delay" gorm:"not null;default:0"` // 本次尝试前的等待时间(毫秒)
+	StartTime LocalTime `json:"start_time" gorm:"column:start_time"`
+	EndTime   LocalTime `json:"end_time" gorm:"column:end_time"`
+	Status    Status    `json:"status" gorm:"type:tinyint;not null;default:1"`
+	Error     string    `json:"error" gorm:"type:varchar(512);not null;default:''"`
+	Result    string    `json:"result" gorm:"type:mediumtext;not null"`
+}
+
+// 批量新增
+func (attempt *TaskLogAttempt) BatchCreate(attempts []TaskLogAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	return Db.Create(&attempts).Error
+}
+
+// 获取某次任务日志的所有执行尝试
+func (attempt *TaskLogAttempt) ListByTaskLogId(taskLogId int64) ([]TaskLogAttempt, error) {
+	list := make([]TaskLogAttempt, 0)
+	err := Db.Where("task_log_id = ?", taskLogId).Order("attempt ASC").Find(&list).Error
+
+	return list, err
+}
+
+// 清空表
+func (attempt *TaskLogAttempt) Clear() (int64, error) {
+	result := Db.Where("1=1").Delete(&TaskLogAttempt{})
+	return result.RowsAffected, result.Error
+}
+
+// 删除指定时间之前的记录
+func (attempt *TaskLogAttempt) RemoveBefore(t time.Time) (int64, error) {
+	result := Db.Where("start_time < ?", t).Delete(&TaskLogAttempt{})
+	return result.RowsAffected, result.Error
+}
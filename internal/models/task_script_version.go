@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 脚本任务的历史版本, 保存任务时脚本或解释器发生变化则新增一个版本
+type TaskScriptVersion struct {
+	Id          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskId      int       `json:"task_id" gorm:"not null;index;default:0"`
+	Version     int       `json:"version" gorm:"not null;default:1"`
+	Interpreter string    `json:"interpreter" gorm:"type:varchar(16);not null;default:''"`
+	Script      string    `json:"script" gorm:"type:text"`
+	Username    string    `json:"username" gorm:"type:varchar(32);not null;default:''"` // 修改人
+	CreatedAt   time.Time `json:"created" gorm:"column:created;autoCreateTime"`
+}
+
+// 新增版本并更新任务当前的脚本版本号, 返回新版本号
+func (version *TaskScriptVersion) Create() (int, error) {
+	err := Db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		err := tx.Model(&TaskScriptVersion{}).Where("task_id = ?", version.TaskId).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+		version.Version = latest + 1
+		if err = tx.Create(version).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Task{}).Where("id = ?", version.TaskId).
+			UpdateColumn("script_version", version.Version).Error
+	})
+
+	return version.Version, err
+}
+
+// 获取任务的所有脚本版本, 最新版本在前
+func (version *TaskScriptVersion) List(taskId int) ([]TaskScriptVersion, error) {
+	list := make([]TaskScriptVersion, 0)
+	err := Db.Where("task_id = ?", taskId).Order("version DESC").Find(&list).Error
+
+	return list, err
+}
+
+// 删除任务的所有脚本版本
+func (version *TaskScriptVersion) RemoveByTaskId(taskId int) error {
+	return Db.Where("task_id = ?", taskId).Delete(&TaskScriptVersion{}).Error
+}
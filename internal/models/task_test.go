@@ -0,0 +1,79 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTaskSpecs(t *testing.T) {
+	task := Task{Spec: " 0 */5 9-18 * * 1-5 ;\n0 0 * * * *;; "}
+	expected := []string{"0 */5 9-18 * * 1-5", "0 0 * * * *"}
+	if specs := task.Specs(); !reflect.DeepEqual(specs, expected) {
+		t.Fatalf("expected %v, got %v", expected, specs)
+	}
+	if specs := (&Task{}).Specs(); len(specs) != 0 {
+		t.Fatalf("expected no specs, got %v", specs)
+	}
+}
+
+func TestTaskValidPeriod(t *testing.T) {
+	from := LocalTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local))
+	until := LocalTime(time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local))
+	task := Task{ValidFrom: &from, ValidUntil: &until}
+
+	if task.InValidPeriod(time.Date(2024, 12, 31, 23, 59, 59, 0, time.Local)) {
+		t.Fatal("time before valid_from should be outside the period")
+	}
+	if !task.InValidPeriod(time.Date(2025, 1, 15, 0, 0, 0, 0, time.Local)) {
+		t.Fatal("time inside the period should be valid")
+	}
+	if task.InValidPeriod(time.Date(2025, 2, 1, 0, 0, 1, 0, time.Local)) {
+		t.Fatal("time after valid_until should be outside the period")
+	}
+	if !task.Expired(time.Date(2025, 2, 2, 0, 0, 0, 0, time.Local)) {
+		t.Fatal("task should be expired after valid_until")
+	}
+	if (&Task{}).Expired(time.Now()) || !(&Task{}).InValidPeriod(time.Now()) {
+		t.Fatal("task without validity window should always be valid")
+	}
+}
+
+func TestTaskRunCountExhausted(t *testing.T) {
+	if (&Task{MaxRunCount: 0, RunCount: 100}).RunCountExhausted() {
+		t.Fatal("unlimited task should never be exhausted")
+	}
+	if (&Task{MaxRunCount: 3, RunCount: 2}).RunCountExhausted() {
+		t.Fatal("task below limit should not be exhausted")
+	}
+	if !(&Task{MaxRunCount: 3, RunCount: 3}).RunCountExhausted() {
+		t.Fatal("task at limit should be exhausted")
+	}
+}
+
+func TestParseTaskEnv(t *testing.T) {
+	env, err := ParseTaskEnv("# comment\nAPP_ENV=prod\r\n\n  _PATH=/a:/b\nEMPTY=\nDSN=a=b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"APP_ENV=prod", "_PATH=/a:/b", "EMPTY=", "DSN=a=b"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Fatalf("expected %v, got %v", expected, env)
+	}
+
+	for _, value := range []string{"NO_VALUE", "1ABC=x", "A-B=x", "=x"} {
+		if _, err = ParseTaskEnv(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestMergeTaskHosts(t *testing.T) {
+	taskHosts := []TaskHostDetail{{TaskHost: TaskHost{TaskId: 1, HostId: 2}, Name: "10.0.0.2", Port: 5921}}
+	hosts := []Host{{Id: 2, Name: "10.0.0.2", Port: 5921}, {Id: 3, Name: "10.0.0.3", Port: 5922, Alias: "web-3"}}
+	merged := mergeTaskHosts(1, taskHosts, hosts)
+	if len(merged) != 2 || merged[0].HostId != 2 || merged[1].HostId != 3 || merged[1].Port != 5922 ||
+		merged[1].Alias != "web-3" || merged[1].TaskId != 1 {
+		t.Fatalf("unexpected merged hosts: %+v", merged)
+	}
+}
@@ -15,6 +15,7 @@ type ResponseWrapper struct {
 	StatusCode int
 	Body       string
 	Header     http.Header
+	Err        error // 请求未得到响应时的错误, 如连接失败、超时
 }
 
 type httpDoer interface {
@@ -78,13 +79,48 @@ func PostJson(url string, body string, timeout int) ResponseWrapper {
 	return request(req, timeout)
 }
 
+// Request 发送任意方法的HTTP请求, headers中的User-Agent会覆盖默认值
+func Request(method, url string, body string, headers http.Header, timeout int, options Options) ResponseWrapper {
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return createRequestError(err)
+	}
+	setRequestHeader(req)
+	for key, values := range headers {
+		// Host请求头需设置到Request.Host上才生效
+		if http.CanonicalHeaderKey(key) == "Host" && len(values) > 0 {
+			req.Host = values[0]
+			continue
+		}
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	client, err := optionsClientFactory(timeout, options)
+	if err != nil {
+		return createRequestError(err)
+	}
+
+	return do(client, req)
+}
+
 func request(req *http.Request, timeout int) ResponseWrapper {
-	wrapper := ResponseWrapper{StatusCode: 0, Body: "", Header: make(http.Header)}
-	client := clientFactory(timeout)
 	setRequestHeader(req)
+
+	return do(clientFactory(timeout), req)
+}
+
+func do(client httpDoer, req *http.Request) ResponseWrapper {
+	wrapper := ResponseWrapper{StatusCode: 0, Body: "", Header: make(http.Header)}
 	resp, err := client.Do(req)
 	if err != nil {
 		wrapper.Body = fmt.Sprintf("执行HTTP请求错误-%s", err.Error())
+		wrapper.Err = err
 		return wrapper
 	}
 	defer resp.Body.Close()
@@ -106,5 +142,5 @@ func setRequestHeader(req *http.Request) {
 
 func createRequestError(err error) ResponseWrapper {
 	errorMessage := fmt.Sprintf("创建HTTP请求错误-%s", err.Error())
-	return ResponseWrapper{0, errorMessage, make(http.Header)}
+	return ResponseWrapper{StatusCode: 0, Body: errorMessage, Header: make(http.Header), Err: err}
 }
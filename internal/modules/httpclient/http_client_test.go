@@ -122,3 +122,46 @@ func TestCreateRequestError(t *testing.T) {
 		t.Fatalf("unexpected error wrapper: %+v", resp)
 	}
 }
+
+func TestRequestWithHeadersAndBody(t *testing.T) {
+	withMockClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPatch {
+			t.Fatalf("expected PATCH, got %s", req.Method)
+		}
+		if ua := req.Header.Get("User-Agent"); ua != "custom" {
+			t.Fatalf("expected custom user-agent, got %s", ua)
+		}
+		if req.Host != "api.internal" || req.Header.Get("X-Token") != "abc" {
+			t.Fatalf("unexpected request headers host=%s %v", req.Host, req.Header)
+		}
+		body, _ := io.ReadAll(req.Body)
+		if string(body) != `{"a":1}` {
+			t.Fatalf("unexpected body %s", body)
+		}
+		return &http.Response{
+			StatusCode: http.StatusAccepted,
+			Body:       io.NopCloser(strings.NewReader("accepted")),
+			Header:     http.Header{},
+		}, nil
+	})
+
+	headers := http.Header{}
+	headers.Set("User-Agent", "custom")
+	headers.Set("Host", "api.internal")
+	headers.Set("X-Token", "abc")
+	resp := Request(http.MethodPatch, "http://example.com", `{"a":1}`, headers, 10, Options{})
+	if resp.StatusCode != http.StatusAccepted || resp.Body != "accepted" || resp.Err != nil {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
+
+func TestRequestKeepsTransportError(t *testing.T) {
+	withMockClient(t, func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("dial failed")
+	})
+
+	resp := Request(http.MethodDelete, "http://example.com", "", nil, 10, Options{})
+	if resp.StatusCode != 0 || resp.Err == nil || !strings.Contains(resp.Body, "dial failed") {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
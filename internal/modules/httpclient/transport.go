@@ -0,0 +1,152 @@
+package httpclient
+
+// 任务级传输选项: 自定义CA、客户端证书、跳过证书校验、禁止重定向、代理
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	CACert             string // PEM格式CA证书
+	ClientCert         string // PEM格式客户端证书
+	ClientKey          string // PEM格式客户端私钥
+	InsecureSkipVerify bool
+	DisableRedirect    bool
+	Proxy              string // 代理地址, 如 http://127.0.0.1:3128
+}
+
+// 是否使用默认传输配置
+func (o Options) isDefault() bool {
+	return o == Options{}
+}
+
+func (o Options) cacheKey() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		o.CACert, o.ClientCert, o.ClientKey, fmt.Sprint(o.InsecureSkipVerify), o.Proxy,
+	}, "\x00")))
+
+	return fmt.Sprintf("%x", sum)
+}
+
+// 按选项缓存Transport, 复用连接池
+var transports sync.Map
+
+func transportFor(options Options) (http.RoundTripper, error) {
+	key := options.cacheKey()
+	if transport, ok := transports.Load(key); ok {
+		return transport.(http.RoundTripper), nil
+	}
+
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+	}
+	if options.Proxy != "" {
+		proxyURL, err := options.proxyURL()
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	actual, _ := transports.LoadOrStore(key, transport)
+
+	return actual.(http.RoundTripper), nil
+}
+
+// Validate 校验证书、私钥和代理地址
+func (o Options) Validate() error {
+	if _, err := o.tlsConfig(); err != nil {
+		return err
+	}
+	if o.Proxy != "" {
+		if _, err := o.proxyURL(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o Options) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(o.CACert)) {
+			return nil, errors.New("CA证书格式错误")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if o.ClientCert != "" || o.ClientKey != "" {
+		certificate, err := tls.X509KeyPair([]byte(o.ClientCert), []byte(o.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("客户端证书或私钥错误-%s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+func (o Options) proxyURL() (*url.URL, error) {
+	proxyURL, err := url.Parse(o.Proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("代理地址格式错误-%s", o.Proxy)
+	}
+
+	return proxyURL, nil
+}
+
+// 按任务选项创建客户端, 默认选项时沿用clientFactory
+var optionsClientFactory = func(timeout int, options Options) (httpDoer, error) {
+	if options.isDefault() {
+		return clientFactory(timeout), nil
+	}
+	client := &http.Client{Timeout: 300 * time.Second}
+	if timeout > 0 {
+		client.Timeout = time.Duration(timeout) * time.Second
+	}
+	if options.DisableRedirect {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	if options.isDefaultTransport() {
+		client.Transport = defaultClient.Transport
+		return client, nil
+	}
+	transport, err := transportFor(options)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = transport
+
+	return client, nil
+}
+
+// 仅禁止重定向时复用默认Transport
+func (o Options) isDefaultTransport() bool {
+	o.DisableRedirect = false
+
+	return o.isDefault()
+}
@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func certificatePEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+// 生成自签名的客户端证书和私钥
+func generateClientCert(t *testing.T) (string, string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gocron-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	return certificatePEM(cert), keyPEM, cert
+}
+
+func TestRequestTLSWithCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	defer server.Close()
+
+	resp := Request(http.MethodGet, server.URL, "", nil, 5, Options{})
+	if resp.Err == nil {
+		t.Fatalf("expected certificate error without custom CA, got %+v", resp)
+	}
+
+	resp = Request(http.MethodGet, server.URL, "", nil, 5, Options{CACert: certificatePEM(server.Certificate())})
+	if resp.Err != nil || resp.StatusCode != http.StatusOK || resp.Body != "secure" {
+		t.Fatalf("unexpected response with custom CA: %+v", resp)
+	}
+
+	resp = Request(http.MethodGet, server.URL, "", nil, 5, Options{InsecureSkipVerify: true})
+	if resp.Err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected response with skip verify: %+v", resp)
+	}
+}
+
+func TestRequestTLSWithClientCert(t *testing.T) {
+	certPEM, keyPEM, clientCert := generateClientCert(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCert)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	caPEM := certificatePEM(server.Certificate())
+	resp := Request(http.MethodGet, server.URL, "", nil, 5, Options{CACert: caPEM})
+	if resp.Err == nil && resp.StatusCode == http.StatusOK {
+		t.Fatalf("expected handshake failure without client certificate, got %+v", resp)
+	}
+
+	resp = Request(http.MethodGet, server.URL, "", nil, 5, Options{CACert: caPEM, ClientCert: certPEM, ClientKey: keyPEM})
+	if resp.Err != nil || resp.Body != "gocron-client" {
+		t.Fatalf("unexpected response with client certificate: %+v", resp)
+	}
+}
+
+func TestRequestDisableRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/end", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("end"))
+	}))
+	defer server.Close()
+
+	resp := Request(http.MethodGet, server.URL+"/start", "", nil, 5, Options{})
+	if resp.StatusCode != http.StatusOK || resp.Body != "end" {
+		t.Fatalf("expected redirect to be followed, got %+v", resp)
+	}
+
+	resp = Request(http.MethodGet, server.URL+"/start", "", nil, 5, Options{DisableRedirect: true})
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/end" {
+		t.Fatalf("expected redirect response, got %+v", resp)
+	}
+}
+
+func TestRequestThroughProxy(t *testing.T) {
+	var requestURI string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.RequestURI
+		_, _ = w.Write([]byte("proxied"))
+	}))
+	defer proxy.Close()
+
+	resp := Request(http.MethodGet, "http://gocron.invalid/ping", "", nil, 5, Options{Proxy: proxy.URL})
+	if resp.Err != nil || resp.Body != "proxied" {
+		t.Fatalf("unexpected proxy response: %+v", resp)
+	}
+	if requestURI != "http://gocron.invalid/ping" {
+		t.Fatalf("expected absolute request URI at proxy, got %q", requestURI)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		errPart string
+	}{
+		{"default", Options{}, ""},
+		{"bad ca", Options{CACert: "not a pem"}, "CA证书"},
+		{"cert without key", Options{ClientCert: "cert"}, "客户端证书"},
+		{"bad proxy", Options{Proxy: "127.0.0.1"}, "代理地址"},
+		{"good proxy", Options{Proxy: "http://127.0.0.1:3128"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if tt.errPart == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Fatalf("expected error containing %q, got %v", tt.errPart, err)
+			}
+		})
+	}
+}
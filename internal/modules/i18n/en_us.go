@@ -78,4 +78,55 @@ var enUS = map[string]string{
 	"password_must_contain_letter_and_digit": "Password must contain both letters and digits",
 	"account_locked":                         "Account locked, please try again in %d minutes",
 	"login_failed_with_attempts":             "Username or password is incorrect, %d attempts remaining",
+	"forecast_time_format_invalid":           "Invalid time format, expected 2006-01-02 15:04:05",
+	"forecast_window_invalid":                "End time must be after start time and the window cannot exceed 7 days",
+	"crontab_count_invalid":                  "Please configure 1-10 crontab expressions separated by semicolons",
+	"valid_period_invalid":                   "Invalid validity period or end time is before start time",
+	"calendar_not_found":                     "Calendar does not exist",
+	"calendar_name_exists":                   "Calendar name already exists",
+	"calendar_dates_invalid":                 "Invalid calendar dates",
+	"calendar_import_file_invalid":           "Invalid ics file or file larger than 2MB",
+	"calendar_in_use_cannot_delete":          "Calendar is used by tasks and cannot be deleted",
+	"start_offset_range_0_3600":              "Start delay and jitter must be between 0 and 3600 seconds",
+	"retry_multiplier_range_1_10":            "Backoff multiplier must be between 1 and 10",
+	"retry_max_interval_jitter_invalid":      "Max retry interval must be 0-86400 seconds and retry jitter 0-3600 seconds",
+	"retry_codes_invalid":                    "Invalid retry exit codes or status codes, e.g. 1,2,500-599",
+	"retry_output_regex_invalid":             "Invalid retry output regex",
+	"http_params_invalid":                    "Invalid request headers or query parameters",
+	"http_auth_secret_required":              "Authentication password or token is required",
+	"http_success_codes_invalid":             "Invalid success status codes, e.g. 2xx,304",
+	"http_body_regex_invalid":                "Invalid response body regex",
+	"http_tls_options_invalid":               "Invalid certificate, private key or proxy settings",
+	"http_skip_verify_warning":               "Saved. Warning: TLS certificate verification is disabled and the task is exposed to man-in-the-middle attacks",
+	"http_callback_url_required":             "Set callback_url in the configuration file before using asynchronous HTTP tasks",
+	"http_async_timeout_max_86400":           "Callback timeout cannot exceed 86400 seconds",
+	"async_callback_token_invalid":           "Invalid callback token",
+	"async_task_not_running":                 "Task log does not exist or has already finished",
+	"local_shell_disabled":                   "Local shell tasks are disabled. Set local_shell.enable=true in the configuration file",
+	"ssh_user_required":                      "SSH username is required",
+	"ssh_host_key_invalid":                   "Invalid host public key",
+	"ssh_private_key_invalid":                "Invalid SSH private key. Passphrase-protected keys are not supported",
+	"ssh_credential_required":                "SSH password or private key is required",
+	"host_ssh_not_configured":                "The selected host has no SSH connection settings",
+	"sql_connection_not_found":               "Database connection not found",
+	"sql_connection_name_exists":             "Database connection name already exists",
+	"sql_connection_dsn_required":            "Please enter the database connection DSN",
+	"sql_connection_test_failed":             "Database connection test failed",
+	"sql_connection_in_use_cannot_delete":    "Database connection is used by tasks and cannot be deleted",
+	"executor_config_invalid":                "Invalid executor configuration",
+	"stop_task_failed":                       "Failed to stop task",
+	"command_required":                       "Please enter the command",
+	"script_required":                        "Please enter the script",
+	"script_protocol_unsupported":            "Scripts are only supported by shell tasks",
+	"script_shebang_required":                "The first line of the script must specify the interpreter with #!",
+	"task_env_invalid":                       "Invalid environment variables",
+	"run_as_protocol_unsupported":            "Run-as user is only supported for RPC tasks",
+	"task_log_not_running":                   "Task is not running or has already finished",
+	"notify_exit_codes_required":             "Please enter the exit codes that trigger notification",
+	"exit_codes_invalid":                     "Invalid exit codes, e.g. 1,2,100-110",
+	"task_log_output_not_found":              "Full output was not saved",
+	"task_log_output_fetch_failed":           "Failed to fetch full output",
+	"stop_signal_invalid":                    "Unsupported stop signal",
+	"host_labels_invalid":                    "Invalid host labels, expected key=value separated by commas",
+	"host_selector_invalid":                  "Invalid host label selector, e.g. role=web,env!=dev",
 }
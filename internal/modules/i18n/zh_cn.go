@@ -78,4 +78,55 @@ var zhCN = map[string]string{
 	"password_must_contain_letter_and_digit": "密码必须包含字母和数字",
 	"account_locked":                         "账户已被锁定，请在%d分钟后重试",
 	"login_failed_with_attempts":             "用户名或密码错误，还剩%d次尝试机会",
+	"forecast_time_format_invalid":           "时间格式错误, 应为 2006-01-02 15:04:05",
+	"forecast_window_invalid":                "结束时间必须晚于开始时间, 且时间窗口不能超过7天",
+	"crontab_count_invalid":                  "请配置1-10个crontab表达式, 多个表达式用分号分隔",
+	"valid_period_invalid":                   "有效期格式错误或结束时间早于开始时间",
+	"calendar_not_found":                     "日历不存在",
+	"calendar_name_exists":                   "日历名称已存在",
+	"calendar_dates_invalid":                 "日历日期格式错误",
+	"calendar_import_file_invalid":           "ics文件无效或超过2MB",
+	"calendar_in_use_cannot_delete":          "日历已被任务引用, 不能删除",
+	"start_offset_range_0_3600":              "启动延迟和随机抖动取值范围0-3600秒",
+	"retry_multiplier_range_1_10":            "指数退避倍数取值范围1-10",
+	"retry_max_interval_jitter_invalid":      "重试间隔上限取值范围0-86400秒, 重试抖动取值范围0-3600秒",
+	"retry_codes_invalid":                    "重试退出码或状态码格式错误, 示例: 1,2,500-599",
+	"retry_output_regex_invalid":             "重试输出匹配正则表达式无效",
+	"http_params_invalid":                    "请求头或查询参数格式错误",
+	"http_auth_secret_required":              "请填写认证密码或Token",
+	"http_success_codes_invalid":             "成功状态码格式错误, 示例: 2xx,304",
+	"http_body_regex_invalid":                "响应内容正则表达式无效",
+	"http_tls_options_invalid":               "证书、私钥或代理配置错误",
+	"http_skip_verify_warning":               "保存成功, 注意: 已跳过TLS证书校验, 存在中间人攻击风险",
+	"http_callback_url_required":             "异步HTTP任务需先在配置文件中设置callback_url",
+	"http_async_timeout_max_86400":           "回调超时时间不能超过86400秒",
+	"async_callback_token_invalid":           "回调令牌无效",
+	"async_task_not_running":                 "任务日志不存在或已结束",
+	"local_shell_disabled":                   "本机Shell任务未启用, 请在配置文件中设置local_shell.enable=true",
+	"ssh_user_required":                      "请填写SSH用户名",
+	"ssh_host_key_invalid":                   "主机公钥格式错误",
+	"ssh_private_key_invalid":                "SSH私钥格式错误, 暂不支持带密码的私钥",
+	"ssh_credential_required":                "请填写SSH密码或私钥",
+	"host_ssh_not_configured":                "所选主机未配置SSH连接信息",
+	"sql_connection_not_found":               "数据库连接不存在",
+	"sql_connection_name_exists":             "数据库连接名称已存在",
+	"sql_connection_dsn_required":            "请填写数据库连接DSN",
+	"sql_connection_test_failed":             "数据库连接测试失败",
+	"sql_connection_in_use_cannot_delete":    "数据库连接已被任务引用, 不能删除",
+	"executor_config_invalid":                "执行器配置错误",
+	"stop_task_failed":                       "停止任务失败",
+	"command_required":                       "请输入命令",
+	"script_required":                        "请输入脚本内容",
+	"script_protocol_unsupported":            "仅Shell任务支持脚本",
+	"script_shebang_required":                "脚本首行必须以#!指定解释器",
+	"task_env_invalid":                       "环境变量格式错误",
+	"run_as_protocol_unsupported":            "仅RPC任务支持指定执行用户",
+	"task_log_not_running":                   "任务未在执行或已结束",
+	"notify_exit_codes_required":             "请填写触发通知的退出码",
+	"exit_codes_invalid":                     "退出码格式错误, 示例: 1,2,100-110",
+	"task_log_output_not_found":              "未保存完整输出",
+	"task_log_output_fetch_failed":           "读取完整输出失败",
+	"stop_signal_invalid":                    "不支持的停止信号",
+	"host_labels_invalid":                    "主机标签格式错误, 应为key=value, 多个以逗号分隔",
+	"host_selector_invalid":                  "主机标签选择器格式错误, 如role=web,env!=dev",
 }
@@ -0,0 +1,154 @@
+// Package ical 解析iCalendar(.ics)文件中的事件日期, 用于导入节假日日历
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405"
+)
+
+// 日历事件, Start、End均为当天零点, 包含首尾两天
+type Event struct {
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// Parse 解析VEVENT事件, 全天事件的DTEND为开区间, 重复规则(RRULE)不展开
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0)
+	var current *Event
+	var endSet, allDay bool
+	for i, line := range lines {
+		name, params, value := splitLine(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &Event{}
+			endSet, allDay = false, false
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("第%d行: END:VEVENT缺少对应的BEGIN", i+1)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("第%d行: 事件缺少DTSTART", i+1)
+			}
+			if !endSet {
+				current.End = current.Start
+			} else if allDay && current.End.After(current.Start) {
+				current.End = current.End.AddDate(0, 0, -1)
+			}
+			if current.End.Before(current.Start) {
+				current.End = current.Start
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "SUMMARY":
+			current.Summary = unescape(value)
+		case name == "DTSTART":
+			current.Start, allDay, err = parseDate(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("第%d行: %s", i+1, err)
+			}
+		case name == "DTEND":
+			current.End, _, err = parseDate(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("第%d行: %s", i+1, err)
+			}
+			endSet = true
+		}
+	}
+	if current != nil {
+		return nil, errors.New("事件缺少END:VEVENT")
+	}
+
+	return events, nil
+}
+
+// 展开折行, 以空格或制表符开头的行是上一行的延续
+func unfold(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// 拆分属性行, 如 DTSTART;VALUE=DATE:20250101
+func splitLine(line string) (name string, params map[string]string, value string) {
+	params = make(map[string]string)
+	index := strings.Index(line, ":")
+	if index < 0 {
+		return strings.ToUpper(line), params, ""
+	}
+	value = line[index+1:]
+	fields := strings.Split(line[:index], ";")
+	name = strings.ToUpper(fields[0])
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	return name, params, value
+}
+
+// 解析日期或日期时间, 返回当天零点及是否为全天日期
+func parseDate(params map[string]string, value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	loc := time.Local
+	if tzid, ok := params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	if params["VALUE"] == "DATE" || len(value) == len(dateFormat) {
+		t, err := time.ParseInLocation(dateFormat, value, time.Local)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("日期格式错误: %s", value)
+		}
+		return t, true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		value = strings.TrimSuffix(value, "Z")
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(dateTimeFormat, value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("日期时间格式错误: %s", value)
+	}
+	t = t.In(time.Local)
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local), false, nil
+}
+
+func unescape(value string) string {
+	replacer := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+	return strings.TrimSpace(replacer.Replace(value))
+}
@@ -0,0 +1,61 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	content := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20250101\r\n" +
+		"DTEND;VALUE=DATE:20250102\r\n" +
+		"SUMMARY:New Year\\, Day\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20250128\r\n" +
+		"DTEND;VALUE=DATE:20250205\r\n" +
+		"SUMMARY:Spring\r\n" +
+		"  Festival\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART:20250501T090000\r\n" +
+		"DTEND:20250501T180000\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+	if events[0].Summary != "New Year, Day" || !events[0].Start.Equal(day(2025, 1, 1)) || !events[0].End.Equal(day(2025, 1, 1)) {
+		t.Fatalf("unexpected first event: %+v", events[0])
+	}
+	if events[1].Summary != "Spring Festival" || !events[1].End.Equal(day(2025, 2, 4)) {
+		t.Fatalf("unexpected second event: %+v", events[1])
+	}
+	if !events[2].Start.Equal(day(2025, 5, 1)) || !events[2].End.Equal(day(2025, 5, 1)) {
+		t.Fatalf("unexpected third event: %+v", events[2])
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []string{
+		"BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nDTSTART;VALUE=DATE:2025-01-01\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250101\n",
+	}
+	for _, content := range cases {
+		if _, err := Parse(strings.NewReader(content)); err == nil {
+			t.Fatalf("expected error for %q", content)
+		}
+	}
+}
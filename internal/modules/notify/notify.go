@@ -70,6 +70,9 @@ func parseNotifyTemplate(notifyTemplate string, msg Message) string {
 		"Status":   msg["status"],
 		"Result":   msg["output"],
 		"Remark":   msg["remark"],
+		"ExitCode": msg["exit_code"],
+		"Stdout":   msg["stdout"],
+		"Stderr":   msg["stderr"],
 	}); err != nil {
 		return fmt.Sprintf("执行模板失败: %s", err)
 	}
@@ -3,6 +3,7 @@ package client
 import (
 	"errors"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -18,31 +19,238 @@ import (
 
 var (
 	taskMap sync.Map
+	// 节点是否自行控制执行超时, key为节点地址
+	timeoutCapability sync.Map
+	// 连接中断后查询分离执行任务状态的间隔
+	detachedPollInterval = 10 * time.Second
+)
+
+const (
+	// 与gocron-node未指定宽限期时的默认值一致(秒)
+	defaultGracePeriod = 10
+	// 节点发送SIGKILL后等待进程退出及返回结果预留的时间
+	terminateMargin = 10 * time.Second
+	// 停止及查询任务状态的超时时间
+	jobRequestTimeout = 5 * time.Second
+	// 节点升级或降级后重新查询是否自行控制执行超时
+	capabilityTTL = 5 * time.Minute
 )
 
 var (
-	errUnavailable = errors.New("无法连接远程服务器")
+	ErrUnavailable = errors.New("无法连接远程服务器")
+	ErrTimeout     = errors.New("执行超时, 强制结束")
+	ErrCanceled    = errors.New("手动停止")
+	ErrJobNotFound = errors.New("节点上未找到任务")
+	ErrUnsupported = errors.New("节点版本过低, 不支持该操作")
+	// 任务开始后连接中断, 任务可能已部分执行, 不能切换到其他主机重新执行
+	ErrConnectionLost = errors.New("任务开始后与节点的连接中断")
 )
 
 func generateTaskUniqueKey(ip string, port int, id int64) string {
 	return fmt.Sprintf("%s:%d:%d", ip, port, id)
 }
 
-func Stop(ip string, port int, id int64) {
+// Stop 通知节点停止任务, signal为空时使用任务指定的停止信号
+// 旧版本节点或节点上未找到任务时取消本地的RPC调用, 均未找到任务时返回false
+func Stop(ip string, port int, id int64, signal string) bool {
 	key := generateTaskUniqueKey(ip, port, id)
 	logger.Infof("尝试停止任务#key-%s#taskLogId-%d", key, id)
+	err := stopOnNode(ip, port, id, signal)
+	if err == nil {
+		logger.Infof("节点已停止任务#key-%s", key)
+		return true
+	}
+	logger.Warnf("节点停止任务失败#key-%s#%s", key, err)
 	cancel, ok := taskMap.Load(key)
 	if !ok {
-		logger.Warnf("未找到运行中的任务，可能是历史任务，直接更新数据库状态#key-%s", key)
-		// 对于历史任务（重启后丢失的任务），直接更新数据库状态
-		updateOrphanedTaskLog(id)
-		return
+		logger.Warnf("未找到运行中的任务#key-%s", key)
+		return false
 	}
 	logger.Infof("找到运行中的任务，执行停止#key-%s", key)
 	cancel.(context.CancelFunc)()
+
+	return true
+}
+
+func stopOnNode(ip string, port int, id int64, signal string) error {
+	c, err := grpcpool.Pool.Get(fmt.Sprintf("%s:%d", ip, port))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), jobRequestTimeout)
+	defer cancel()
+	_, err = c.Stop(ctx, &pb.StopRequest{Id: id, Signal: signal})
+
+	return err
+}
+
+// ListRunning 节点上运行中的任务, 旧版本节点返回ErrUnsupported
+func ListRunning(ip string, port int) ([]*pb.JobStatus, error) {
+	c, err := grpcpool.Pool.Get(fmt.Sprintf("%s:%d", ip, port))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), jobRequestTimeout)
+	defer cancel()
+	list, err := c.ListRunning(ctx, &pb.ListRunningRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, parseGRPCError(err)
+	}
+
+	return list.Jobs, nil
+}
+
+// GetStatus 节点上任务的运行状态, 节点未保留该任务时返回ErrJobNotFound, 旧版本节点返回ErrUnsupported
+func GetStatus(ip string, port int, id int64) (*pb.JobStatus, error) {
+	c, err := grpcpool.Pool.Get(fmt.Sprintf("%s:%d", ip, port))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), jobRequestTimeout)
+	defer cancel()
+	jobStatus, err := c.GetStatus(ctx, &pb.JobRequest{Id: id})
+	switch status.Code(err) {
+	case codes.NotFound:
+		return nil, ErrJobNotFound
+	case codes.Unimplemented:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, parseGRPCError(err)
+	}
+
+	return jobStatus, nil
+}
+
+// Inventory 节点的版本、系统及资源使用情况, 旧版本节点返回ErrUnsupported
+func Inventory(ip string, port int) (*pb.NodeInfo, error) {
+	c, err := grpcpool.Pool.Get(fmt.Sprintf("%s:%d", ip, port))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), jobRequestTimeout)
+	defer cancel()
+	info, err := c.Inventory(ctx, &pb.InventoryRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, parseGRPCError(err)
+	}
+
+	return info, nil
 }
 
 func Exec(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
+	resp, err := execute(ip, port, taskReq, func(ctx context.Context, c pb.TaskClient) (*pb.TaskResponse, error) {
+		return c.Run(ctx, taskReq)
+	})
+
+	return resp.GetOutput(), err
+}
+
+// ExecStream 执行过程中通过onOutput接收输出片段, 返回节点的完整响应, 旧版本节点不支持时退回到Run
+// 任务开始后连接中断时, 分离执行的任务轮询节点直到任务结束, 其他任务返回ErrConnectionLost
+func ExecStream(ip string, port int, taskReq *pb.TaskRequest, onOutput func(stdout, stderr string)) (*pb.TaskResponse, error) {
+	return execute(ip, port, taskReq, func(ctx context.Context, c pb.TaskClient) (*pb.TaskResponse, error) {
+		return runStream(ctx, c, fmt.Sprintf("%s:%d", ip, port), taskReq, onOutput)
+	})
+}
+
+// 接收节点的输出直到返回执行结果
+func runStream(ctx context.Context, c pb.TaskClient, addr string, taskReq *pb.TaskRequest, onOutput func(stdout, stderr string)) (*pb.TaskResponse, error) {
+	stream, err := c.RunStream(ctx, taskReq)
+	if err != nil {
+		return nil, err
+	}
+	// started: 节点已开始执行; detached: 节点确认分离执行, 旧版本节点不发送Started, 不支持分离执行
+	started, detached := false, false
+	for {
+		output, err := stream.Recv()
+		if started && status.Code(err) == codes.Unavailable {
+			if !detached {
+				logger.Warnf("任务开始后与节点的连接中断#%s#taskLogId-%d#%s", addr, taskReq.Id, err)
+				return nil, ErrConnectionLost
+			}
+			logger.Warnf("与节点的连接中断, 等待分离执行的任务结束#%s#taskLogId-%d", addr, taskReq.Id)
+			return waitDetached(ctx, c, taskReq.Id)
+		}
+		if status.Code(err) == codes.Unimplemented {
+			if taskReq.Detached {
+				logger.Warnf("节点版本过低, 不支持分离执行, 连接中断时任务将被结束#%s#taskLogId-%d", addr, taskReq.Id)
+			} else {
+				logger.Infof("节点不支持实时输出, 等待执行完成#%s", addr)
+			}
+			return c.Run(ctx, taskReq)
+		}
+		if err == io.EOF {
+			return nil, errors.New("节点未返回执行结果")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if output.Result != nil {
+			return output.Result, nil
+		}
+		// 收到输出时任务也已开始
+		started = true
+		if output.Started {
+			detached = taskReq.Detached
+			continue
+		}
+		onOutput(output.Stdout, output.Stderr)
+	}
+}
+
+// 轮询节点上分离执行的任务, 连接恢复前持续重试, 节点重启后任务结果丢失时返回ErrJobNotFound
+func waitDetached(ctx context.Context, c pb.TaskClient, id int64) (*pb.TaskResponse, error) {
+	ticker := time.NewTicker(detachedPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, ErrTimeout
+			}
+			return nil, ErrCanceled
+		case <-ticker.C:
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, jobRequestTimeout)
+		jobStatus, err := c.GetStatus(reqCtx, &pb.JobRequest{Id: id})
+		cancel()
+		switch {
+		case status.Code(err) == codes.NotFound:
+			return nil, ErrJobNotFound
+		case err != nil:
+			logger.Debugf("查询分离执行的任务失败#taskLogId-%d#%s", id, err)
+		case !jobStatus.Running:
+			logger.Infof("分离执行的任务已结束#taskLogId-%d", id)
+			return jobStatus.Result, nil
+		}
+	}
+}
+
+// FetchOutput 从offset开始读取节点上保存的完整输出
+func FetchOutput(ip string, port int, file string, offset int64) (*pb.OutputChunk, error) {
+	c, err := grpcpool.Pool.Get(fmt.Sprintf("%s:%d", ip, port))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	chunk, err := c.FetchOutput(ctx, &pb.OutputRequest{File: file, Offset: offset})
+	if err != nil {
+		return nil, parseGRPCError(err)
+	}
+
+	return chunk, nil
+}
+
+// 执行失败时响应可能为nil, 命令返回错误时同时返回响应
+func execute(ip string, port int, taskReq *pb.TaskRequest, run func(ctx context.Context, c pb.TaskClient) (*pb.TaskResponse, error)) (*pb.TaskResponse, error) {
 	defer func() {
 		if err := recover(); err != nil {
 			logger.Error("panic#rpc/client.go:Exec#", err)
@@ -51,12 +259,21 @@ func Exec(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
 	addr := fmt.Sprintf("%s:%d", ip, port)
 	c, err := grpcpool.Pool.Get(addr)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if taskReq.Timeout <= 0 || taskReq.Timeout > 86400 {
 		taskReq.Timeout = 86400
 	}
 	timeout := time.Duration(taskReq.Timeout) * time.Second
+	// 节点超时后先发送停止信号, 等待宽限期后才能返回执行结果
+	// 不控制执行超时的旧版本节点仍按任务的超时时间取消调用
+	if enforcesTimeout(addr, c) {
+		gracePeriod := taskReq.GracePeriod
+		if gracePeriod <= 0 {
+			gracePeriod = defaultGracePeriod
+		}
+		timeout += time.Duration(gracePeriod)*time.Second + terminateMargin
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -68,32 +285,68 @@ func Exec(ip string, port int, taskReq *pb.TaskRequest) (string, error) {
 		taskMap.Delete(taskUniqueKey)
 	}()
 
-	resp, err := c.Run(ctx, taskReq)
+	resp, err := run(ctx, c)
 	if err != nil {
-		return parseGRPCError(err)
+		return nil, parseGRPCError(err)
+	}
+
+	return resp, ResponseError(resp)
+}
+
+type timeoutCapabilityEntry struct {
+	enforce   bool
+	checkedAt time.Time
+}
+
+// 通过Inventory查询节点是否自行控制执行超时, 查询失败时视为不控制且不缓存
+func enforcesTimeout(addr string, c pb.TaskClient) bool {
+	if value, ok := timeoutCapability.Load(addr); ok {
+		entry := value.(timeoutCapabilityEntry)
+		if time.Since(entry.checkedAt) < capabilityTTL {
+			return entry.enforce
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), jobRequestTimeout)
+	defer cancel()
+	info, err := c.Inventory(ctx, &pb.InventoryRequest{})
+	if err != nil && status.Code(err) != codes.Unimplemented {
+		logger.Warnf("查询节点信息失败, 按任务超时时间取消调用#%s#%s", addr, err)
+		return false
 	}
+	enforce := info.GetEnforceTimeout()
+	timeoutCapability.Store(addr, timeoutCapabilityEntry{enforce: enforce, checkedAt: time.Now()})
+
+	return enforce
+}
 
-	if resp.Error == "" {
-		return resp.Output, nil
+// ResponseError 节点返回的命令错误, 超时及停止时与本地取消调用的错误一致
+func ResponseError(resp *pb.TaskResponse) error {
+	switch {
+	case resp.GetError() == "":
+		return nil
+	case resp.TimedOut:
+		return ErrTimeout
+	case resp.Canceled:
+		return ErrCanceled
 	}
 
-	return resp.Output, errors.New(resp.Error)
+	return errors.New(resp.Error)
 }
 
-func parseGRPCError(err error) (string, error) {
+func parseGRPCError(err error) error {
 	switch status.Code(err) {
 	case codes.Unavailable:
-		return "", errUnavailable
+		return ErrUnavailable
 	case codes.DeadlineExceeded:
-		return "", errors.New("执行超时, 强制结束")
+		return ErrTimeout
 	case codes.Canceled:
-		return "", errors.New("手动停止")
+		return ErrCanceled
 	}
-	return "", err
+	return err
 }
 
-// 处理孤立的任务日志（重启后丢失的任务）
-func updateOrphanedTaskLog(taskLogId int64) {
+// UpdateOrphanedTaskLog 所有主机上均未找到任务时, 视为重启后丢失的任务, 直接更新日志状态
+func UpdateOrphanedTaskLog(taskLogId int64) {
 	taskLogModel := new(models.TaskLog)
 	_, err := taskLogModel.Update(taskLogId, models.CommonMap{
 		"status": models.Cancel,
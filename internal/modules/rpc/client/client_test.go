@@ -0,0 +1,166 @@
+package client
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 日志写入临时目录
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "gocron-client")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Chdir(dir)
+	logger.InitLogger()
+	code := m.Run()
+	logger.Close()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// 只实现测试用到的方法, 其余方法调用时panic
+type fakeTaskClient struct {
+	pb.TaskClient
+	inventory func() (*pb.NodeInfo, error)
+	getStatus func(id int64) (*pb.JobStatus, error)
+	stream    []streamMessage
+	calls     int
+}
+
+type streamMessage struct {
+	output *pb.TaskOutput
+	err    error
+}
+
+// 依次返回预设的输出
+type fakeStream struct {
+	pb.Task_RunStreamClient
+	messages []streamMessage
+}
+
+func (s *fakeStream) Recv() (*pb.TaskOutput, error) {
+	message := s.messages[0]
+	s.messages = s.messages[1:]
+	return message.output, message.err
+}
+
+func (f *fakeTaskClient) RunStream(ctx context.Context, in *pb.TaskRequest, opts ...grpc.CallOption) (pb.Task_RunStreamClient, error) {
+	f.calls++
+	return &fakeStream{messages: f.stream}, nil
+}
+
+func (f *fakeTaskClient) Inventory(ctx context.Context, in *pb.InventoryRequest, opts ...grpc.CallOption) (*pb.NodeInfo, error) {
+	f.calls++
+	return f.inventory()
+}
+
+func (f *fakeTaskClient) GetStatus(ctx context.Context, in *pb.JobRequest, opts ...grpc.CallOption) (*pb.JobStatus, error) {
+	f.calls++
+	return f.getStatus(in.Id)
+}
+
+func TestEnforcesTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		inventory func() (*pb.NodeInfo, error)
+		expected  bool
+		cached    bool
+	}{
+		{"current node", func() (*pb.NodeInfo, error) { return &pb.NodeInfo{EnforceTimeout: true}, nil }, true, true},
+		{"node without timeout", func() (*pb.NodeInfo, error) { return &pb.NodeInfo{}, nil }, false, true},
+		{"old node", func() (*pb.NodeInfo, error) { return nil, status.Error(codes.Unimplemented, "unknown method") }, false, true},
+		{"unreachable node", func() (*pb.NodeInfo, error) { return nil, errors.New("connection refused") }, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := "127.0.0.1:" + tt.name
+			defer timeoutCapability.Delete(addr)
+			c := &fakeTaskClient{inventory: tt.inventory}
+			if got := enforcesTimeout(addr, c); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+			enforcesTimeout(addr, c)
+			if tt.cached && c.calls != 1 {
+				t.Fatalf("expected capability to be cached, got %d calls", c.calls)
+			}
+			if !tt.cached && c.calls != 2 {
+				t.Fatalf("expected failed lookup not to be cached, got %d calls", c.calls)
+			}
+		})
+	}
+}
+
+func TestWaitDetached(t *testing.T) {
+	original := detachedPollInterval
+	defer func() { detachedPollInterval = original }()
+	detachedPollInterval = time.Millisecond
+
+	// 连接恢复前持续重试, 任务结束后返回节点保留的结果
+	c := &fakeTaskClient{}
+	c.getStatus = func(id int64) (*pb.JobStatus, error) {
+		switch c.calls {
+		case 1:
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		case 2:
+			return &pb.JobStatus{Id: id, Running: true}, nil
+		}
+		return &pb.JobStatus{Id: id, Result: &pb.TaskResponse{Output: "done"}}, nil
+	}
+	resp, err := waitDetached(context.Background(), c, 51)
+	if err != nil || resp.GetOutput() != "done" || c.calls != 3 {
+		t.Fatalf("unexpected result: %v %v, %d calls", resp, err, c.calls)
+	}
+
+	c = &fakeTaskClient{getStatus: func(id int64) (*pb.JobStatus, error) {
+		return nil, status.Error(codes.NotFound, "job not found")
+	}}
+	if _, err = waitDetached(context.Background(), c, 52); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected job not found, got %v", err)
+	}
+
+	running := func(id int64) (*pb.JobStatus, error) {
+		return &pb.JobStatus{Id: id, Running: true}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = waitDetached(ctx, &fakeTaskClient{getStatus: running}, 53); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err = waitDetached(ctx, &fakeTaskClient{getStatus: running}, 54); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+}
+
+func TestRunStreamConnectionLost(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection reset")
+	noop := func(stdout, stderr string) {}
+
+	// 任务开始前连接失败, 可以切换主机
+	c := &fakeTaskClient{stream: []streamMessage{{err: unavailable}}}
+	if _, err := runStream(context.Background(), c, "10.0.0.1:5921", &pb.TaskRequest{Id: 71}, noop); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected unavailable before start, got %v", err)
+	}
+
+	// 任务开始后连接中断, 任务可能已部分执行
+	c = &fakeTaskClient{stream: []streamMessage{{output: &pb.TaskOutput{Started: true}}, {err: unavailable}}}
+	if _, err := runStream(context.Background(), c, "10.0.0.1:5921", &pb.TaskRequest{Id: 72}, noop); !errors.Is(err, ErrConnectionLost) {
+		t.Fatalf("expected connection lost after start, got %v", err)
+	}
+	// 旧版本节点不发送Started, 已收到输出
+	c = &fakeTaskClient{stream: []streamMessage{{output: &pb.TaskOutput{Stdout: "partial"}}, {err: unavailable}}}
+	if _, err := runStream(context.Background(), c, "10.0.0.1:5921", &pb.TaskRequest{Id: 73, Detached: true}, noop); !errors.Is(err, ErrConnectionLost) {
+		t.Fatalf("expected connection lost after output, got %v", err)
+	}
+}
@@ -18,6 +18,8 @@ import (
 const (
 	backOffMaxDelay = 3 * time.Second
 	dialTimeout     = 2 * time.Second
+	// 执行结果包含合并输出、标准输出及标准错误, 超过默认的4MB限制
+	maxRecvMsgSize = 64 * 1024 * 1024
 )
 
 var (
@@ -86,6 +88,7 @@ func (p *GRPCPool) factory(addr string) (*Client, error) {
 			Backoff:           backoff.Config{MaxDelay: backOffMaxDelay},
 			MinConnectTimeout: dialTimeout,
 		}),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxRecvMsgSize)),
 	}
 
 	if !app.Setting.EnableTLS {
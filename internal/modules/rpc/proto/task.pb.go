@@ -12,6 +12,16 @@ It has these top-level messages:
 
 	TaskRequest
 	TaskResponse
+	TaskOutput
+	OutputRequest
+	OutputChunk
+	StopRequest
+	JobRequest
+	ListRunningRequest
+	JobStatus
+	JobList
+	InventoryRequest
+	NodeInfo
 */
 package rpc
 
@@ -38,9 +48,23 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type TaskRequest struct {
-	Command string `protobuf:"bytes,2,opt,name=command" json:"command,omitempty"`
-	Timeout int32  `protobuf:"varint,3,opt,name=timeout" json:"timeout,omitempty"`
-	Id      int64  `protobuf:"varint,4,opt,name=id" json:"id,omitempty"`
+	Command     string   `protobuf:"bytes,2,opt,name=command" json:"command,omitempty"`
+	Timeout     int32    `protobuf:"varint,3,opt,name=timeout" json:"timeout,omitempty"`
+	Id          int64    `protobuf:"varint,4,opt,name=id" json:"id,omitempty"`
+	Script      string   `protobuf:"bytes,5,opt,name=script" json:"script,omitempty"`
+	Interpreter string   `protobuf:"bytes,6,opt,name=interpreter" json:"interpreter,omitempty"`
+	Args        string   `protobuf:"bytes,7,opt,name=args" json:"args,omitempty"`
+	Env         []string `protobuf:"bytes,8,rep,name=env" json:"env,omitempty"`
+	WorkDir     string   `protobuf:"bytes,9,opt,name=work_dir,json=workDir" json:"work_dir,omitempty"`
+	User        string   `protobuf:"bytes,10,opt,name=user" json:"user,omitempty"`
+	Group       string   `protobuf:"bytes,11,opt,name=group" json:"group,omitempty"`
+	OutputLimit int64    `protobuf:"varint,12,opt,name=output_limit,json=outputLimit" json:"output_limit,omitempty"`
+	SaveOutput  bool     `protobuf:"varint,13,opt,name=save_output,json=saveOutput" json:"save_output,omitempty"`
+	StopSignal  string   `protobuf:"bytes,14,opt,name=stop_signal,json=stopSignal" json:"stop_signal,omitempty"`
+	GracePeriod int32    `protobuf:"varint,15,opt,name=grace_period,json=gracePeriod" json:"grace_period,omitempty"`
+	Detached    bool     `protobuf:"varint,16,opt,name=detached" json:"detached,omitempty"`
+	ExecCommand string   `protobuf:"bytes,17,opt,name=exec_command,json=execCommand" json:"exec_command,omitempty"`
+	ExecScript  string   `protobuf:"bytes,18,opt,name=exec_script,json=execScript" json:"exec_script,omitempty"`
 }
 
 func (m *TaskRequest) Reset()                    { *m = TaskRequest{} }
@@ -69,9 +93,120 @@ func (m *TaskRequest) GetId() int64 {
 	return 0
 }
 
+func (m *TaskRequest) GetScript() string {
+	if m != nil {
+		return m.Script
+	}
+	return ""
+}
+
+func (m *TaskRequest) GetInterpreter() string {
+	if m != nil {
+		return m.Interpreter
+	}
+	return ""
+}
+
+func (m *TaskRequest) GetArgs() string {
+	if m != nil {
+		return m.Args
+	}
+	return ""
+}
+
+func (m *TaskRequest) GetEnv() []string {
+	if m != nil {
+		return m.Env
+	}
+	return nil
+}
+
+func (m *TaskRequest) GetWorkDir() string {
+	if m != nil {
+		return m.WorkDir
+	}
+	return ""
+}
+
+func (m *TaskRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *TaskRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *TaskRequest) GetOutputLimit() int64 {
+	if m != nil {
+		return m.OutputLimit
+	}
+	return 0
+}
+
+func (m *TaskRequest) GetSaveOutput() bool {
+	if m != nil {
+		return m.SaveOutput
+	}
+	return false
+}
+
+func (m *TaskRequest) GetStopSignal() string {
+	if m != nil {
+		return m.StopSignal
+	}
+	return ""
+}
+
+func (m *TaskRequest) GetGracePeriod() int32 {
+	if m != nil {
+		return m.GracePeriod
+	}
+	return 0
+}
+
+func (m *TaskRequest) GetDetached() bool {
+	if m != nil {
+		return m.Detached
+	}
+	return false
+}
+
+func (m *TaskRequest) GetExecCommand() string {
+	if m != nil {
+		return m.ExecCommand
+	}
+	return ""
+}
+
+func (m *TaskRequest) GetExecScript() string {
+	if m != nil {
+		return m.ExecScript
+	}
+	return ""
+}
+
 type TaskResponse struct {
-	Output string `protobuf:"bytes,1,opt,name=output" json:"output,omitempty"`
-	Error  string `protobuf:"bytes,2,opt,name=error" json:"error,omitempty"`
+	Output     string `protobuf:"bytes,1,opt,name=output" json:"output,omitempty"`
+	Error      string `protobuf:"bytes,2,opt,name=error" json:"error,omitempty"`
+	Exited     bool   `protobuf:"varint,3,opt,name=exited" json:"exited,omitempty"`
+	ExitCode   int32  `protobuf:"varint,4,opt,name=exit_code,json=exitCode" json:"exit_code,omitempty"`
+	Stdout     string `protobuf:"bytes,5,opt,name=stdout" json:"stdout,omitempty"`
+	Stderr     string `protobuf:"bytes,6,opt,name=stderr" json:"stderr,omitempty"`
+	WallTime   int64  `protobuf:"varint,7,opt,name=wall_time,json=wallTime" json:"wall_time,omitempty"`
+	UserTime   int64  `protobuf:"varint,8,opt,name=user_time,json=userTime" json:"user_time,omitempty"`
+	SystemTime int64  `protobuf:"varint,9,opt,name=system_time,json=systemTime" json:"system_time,omitempty"`
+	MaxRss     int64  `protobuf:"varint,10,opt,name=max_rss,json=maxRss" json:"max_rss,omitempty"`
+	Truncated  bool   `protobuf:"varint,11,opt,name=truncated" json:"truncated,omitempty"`
+	OutputFile string `protobuf:"bytes,12,opt,name=output_file,json=outputFile" json:"output_file,omitempty"`
+	Signal     string `protobuf:"bytes,13,opt,name=signal" json:"signal,omitempty"`
+	TimedOut   bool   `protobuf:"varint,14,opt,name=timed_out,json=timedOut" json:"timed_out,omitempty"`
+	Canceled   bool   `protobuf:"varint,15,opt,name=canceled" json:"canceled,omitempty"`
 }
 
 func (m *TaskResponse) Reset()                    { *m = TaskResponse{} }
@@ -93,9 +228,446 @@ func (m *TaskResponse) GetError() string {
 	return ""
 }
 
+func (m *TaskResponse) GetExited() bool {
+	if m != nil {
+		return m.Exited
+	}
+	return false
+}
+
+func (m *TaskResponse) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+func (m *TaskResponse) GetStdout() string {
+	if m != nil {
+		return m.Stdout
+	}
+	return ""
+}
+
+func (m *TaskResponse) GetStderr() string {
+	if m != nil {
+		return m.Stderr
+	}
+	return ""
+}
+
+func (m *TaskResponse) GetWallTime() int64 {
+	if m != nil {
+		return m.WallTime
+	}
+	return 0
+}
+
+func (m *TaskResponse) GetUserTime() int64 {
+	if m != nil {
+		return m.UserTime
+	}
+	return 0
+}
+
+func (m *TaskResponse) GetSystemTime() int64 {
+	if m != nil {
+		return m.SystemTime
+	}
+	return 0
+}
+
+func (m *TaskResponse) GetMaxRss() int64 {
+	if m != nil {
+		return m.MaxRss
+	}
+	return 0
+}
+
+func (m *TaskResponse) GetTruncated() bool {
+	if m != nil {
+		return m.Truncated
+	}
+	return false
+}
+
+func (m *TaskResponse) GetOutputFile() string {
+	if m != nil {
+		return m.OutputFile
+	}
+	return ""
+}
+
+func (m *TaskResponse) GetSignal() string {
+	if m != nil {
+		return m.Signal
+	}
+	return ""
+}
+
+func (m *TaskResponse) GetTimedOut() bool {
+	if m != nil {
+		return m.TimedOut
+	}
+	return false
+}
+
+func (m *TaskResponse) GetCanceled() bool {
+	if m != nil {
+		return m.Canceled
+	}
+	return false
+}
+
+type TaskOutput struct {
+	Stdout  string        `protobuf:"bytes,1,opt,name=stdout" json:"stdout,omitempty"`
+	Stderr  string        `protobuf:"bytes,2,opt,name=stderr" json:"stderr,omitempty"`
+	Result  *TaskResponse `protobuf:"bytes,3,opt,name=result" json:"result,omitempty"`
+	Started bool          `protobuf:"varint,4,opt,name=started" json:"started,omitempty"`
+}
+
+func (m *TaskOutput) Reset()                    { *m = TaskOutput{} }
+func (m *TaskOutput) String() string            { return proto.CompactTextString(m) }
+func (*TaskOutput) ProtoMessage()               {}
+func (*TaskOutput) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *TaskOutput) GetStdout() string {
+	if m != nil {
+		return m.Stdout
+	}
+	return ""
+}
+
+func (m *TaskOutput) GetStderr() string {
+	if m != nil {
+		return m.Stderr
+	}
+	return ""
+}
+
+func (m *TaskOutput) GetResult() *TaskResponse {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *TaskOutput) GetStarted() bool {
+	if m != nil {
+		return m.Started
+	}
+	return false
+}
+
+type OutputRequest struct {
+	File   string `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
+	Offset int64  `protobuf:"varint,2,opt,name=offset" json:"offset,omitempty"`
+	Limit  int64  `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *OutputRequest) Reset()                    { *m = OutputRequest{} }
+func (m *OutputRequest) String() string            { return proto.CompactTextString(m) }
+func (*OutputRequest) ProtoMessage()               {}
+func (*OutputRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *OutputRequest) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *OutputRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *OutputRequest) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type OutputChunk struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Size int64  `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *OutputChunk) Reset()                    { *m = OutputChunk{} }
+func (m *OutputChunk) String() string            { return proto.CompactTextString(m) }
+func (*OutputChunk) ProtoMessage()               {}
+func (*OutputChunk) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *OutputChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *OutputChunk) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type StopRequest struct {
+	Id     int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Signal string `protobuf:"bytes,2,opt,name=signal" json:"signal,omitempty"`
+}
+
+func (m *StopRequest) Reset()                    { *m = StopRequest{} }
+func (m *StopRequest) String() string            { return proto.CompactTextString(m) }
+func (*StopRequest) ProtoMessage()               {}
+func (*StopRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *StopRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *StopRequest) GetSignal() string {
+	if m != nil {
+		return m.Signal
+	}
+	return ""
+}
+
+type JobRequest struct {
+	Id int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *JobRequest) Reset()                    { *m = JobRequest{} }
+func (m *JobRequest) String() string            { return proto.CompactTextString(m) }
+func (*JobRequest) ProtoMessage()               {}
+func (*JobRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *JobRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type ListRunningRequest struct {
+}
+
+func (m *ListRunningRequest) Reset()                    { *m = ListRunningRequest{} }
+func (m *ListRunningRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListRunningRequest) ProtoMessage()               {}
+func (*ListRunningRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+type JobStatus struct {
+	Id        int64         `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Running   bool          `protobuf:"varint,2,opt,name=running" json:"running,omitempty"`
+	Command   string        `protobuf:"bytes,3,opt,name=command" json:"command,omitempty"`
+	StartTime int64         `protobuf:"varint,4,opt,name=start_time,json=startTime" json:"start_time,omitempty"`
+	Result    *TaskResponse `protobuf:"bytes,5,opt,name=result" json:"result,omitempty"`
+}
+
+func (m *JobStatus) Reset()                    { *m = JobStatus{} }
+func (m *JobStatus) String() string            { return proto.CompactTextString(m) }
+func (*JobStatus) ProtoMessage()               {}
+func (*JobStatus) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *JobStatus) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *JobStatus) GetRunning() bool {
+	if m != nil {
+		return m.Running
+	}
+	return false
+}
+
+func (m *JobStatus) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *JobStatus) GetStartTime() int64 {
+	if m != nil {
+		return m.StartTime
+	}
+	return 0
+}
+
+func (m *JobStatus) GetResult() *TaskResponse {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+type JobList struct {
+	Jobs []*JobStatus `protobuf:"bytes,1,rep,name=jobs" json:"jobs,omitempty"`
+}
+
+func (m *JobList) Reset()                    { *m = JobList{} }
+func (m *JobList) String() string            { return proto.CompactTextString(m) }
+func (*JobList) ProtoMessage()               {}
+func (*JobList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *JobList) GetJobs() []*JobStatus {
+	if m != nil {
+		return m.Jobs
+	}
+	return nil
+}
+
+type InventoryRequest struct {
+}
+
+func (m *InventoryRequest) Reset()                    { *m = InventoryRequest{} }
+func (m *InventoryRequest) String() string            { return proto.CompactTextString(m) }
+func (*InventoryRequest) ProtoMessage()               {}
+func (*InventoryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+type NodeInfo struct {
+	Version        string  `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+	Os             string  `protobuf:"bytes,2,opt,name=os" json:"os,omitempty"`
+	Arch           string  `protobuf:"bytes,3,opt,name=arch" json:"arch,omitempty"`
+	CpuCount       int32   `protobuf:"varint,4,opt,name=cpu_count,json=cpuCount" json:"cpu_count,omitempty"`
+	Load1          float64 `protobuf:"fixed64,5,opt,name=load1" json:"load1,omitempty"`
+	Load5          float64 `protobuf:"fixed64,6,opt,name=load5" json:"load5,omitempty"`
+	Load15         float64 `protobuf:"fixed64,7,opt,name=load15" json:"load15,omitempty"`
+	MemTotal       int64   `protobuf:"varint,8,opt,name=mem_total,json=memTotal" json:"mem_total,omitempty"`
+	MemAvailable   int64   `protobuf:"varint,9,opt,name=mem_available,json=memAvailable" json:"mem_available,omitempty"`
+	DiskTotal      int64   `protobuf:"varint,10,opt,name=disk_total,json=diskTotal" json:"disk_total,omitempty"`
+	DiskFree       int64   `protobuf:"varint,11,opt,name=disk_free,json=diskFree" json:"disk_free,omitempty"`
+	RunningJobs    int32   `protobuf:"varint,12,opt,name=running_jobs,json=runningJobs" json:"running_jobs,omitempty"`
+	Uptime         int64   `protobuf:"varint,13,opt,name=uptime" json:"uptime,omitempty"`
+	EnforceTimeout bool    `protobuf:"varint,14,opt,name=enforce_timeout,json=enforceTimeout" json:"enforce_timeout,omitempty"`
+}
+
+func (m *NodeInfo) Reset()                    { *m = NodeInfo{} }
+func (m *NodeInfo) String() string            { return proto.CompactTextString(m) }
+func (*NodeInfo) ProtoMessage()               {}
+func (*NodeInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *NodeInfo) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetOs() string {
+	if m != nil {
+		return m.Os
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetArch() string {
+	if m != nil {
+		return m.Arch
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetCpuCount() int32 {
+	if m != nil {
+		return m.CpuCount
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetLoad1() float64 {
+	if m != nil {
+		return m.Load1
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetLoad5() float64 {
+	if m != nil {
+		return m.Load5
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetLoad15() float64 {
+	if m != nil {
+		return m.Load15
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetMemTotal() int64 {
+	if m != nil {
+		return m.MemTotal
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetMemAvailable() int64 {
+	if m != nil {
+		return m.MemAvailable
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetDiskTotal() int64 {
+	if m != nil {
+		return m.DiskTotal
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetDiskFree() int64 {
+	if m != nil {
+		return m.DiskFree
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetRunningJobs() int32 {
+	if m != nil {
+		return m.RunningJobs
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetUptime() int64 {
+	if m != nil {
+		return m.Uptime
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetEnforceTimeout() bool {
+	if m != nil {
+		return m.EnforceTimeout
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*TaskRequest)(nil), "rpc.TaskRequest")
 	proto.RegisterType((*TaskResponse)(nil), "rpc.TaskResponse")
+	proto.RegisterType((*TaskOutput)(nil), "rpc.TaskOutput")
+	proto.RegisterType((*OutputRequest)(nil), "rpc.OutputRequest")
+	proto.RegisterType((*OutputChunk)(nil), "rpc.OutputChunk")
+	proto.RegisterType((*StopRequest)(nil), "rpc.StopRequest")
+	proto.RegisterType((*JobRequest)(nil), "rpc.JobRequest")
+	proto.RegisterType((*ListRunningRequest)(nil), "rpc.ListRunningRequest")
+	proto.RegisterType((*JobStatus)(nil), "rpc.JobStatus")
+	proto.RegisterType((*JobList)(nil), "rpc.JobList")
+	proto.RegisterType((*InventoryRequest)(nil), "rpc.InventoryRequest")
+	proto.RegisterType((*NodeInfo)(nil), "rpc.NodeInfo")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -110,6 +682,12 @@ const _ = grpc.SupportPackageIsVersion4
 
 type TaskClient interface {
 	Run(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*TaskResponse, error)
+	RunStream(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (Task_RunStreamClient, error)
+	FetchOutput(ctx context.Context, in *OutputRequest, opts ...grpc.CallOption) (*OutputChunk, error)
+	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*JobStatus, error)
+	ListRunning(ctx context.Context, in *ListRunningRequest, opts ...grpc.CallOption) (*JobList, error)
+	GetStatus(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobStatus, error)
+	Inventory(ctx context.Context, in *InventoryRequest, opts ...grpc.CallOption) (*NodeInfo, error)
 }
 
 type taskClient struct {
@@ -129,10 +707,93 @@ func (c *taskClient) Run(ctx context.Context, in *TaskRequest, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *taskClient) RunStream(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (Task_RunStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Task_serviceDesc.Streams[0], c.cc, "/rpc.Task/RunStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &taskRunStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (c *taskClient) FetchOutput(ctx context.Context, in *OutputRequest, opts ...grpc.CallOption) (*OutputChunk, error) {
+	out := new(OutputChunk)
+	err := grpc.Invoke(ctx, "/rpc.Task/FetchOutput", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskClient) Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*JobStatus, error) {
+	out := new(JobStatus)
+	err := grpc.Invoke(ctx, "/rpc.Task/Stop", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskClient) ListRunning(ctx context.Context, in *ListRunningRequest, opts ...grpc.CallOption) (*JobList, error) {
+	out := new(JobList)
+	err := grpc.Invoke(ctx, "/rpc.Task/ListRunning", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskClient) GetStatus(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobStatus, error) {
+	out := new(JobStatus)
+	err := grpc.Invoke(ctx, "/rpc.Task/GetStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskClient) Inventory(ctx context.Context, in *InventoryRequest, opts ...grpc.CallOption) (*NodeInfo, error) {
+	out := new(NodeInfo)
+	err := grpc.Invoke(ctx, "/rpc.Task/Inventory", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type Task_RunStreamClient interface {
+	Recv() (*TaskOutput, error)
+	grpc.ClientStream
+}
+
+type taskRunStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *taskRunStreamClient) Recv() (*TaskOutput, error) {
+	m := new(TaskOutput)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for Task service
 
 type TaskServer interface {
 	Run(context.Context, *TaskRequest) (*TaskResponse, error)
+	RunStream(*TaskRequest, Task_RunStreamServer) error
+	FetchOutput(context.Context, *OutputRequest) (*OutputChunk, error)
+	Stop(context.Context, *StopRequest) (*JobStatus, error)
+	ListRunning(context.Context, *ListRunningRequest) (*JobList, error)
+	GetStatus(context.Context, *JobRequest) (*JobStatus, error)
+	Inventory(context.Context, *InventoryRequest) (*NodeInfo, error)
 }
 
 func RegisterTaskServer(s *grpc.Server, srv TaskServer) {
@@ -157,6 +818,117 @@ func _Task_Run_Handler(srv interface{}, ctx context.Context, dec func(interface{
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Task_RunStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TaskRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TaskServer).RunStream(m, &taskRunStreamServer{stream})
+}
+
+func _Task_FetchOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OutputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServer).FetchOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Task/FetchOutput",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServer).FetchOutput(ctx, req.(*OutputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Task_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Task/Stop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServer).Stop(ctx, req.(*StopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Task_ListRunning_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRunningRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServer).ListRunning(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Task/ListRunning",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServer).ListRunning(ctx, req.(*ListRunningRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Task_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Task/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServer).GetStatus(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Task_Inventory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InventoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServer).Inventory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Task/Inventory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServer).Inventory(ctx, req.(*InventoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type Task_RunStreamServer interface {
+	Send(*TaskOutput) error
+	grpc.ServerStream
+}
+
+type taskRunStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *taskRunStreamServer) Send(m *TaskOutput) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Task_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "rpc.Task",
 	HandlerType: (*TaskServer)(nil),
@@ -165,25 +937,107 @@ var _Task_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Run",
 			Handler:    _Task_Run_Handler,
 		},
+		{
+			MethodName: "FetchOutput",
+			Handler:    _Task_FetchOutput_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _Task_Stop_Handler,
+		},
+		{
+			MethodName: "ListRunning",
+			Handler:    _Task_ListRunning_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Task_GetStatus_Handler,
+		},
+		{
+			MethodName: "Inventory",
+			Handler:    _Task_Inventory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RunStream",
+			Handler:       _Task_RunStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "task.proto",
 }
 
 func init() { proto.RegisterFile("task.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 184 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x8f, 0xcf, 0x8a, 0x83, 0x30,
-	0x10, 0xc6, 0x37, 0x46, 0x5d, 0x76, 0x76, 0x59, 0xda, 0xa1, 0x94, 0xd0, 0x93, 0x78, 0xf2, 0x50,
-	0x3c, 0xb4, 0x3d, 0xf6, 0x25, 0x1a, 0xfa, 0x02, 0x56, 0x73, 0x10, 0xd1, 0x49, 0x93, 0xc9, 0xfb,
-	0x17, 0xff, 0x81, 0xc7, 0xdf, 0x0c, 0xf3, 0xfd, 0xe6, 0x03, 0xe0, 0xca, 0x77, 0xa5, 0x75, 0xc4,
-	0x84, 0xd2, 0xd9, 0x3a, 0x7f, 0xc0, 0xef, 0xb3, 0xf2, 0x9d, 0x36, 0xef, 0x60, 0x3c, 0xa3, 0x82,
-	0xef, 0x9a, 0xfa, 0xbe, 0x1a, 0x1a, 0x15, 0x65, 0xa2, 0xf8, 0xd1, 0x2b, 0x8e, 0x1b, 0x6e, 0x7b,
-	0x43, 0x81, 0x95, 0xcc, 0x44, 0x91, 0xe8, 0x15, 0xf1, 0x1f, 0xa2, 0xb6, 0x51, 0x71, 0x26, 0x0a,
-	0xa9, 0xa3, 0xb6, 0xc9, 0xef, 0xf0, 0x37, 0x47, 0x7a, 0x4b, 0x83, 0x37, 0x78, 0x84, 0x94, 0x02,
-	0xdb, 0xc0, 0x4a, 0x4c, 0x91, 0x0b, 0xe1, 0x01, 0x12, 0xe3, 0x1c, 0xb9, 0xc5, 0x34, 0xc3, 0xe5,
-	0x06, 0xf1, 0x78, 0x8d, 0x67, 0x90, 0x3a, 0x0c, 0xb8, 0x2b, 0x9d, 0xad, 0xcb, 0xcd, 0x8b, 0xa7,
-	0xfd, 0x66, 0x32, 0x1b, 0xf2, 0xaf, 0x57, 0x3a, 0x55, 0xba, 0x7e, 0x02, 0x00, 0x00, 0xff, 0xff,
-	0xd7, 0x7f, 0x8a, 0x9d, 0xe0, 0x00, 0x00, 0x00,
+	// 1077 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x56, 0x51, 0x73, 0xdc, 0x34,
+	0x10, 0x3e, 0x9f, 0x2f, 0x17, 0x7b, 0x7d, 0x97, 0xa4, 0x9a, 0x42, 0xcd, 0xb5, 0x4c, 0x0f, 0xf3,
+	0xc0, 0xc1, 0x40, 0x06, 0xd2, 0x09, 0xef, 0x4c, 0x98, 0x32, 0xcd, 0x74, 0x28, 0x28, 0x79, 0xf7,
+	0xe8, 0x6c, 0x5d, 0x62, 0x62, 0x5b, 0x46, 0x92, 0xd3, 0x94, 0x37, 0x9e, 0xf9, 0x05, 0xfc, 0x00,
+	0xfe, 0x16, 0xcf, 0xfc, 0x0c, 0x66, 0x57, 0x72, 0x72, 0x69, 0xe9, 0xf0, 0xa6, 0xef, 0x5b, 0x79,
+	0xb5, 0xda, 0xef, 0x5b, 0xdd, 0x01, 0x58, 0x61, 0xae, 0x0e, 0x3b, 0xad, 0xac, 0x62, 0xa1, 0xee,
+	0x8a, 0xec, 0xef, 0x10, 0x92, 0x73, 0x61, 0xae, 0xb8, 0xfc, 0xb5, 0x97, 0xc6, 0xb2, 0x14, 0x76,
+	0x0b, 0xd5, 0x34, 0xa2, 0x2d, 0xd3, 0xf1, 0x32, 0x58, 0xc5, 0x7c, 0x80, 0x18, 0xb1, 0x55, 0x23,
+	0x55, 0x6f, 0xd3, 0x70, 0x19, 0xac, 0x76, 0xf8, 0x00, 0xd9, 0x1e, 0x8c, 0xab, 0x32, 0x9d, 0x2c,
+	0x83, 0x55, 0xc8, 0xc7, 0x55, 0xc9, 0x3e, 0x84, 0xa9, 0x29, 0x74, 0xd5, 0xd9, 0x74, 0x87, 0x52,
+	0x78, 0xc4, 0x96, 0x90, 0x54, 0xad, 0x95, 0xba, 0xd3, 0xd2, 0x4a, 0x9d, 0x4e, 0x29, 0xb8, 0x4d,
+	0x31, 0x06, 0x13, 0xa1, 0x2f, 0x4c, 0xba, 0x4b, 0x21, 0x5a, 0xb3, 0x03, 0x08, 0x65, 0x7b, 0x9d,
+	0x46, 0xcb, 0x70, 0x15, 0x73, 0x5c, 0xb2, 0x8f, 0x20, 0x7a, 0xad, 0xf4, 0x55, 0x5e, 0x56, 0x3a,
+	0x8d, 0x5d, 0x91, 0x88, 0xbf, 0xaf, 0x28, 0x41, 0x6f, 0xa4, 0x4e, 0xc1, 0x25, 0xc0, 0x35, 0x7b,
+	0x08, 0x3b, 0x17, 0x5a, 0xf5, 0x5d, 0x9a, 0x10, 0xe9, 0x00, 0xfb, 0x04, 0x66, 0xaa, 0xb7, 0x5d,
+	0x6f, 0xf3, 0xba, 0x6a, 0x2a, 0x9b, 0xce, 0xa8, 0xfc, 0xc4, 0x71, 0x2f, 0x91, 0x62, 0x4f, 0x21,
+	0x31, 0xe2, 0x5a, 0xe6, 0x8e, 0x4b, 0xe7, 0xcb, 0x60, 0x15, 0x71, 0x40, 0xea, 0x15, 0x31, 0xb4,
+	0xc1, 0xaa, 0x2e, 0x37, 0xd5, 0x45, 0x2b, 0xea, 0x74, 0x8f, 0xf2, 0x03, 0x52, 0x67, 0xc4, 0xe0,
+	0x21, 0x17, 0x5a, 0x14, 0x32, 0xef, 0xa4, 0xae, 0x54, 0x99, 0xee, 0x53, 0xe3, 0x12, 0xe2, 0x7e,
+	0x22, 0x8a, 0x2d, 0x20, 0x2a, 0xa5, 0x15, 0xc5, 0xa5, 0x2c, 0xd3, 0x03, 0x3a, 0xe1, 0x16, 0xe3,
+	0xe7, 0xf2, 0x46, 0x16, 0xf9, 0xa0, 0xc8, 0x03, 0xd7, 0x31, 0xe4, 0x4e, 0xbc, 0x2a, 0x4f, 0x81,
+	0x60, 0xee, 0x1b, 0xce, 0x5c, 0x09, 0x48, 0x9d, 0x11, 0x93, 0xfd, 0x15, 0xc2, 0xcc, 0x09, 0x6c,
+	0x3a, 0xd5, 0x1a, 0x89, 0xea, 0xf8, 0x0b, 0x05, 0x4e, 0x1d, 0x87, 0xb0, 0x4d, 0x52, 0x6b, 0xa5,
+	0xbd, 0xee, 0x0e, 0xe0, 0x6e, 0x79, 0x53, 0x59, 0x59, 0x92, 0xe8, 0x11, 0xf7, 0x88, 0x3d, 0x86,
+	0x18, 0x57, 0x79, 0xa1, 0x4a, 0x49, 0xd2, 0xef, 0xf0, 0x08, 0x89, 0x13, 0x55, 0xd2, 0x11, 0xc6,
+	0x96, 0xaa, 0xbf, 0x33, 0x00, 0x21, 0xcf, 0x4b, 0x3d, 0x68, 0xef, 0x11, 0x26, 0x7b, 0x2d, 0xea,
+	0x3a, 0x47, 0x43, 0x91, 0xf6, 0x21, 0x8f, 0x90, 0x38, 0xaf, 0x1a, 0x89, 0x41, 0x94, 0xd1, 0x05,
+	0x23, 0x17, 0x44, 0x82, 0x82, 0xa8, 0xc0, 0x1b, 0x63, 0x65, 0xe3, 0xc2, 0x31, 0x85, 0xc1, 0x51,
+	0xb4, 0xe1, 0x11, 0xec, 0x36, 0xe2, 0x26, 0xd7, 0xc6, 0x90, 0x27, 0x42, 0x3e, 0x6d, 0xc4, 0x0d,
+	0x37, 0x86, 0x3d, 0x81, 0xd8, 0xea, 0xbe, 0x2d, 0x04, 0xde, 0x2d, 0xa1, 0xbb, 0xdd, 0x11, 0x98,
+	0xd7, 0xbb, 0x63, 0x53, 0xd5, 0x92, 0xcc, 0x11, 0x73, 0x70, 0xd4, 0xf3, 0xaa, 0x76, 0x57, 0x74,
+	0xaa, 0xcf, 0xfd, 0x55, 0x08, 0x61, 0xb5, 0x58, 0x49, 0x89, 0xa6, 0x21, 0x43, 0x44, 0x3c, 0x22,
+	0xe2, 0x55, 0x6f, 0x51, 0xeb, 0x42, 0xb4, 0x85, 0xac, 0xa5, 0xb3, 0x42, 0xc4, 0x6f, 0x71, 0xf6,
+	0x7b, 0x00, 0x80, 0x3a, 0x79, 0x6b, 0xdd, 0xb5, 0x30, 0x78, 0x4f, 0x0b, 0xc7, 0xf7, 0x5a, 0xf8,
+	0x39, 0x4c, 0xb5, 0x34, 0x7d, 0xed, 0x86, 0x33, 0x39, 0x7a, 0x70, 0xa8, 0xbb, 0xe2, 0x70, 0x5b,
+	0x78, 0xee, 0x37, 0xe0, 0x20, 0x1b, 0x2b, 0x34, 0xde, 0x7b, 0x42, 0x45, 0x0c, 0x30, 0xfb, 0x19,
+	0xe6, 0xee, 0xf8, 0xe1, 0x35, 0x60, 0x30, 0xa1, 0xfb, 0xbb, 0x1a, 0x68, 0x4d, 0xfe, 0xd9, 0x6c,
+	0x8c, 0xb4, 0x54, 0x41, 0xc8, 0x3d, 0x42, 0xff, 0xb8, 0x49, 0x0a, 0x89, 0x76, 0x20, 0x3b, 0x86,
+	0xc4, 0xa5, 0x3c, 0xb9, 0xec, 0xdb, 0x2b, 0x4c, 0x58, 0x0a, 0x2b, 0x28, 0xe1, 0x8c, 0xd3, 0x1a,
+	0x39, 0x53, 0xfd, 0x26, 0x7d, 0x3a, 0x5a, 0xe3, 0x67, 0x67, 0x56, 0x75, 0x43, 0x1d, 0xee, 0x85,
+	0x09, 0xee, 0xbd, 0x30, 0xae, 0xfb, 0xe3, 0xed, 0xee, 0x67, 0x4f, 0x00, 0x4e, 0xd5, 0xfa, 0x3d,
+	0x5f, 0x65, 0x0f, 0x81, 0xbd, 0xac, 0x8c, 0xe5, 0x7d, 0xdb, 0x56, 0xed, 0x85, 0xdf, 0x95, 0xfd,
+	0x19, 0x40, 0x7c, 0xaa, 0xd6, 0x67, 0x56, 0xd8, 0xde, 0xbc, 0x73, 0x52, 0x0a, 0xbb, 0xda, 0xed,
+	0xa7, 0xa3, 0x22, 0x3e, 0xc0, 0xed, 0x97, 0x32, 0xbc, 0xff, 0x52, 0x7e, 0x0c, 0x40, 0x1d, 0x75,
+	0x9e, 0x74, 0xef, 0x62, 0x4c, 0x0c, 0x59, 0xf2, 0x4e, 0xaa, 0x9d, 0xff, 0x91, 0x2a, 0xfb, 0x0a,
+	0x76, 0x4f, 0xd5, 0x1a, 0x8b, 0x66, 0x19, 0x4c, 0x7e, 0x51, 0x6b, 0x93, 0x06, 0xcb, 0x70, 0x95,
+	0x1c, 0xed, 0xd1, 0x37, 0xb7, 0x65, 0x73, 0x8a, 0x65, 0x0c, 0x0e, 0x5e, 0xb4, 0xd7, 0xb2, 0xb5,
+	0x4a, 0xbf, 0x19, 0xae, 0xf7, 0x47, 0x08, 0xd1, 0x8f, 0xaa, 0x94, 0x2f, 0xda, 0x8d, 0xc2, 0x9a,
+	0xaf, 0xa5, 0x36, 0x95, 0x6a, 0xbd, 0xa4, 0x03, 0xc4, 0x7b, 0x2b, 0xe3, 0xbb, 0x39, 0x56, 0xc6,
+	0xbd, 0xc4, 0xc5, 0xa5, 0xbf, 0x1a, 0xad, 0xd1, 0xdb, 0x45, 0xd7, 0xe7, 0x85, 0xea, 0x5b, 0x3b,
+	0xcc, 0x7c, 0xd1, 0xf5, 0x27, 0x88, 0x49, 0x7e, 0x25, 0xca, 0x6f, 0xe8, 0x52, 0x01, 0x77, 0x60,
+	0x60, 0x8f, 0xd3, 0xe9, 0x1d, 0x7b, 0x8c, 0xf2, 0x51, 0xf8, 0x98, 0x86, 0x3d, 0xe0, 0x1e, 0xe1,
+	0x01, 0x0d, 0x8e, 0xb2, 0xb2, 0xa2, 0x1e, 0x46, 0xbd, 0x91, 0xcd, 0x39, 0x62, 0xf6, 0x29, 0xcc,
+	0x31, 0x28, 0xae, 0x45, 0x55, 0x8b, 0x75, 0x3d, 0x0c, 0xfb, 0xac, 0x91, 0xcd, 0x77, 0x03, 0x87,
+	0xad, 0x2f, 0x2b, 0x73, 0xe5, 0x53, 0xb8, 0x89, 0x8f, 0x91, 0x71, 0x39, 0x1e, 0x03, 0x81, 0x7c,
+	0xa3, 0xa5, 0xa4, 0xa1, 0x0f, 0x79, 0x84, 0xc4, 0x73, 0x2d, 0x25, 0xbe, 0xb6, 0x5e, 0xdb, 0x9c,
+	0x3a, 0x3d, 0x73, 0x8f, 0xb5, 0xe7, 0x4e, 0xd5, 0xda, 0x60, 0xe1, 0x7d, 0x47, 0xaa, 0xce, 0x9d,
+	0xf7, 0x1d, 0x62, 0x9f, 0xc1, 0xbe, 0x6c, 0x37, 0x4a, 0x17, 0x32, 0x1f, 0x7e, 0x23, 0xdd, 0xec,
+	0xef, 0x79, 0xfa, 0xdc, 0xb1, 0x47, 0xff, 0x8c, 0x61, 0x82, 0x4a, 0xb3, 0x2f, 0x21, 0xe4, 0x7d,
+	0xcb, 0x0e, 0xb6, 0xb4, 0x27, 0xbd, 0x16, 0xef, 0xba, 0x21, 0x1b, 0xb1, 0x23, 0x88, 0x79, 0xdf,
+	0x9e, 0x59, 0x2d, 0x45, 0xf3, 0x1f, 0xdf, 0xec, 0xdf, 0x32, 0x6e, 0xd6, 0xb2, 0xd1, 0xd7, 0x01,
+	0x3b, 0x86, 0xe4, 0xb9, 0xb4, 0xc5, 0xa5, 0xa3, 0x18, 0xa3, 0x3d, 0xf7, 0xc6, 0x7b, 0x71, 0xb0,
+	0xc5, 0xd1, 0x7c, 0x66, 0x23, 0xf6, 0x05, 0x4c, 0x70, 0xf2, 0xfc, 0x29, 0x5b, 0x43, 0xb8, 0x78,
+	0xcb, 0x73, 0xd9, 0x88, 0x7d, 0x0b, 0xc9, 0xd6, 0x40, 0xb1, 0x47, 0xb4, 0xe1, 0xdd, 0x11, 0x5b,
+	0xcc, 0x86, 0x2f, 0x31, 0x96, 0x8d, 0xd8, 0x21, 0xc4, 0x3f, 0x48, 0xeb, 0x27, 0x6e, 0x7f, 0x08,
+	0xbe, 0xff, 0x9c, 0x67, 0x10, 0xdf, 0xfa, 0x9a, 0x7d, 0x40, 0xe1, 0xb7, 0x7d, 0xbe, 0x98, 0x13,
+	0x3d, 0x38, 0x3d, 0x1b, 0xad, 0xa7, 0xf4, 0x2f, 0xe7, 0xd9, 0xbf, 0x03, 0x00, 0x41, 0x90, 0x47,
+	0x81, 0xf3, 0x08, 0x00, 0x00,
 }
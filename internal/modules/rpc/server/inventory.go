@@ -0,0 +1,37 @@
+package server
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+	"golang.org/x/net/context"
+)
+
+// gocron-node启动时间, 用于计算运行时长
+var startTime = time.Now()
+
+// Inventory 节点的版本、系统及资源使用情况, 读取失败的项为0
+func (s Server) Inventory(ctx context.Context, req *pb.InventoryRequest) (*pb.NodeInfo, error) {
+	info := &pb.NodeInfo{
+		Version:     s.Version,
+		Os:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		CpuCount:    int32(runtime.NumCPU()),
+		RunningJobs: int32(len(jobs.list())),
+		Uptime:      int64(time.Since(startTime).Seconds()),
+		// 由节点控制执行超时, 见execute
+		EnforceTimeout: true,
+	}
+	info.Load1, info.Load5, info.Load15 = loadAverage()
+	info.MemTotal, info.MemAvailable = memoryUsage()
+	// 输出目录未创建时统计临时目录所在磁盘
+	dir := s.Output.Dir
+	if _, err := os.Stat(dir); err != nil {
+		dir = os.TempDir()
+	}
+	info.DiskTotal, info.DiskFree = diskUsage(dir)
+
+	return info, nil
+}
@@ -0,0 +1,57 @@
+//go:build windows
+// +build windows
+
+package server
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+	procGetDiskFreeSpaceEx   = kernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// Windows没有平均负载
+func loadAverage() (load1, load5, load15 float64) {
+	return
+}
+
+type memoryStatusEx struct {
+	length               uint32
+	memoryLoad           uint32
+	totalPhys            uint64
+	availPhys            uint64
+	totalPageFile        uint64
+	availPageFile        uint64
+	totalVirtual         uint64
+	availVirtual         uint64
+	availExtendedVirtual uint64
+}
+
+func memoryUsage() (total, available int64) {
+	status := memoryStatusEx{}
+	status.length = uint32(unsafe.Sizeof(status))
+	if ret, _, _ := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); ret == 0 {
+		return
+	}
+
+	return int64(status.totalPhys), int64(status.availPhys)
+}
+
+func diskUsage(path string) (total, free int64) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return
+	}
+	var freeBytes, totalBytes, totalFreeBytes uint64
+	ret, _, _ := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&freeBytes)), uintptr(unsafe.Pointer(&totalBytes)), uintptr(unsafe.Pointer(&totalFreeBytes)))
+	if ret == 0 {
+		return
+	}
+
+	return int64(totalBytes), int64(freeBytes)
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// 保留已结束任务执行结果的数量及时间, 供gocron重启后查询
+	maxFinishedJobs = 64
+	finishedJobTTL  = time.Hour
+	// 分离执行的任务结果保留更长时间, 且不受保留数量限制, 等待gocron重新连接后获取
+	detachedJobTTL = 24 * time.Hour
+)
+
+// 节点上运行中及最近结束的任务, 执行任务唯一ID作为Key
+var jobs = newJobRegistry()
+
+type job struct {
+	status   *pb.JobStatus
+	cancel   context.CancelCauseFunc
+	stopped  bool
+	detached bool
+}
+
+type finishedJob struct {
+	status   *pb.JobStatus
+	expireAt time.Time
+	detached bool
+}
+
+type jobRegistry struct {
+	mu       sync.Mutex
+	running  map[int64]*job
+	finished map[int64]finishedJob
+	order    []int64 // 已结束任务的结束顺序
+}
+
+func newJobRegistry() *jobRegistry {
+	return &jobRegistry{
+		running:  make(map[int64]*job),
+		finished: make(map[int64]finishedJob),
+	}
+}
+
+// 登记开始执行的任务, 返回的ctx可通过Stop取消
+func (r *jobRegistry) start(ctx context.Context, req *pb.TaskRequest) (context.Context, *job) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	command := req.Command
+	if req.Script != "" {
+		command = req.Args
+	}
+	j := &job{
+		status:   &pb.JobStatus{Id: req.Id, Running: true, Command: command, StartTime: time.Now().Unix()},
+		cancel:   cancel,
+		detached: req.Detached,
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running[req.Id] = j
+	r.forget(req.Id)
+
+	return ctx, j
+}
+
+// 记录任务的执行结果, 超过保留数量时删除最早结束的任务
+func (r *jobRegistry) finish(j *job, resp *pb.TaskResponse) {
+	j.cancel(nil)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := j.status.Id
+	if r.running[id] == j {
+		delete(r.running, id)
+	}
+	r.forget(id)
+	ttl := finishedJobTTL
+	if j.detached {
+		ttl = detachedJobTTL
+	}
+	r.finished[id] = finishedJob{
+		status:   &pb.JobStatus{Id: id, Command: j.status.Command, StartTime: j.status.StartTime, Result: resp},
+		expireAt: time.Now().Add(ttl),
+		detached: j.detached,
+	}
+	r.order = append(r.order, id)
+	r.purge()
+}
+
+// 删除超过保留时间的已结束任务, 超过保留数量时删除最早结束的非分离任务
+func (r *jobRegistry) purge() {
+	now := time.Now()
+	excess := len(r.order) - maxFinishedJobs
+	kept := r.order[:0]
+	for _, id := range r.order {
+		item := r.finished[id]
+		if now.Before(item.expireAt) && (excess <= 0 || item.detached) {
+			kept = append(kept, id)
+			continue
+		}
+		delete(r.finished, id)
+		excess--
+	}
+	r.order = kept
+}
+
+func (r *jobRegistry) forget(id int64) {
+	if _, ok := r.finished[id]; !ok {
+		return
+	}
+	delete(r.finished, id)
+	for i, item := range r.order {
+		if item == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// 任务是否由Stop停止
+func (r *jobRegistry) stopped(j *job) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return j.stopped
+}
+
+// 向运行中的任务发送停止信号, signal为空时使用任务指定的信号
+func (r *jobRegistry) stop(id int64, signal string) (*pb.JobStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.running[id]
+	if !ok {
+		return nil, false
+	}
+	j.stopped = true
+	j.cancel(utils.StopCause{Signal: signal})
+
+	return j.status, true
+}
+
+func (r *jobRegistry) get(id int64) (*pb.JobStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if j, ok := r.running[id]; ok {
+		return j.status, true
+	}
+	r.purge()
+	item, ok := r.finished[id]
+
+	return item.status, ok
+}
+
+func (r *jobRegistry) list() []*pb.JobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*pb.JobStatus, 0, len(r.running))
+	for _, j := range r.running {
+		list = append(list, j.status)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Id < list[j].Id
+	})
+
+	return list
+}
+
+// Stop 停止运行中的任务, 先发送停止信号, 宽限期后发送SIGKILL
+func (s Server) Stop(ctx context.Context, req *pb.StopRequest) (*pb.JobStatus, error) {
+	jobStatus, ok := jobs.stop(req.Id, req.Signal)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "job is not running")
+	}
+	log.Infof("stop job: [id: %d signal: %s]", req.Id, req.Signal)
+
+	return jobStatus, nil
+}
+
+// ListRunning 节点上运行中的任务
+func (s Server) ListRunning(ctx context.Context, req *pb.ListRunningRequest) (*pb.JobList, error) {
+	return &pb.JobList{Jobs: jobs.list()}, nil
+}
+
+// GetStatus 任务的运行状态, 已结束的任务包含执行结果, 仅保留最近结束的任务
+func (s Server) GetStatus(ctx context.Context, req *pb.JobRequest) (*pb.JobStatus, error) {
+	jobStatus, ok := jobs.get(req.Id)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "job not found")
+	}
+
+	return jobStatus, nil
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 单次读取完整输出的最大字节数
+const outputChunkSize = 1024 * 1024
+
+var outputFilePattern = regexp.MustCompile(`^[0-9]+-[0-9]+\.log$`)
+
+// OutputPolicy 任务输出的保留上限及完整输出的保存目录
+type OutputPolicy struct {
+	Limit    int64  // 输出保留的最大字节数, 任务未指定或超过时使用, 0表示不限制
+	Dir      string // 完整输出的保存目录, 为空时不保存
+	KeepDays int    // 完整输出文件的保留天数, 0表示不清理
+}
+
+// 任务指定的输出上限不能超过节点的上限
+func (p OutputPolicy) limit(requested int64) int {
+	if requested <= 0 || (p.Limit > 0 && requested > p.Limit) {
+		return int(p.Limit)
+	}
+
+	return int(requested)
+}
+
+// 创建保存完整输出的文件, 文件名以任务日志ID开头
+func (p OutputPolicy) create(id int64) (*os.File, error) {
+	if p.Dir == "" {
+		return nil, fmt.Errorf("output dir is not configured")
+	}
+	if err := os.MkdirAll(p.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return os.CreateTemp(p.Dir, fmt.Sprintf("%d-*.log", id))
+}
+
+// 删除超过保留天数的完整输出文件
+func (p OutputPolicy) purge() {
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		return
+	}
+	expired := time.Now().AddDate(0, 0, -p.KeepDays)
+	for _, entry := range entries {
+		if !outputFilePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(expired) {
+			continue
+		}
+		if err = os.Remove(filepath.Join(p.Dir, entry.Name())); err != nil {
+			log.Warnf("remove output file failed: %s", err)
+		}
+	}
+}
+
+func (p OutputPolicy) startPurge() {
+	if p.Dir == "" || p.KeepDays <= 0 {
+		return
+	}
+	go func() {
+		for {
+			p.purge()
+			time.Sleep(time.Hour)
+		}
+	}()
+}
+
+// FetchOutput 分段读取保存在节点上的完整输出
+func (s Server) FetchOutput(ctx context.Context, req *pb.OutputRequest) (*pb.OutputChunk, error) {
+	if s.Output.Dir == "" {
+		return nil, status.Error(codes.FailedPrecondition, "output dir is not configured")
+	}
+	if !outputFilePattern.MatchString(req.File) || req.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid output file")
+	}
+	file, err := os.Open(filepath.Join(s.Output.Dir, req.File))
+	if os.IsNotExist(err) {
+		return nil, status.Error(codes.NotFound, "output file not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	size := req.Limit
+	if size <= 0 || size > outputChunkSize {
+		size = outputChunkSize
+	}
+	data := make([]byte, size)
+	n, err := file.ReadAt(data, req.Offset)
+	if err != nil && err != io.EOF {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.OutputChunk{Data: data[:n], Size: info.Size()}, nil
+}
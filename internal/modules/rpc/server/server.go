@@ -1,9 +1,12 @@
 package server
 
 import (
+	"fmt"
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
@@ -17,7 +20,39 @@ import (
 	"google.golang.org/grpc/keepalive"
 )
 
-type Server struct{}
+type Server struct {
+	Version string
+	RunAs   RunAsPolicy
+	Output  OutputPolicy
+}
+
+// RunAsPolicy 允许任务切换的执行用户及用户组, 列表为空时拒绝切换
+type RunAsPolicy struct {
+	Users  []string
+	Groups []string
+}
+
+// Check 校验任务指定的执行用户及用户组是否在允许列表中
+func (p RunAsPolicy) Check(user, group string) error {
+	if user != "" && !contains(p.Users, user) {
+		return fmt.Errorf("user %s is not allowed to run tasks on this node", user)
+	}
+	if group != "" && !contains(p.Groups, group) {
+		return fmt.Errorf("group %s is not allowed to run tasks on this node", group)
+	}
+
+	return nil
+}
+
+func contains(list []string, item string) bool {
+	for _, v := range list {
+		if v == item {
+			return true
+		}
+	}
+
+	return false
+}
 
 var keepAlivePolicy = keepalive.EnforcementPolicy{
 	MinTime:             10 * time.Second,
@@ -31,26 +66,138 @@ var keepAliveParams = keepalive.ServerParameters{
 }
 
 func (s Server) Run(ctx context.Context, req *pb.TaskRequest) (*pb.TaskResponse, error) {
+	return s.execute(ctx, req, utils.ExecOptions{}), nil
+}
+
+// RunStream 执行过程中持续发送输出片段, 最后发送执行结果
+func (s Server) RunStream(req *pb.TaskRequest, stream pb.Task_RunStreamServer) error {
+	sender := &outputSender{stream: stream}
+	// 通知调用方任务已开始执行, 连接中断时据此判断是否需要查询执行结果
+	if err := sender.send(&pb.TaskOutput{Started: true}); err != nil {
+		return err
+	}
+	resp := s.execute(stream.Context(), req, utils.ExecOptions{
+		Stdout: outputWriter{sender, false},
+		Stderr: outputWriter{sender, true},
+	})
+
+	return sender.send(&pb.TaskOutput{Result: resp})
+}
+
+func (s Server) execute(ctx context.Context, req *pb.TaskRequest, options utils.ExecOptions) (resp *pb.TaskResponse) {
+	resp = new(pb.TaskResponse)
 	defer func() {
 		if err := recover(); err != nil {
 			log.Error(err)
 		}
 	}()
-	log.Infof("execute cmd start: [id: %d cmd: %s]", req.Id, req.Command)
-	output, err := utils.ExecShell(ctx, req.Command)
-	resp := new(pb.TaskResponse)
-	resp.Output = output
+	// 设置了执行选项时, 实际的命令和脚本在exec字段中
+	if req.ExecCommand != "" {
+		req.Command = req.ExecCommand
+	}
+	if req.ExecScript != "" {
+		req.Script = req.ExecScript
+	}
+	// 分离执行的任务不随调用方断开而结束, 执行结果保留在节点上供查询
+	if req.Detached {
+		ctx = context.Background()
+	}
+	ctx, j := jobs.start(ctx, req)
+	defer func() {
+		jobs.finish(j, resp)
+	}()
+	options.Env = req.Env
+	options.Dir = req.WorkDir
+	options.User = req.User
+	options.Group = req.Group
+	options.OutputLimit = s.Output.limit(req.OutputLimit)
+	if req.OutputLimit > 0 && int64(options.OutputLimit) < req.OutputLimit {
+		log.Warnf("output limit exceeds node limit: [id: %d requested: %d effective: %d]", req.Id, req.OutputLimit, options.OutputLimit)
+	}
+	options.StopSignal = req.StopSignal
+	options.GracePeriod = time.Duration(req.GracePeriod) * time.Second
+	// 由节点控制执行超时, 结束进程后仍能返回执行结果
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout)*time.Second)
+		defer cancel()
+	}
+	var result utils.ExecResult
+	err := s.RunAs.Check(req.User, req.Group)
+	if err == nil && req.SaveOutput {
+		// 保存失败不影响任务执行
+		if file, createErr := s.Output.create(req.Id); createErr != nil {
+			log.Warnf("create output file failed: [id: %d err: %s]", req.Id, createErr)
+		} else {
+			defer file.Close()
+			options.Spill = file
+			resp.OutputFile = filepath.Base(file.Name())
+		}
+	}
+	switch {
+	case err != nil:
+		log.Warnf("execute rejected: [id: %d err: %s]", req.Id, err)
+	case req.Script != "":
+		log.Infof("execute script start: [id: %d interpreter: %s args: %s user: %s]", req.Id, req.Interpreter, req.Args, req.User)
+		result, err = utils.ExecScriptDetail(ctx, req.Interpreter, req.Script, req.Args, options)
+	default:
+		log.Infof("execute cmd start: [id: %d cmd: %s user: %s]", req.Id, req.Command, req.User)
+		result, err = utils.ExecShellDetail(ctx, req.Command, options)
+	}
+	resp.Output = result.Output
+	resp.Exited = result.Exited
+	resp.ExitCode = int32(result.ExitCode)
+	resp.Stdout = result.Stdout
+	resp.Stderr = result.Stderr
+	resp.WallTime = result.WallTime.Milliseconds()
+	resp.UserTime = result.UserTime.Milliseconds()
+	resp.SystemTime = result.SystemTime.Milliseconds()
+	resp.MaxRss = result.MaxRSS
+	resp.Truncated = result.Truncated
+	resp.Signal = result.Signal
+	resp.TimedOut = ctx.Err() == context.DeadlineExceeded
+	resp.Canceled = jobs.stopped(j)
 	if err != nil {
 		resp.Error = err.Error()
 	} else {
 		resp.Error = ""
 	}
-	log.Infof("execute cmd end: [id: %d cmd: %s err: %s]", req.Id, req.Command, resp.Error)
+	log.Infof("execute cmd end: [id: %d cmd: %s exit code: %d signal: %s err: %s]", req.Id, req.Command, resp.ExitCode, resp.Signal, resp.Error)
+
+	return resp
+}
+
+// 串行发送流消息, 标准输出和标准错误由不同协程写入
+type outputSender struct {
+	mu     sync.Mutex
+	stream pb.Task_RunStreamServer
+}
+
+func (s *outputSender) send(output *pb.TaskOutput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream.Send(output)
+}
+
+type outputWriter struct {
+	sender *outputSender
+	stderr bool
+}
+
+// 发送失败时忽略, 调用方断开后由ctx结束命令或继续分离执行, 避免中断完整输出的记录
+func (w outputWriter) Write(p []byte) (int, error) {
+	output := &pb.TaskOutput{Stdout: string(p)}
+	if w.stderr {
+		output = &pb.TaskOutput{Stderr: string(p)}
+	}
+	if err := w.sender.send(output); err != nil {
+		log.Debugf("send output failed: %s", err)
+	}
 
-	return resp, nil
+	return len(p), nil
 }
 
-func Start(addr string, enableTLS bool, certificate auth.Certificate) {
+func Start(addr string, enableTLS bool, certificate auth.Certificate, srv Server) {
 	l, err := net.Listen("tcp", addr)
 	if err != nil {
 		log.Fatal(err)
@@ -68,7 +215,8 @@ func Start(addr string, enableTLS bool, certificate auth.Certificate) {
 		opts = append(opts, opt)
 	}
 	server := grpc.NewServer(opts...)
-	pb.RegisterTaskServer(server, Server{})
+	pb.RegisterTaskServer(server, srv)
+	srv.Output.startPurge()
 	log.Infof("server listen on %s", addr)
 
 	go func() {
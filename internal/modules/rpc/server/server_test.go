@@ -0,0 +1,267 @@
+package server
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRunAsPolicyCheck(t *testing.T) {
+	policy := RunAsPolicy{Users: []string{"deploy"}, Groups: []string{"www"}}
+	if err := policy.Check("", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := policy.Check("deploy", "www"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := policy.Check("root", ""); err == nil {
+		t.Fatal("expected user not in allowlist to be rejected")
+	}
+	if err := policy.Check("deploy", "wheel"); err == nil {
+		t.Fatal("expected group not in allowlist to be rejected")
+	}
+	if err := (RunAsPolicy{}).Check("deploy", ""); err == nil {
+		t.Fatal("expected empty allowlist to reject run-as")
+	}
+}
+
+func TestRunRejectsUserNotAllowed(t *testing.T) {
+	resp, err := Server{}.Run(context.Background(), &pb.TaskRequest{Id: 1, Command: "echo executed", User: "root"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Output != "" || !strings.Contains(resp.Error, "not allowed") {
+		t.Fatalf("expected run-as rejection, got %+v", resp)
+	}
+}
+
+type fakeRunStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	outputs []*pb.TaskOutput
+}
+
+func (s *fakeRunStream) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
+func (s *fakeRunStream) Send(output *pb.TaskOutput) error {
+	s.outputs = append(s.outputs, output)
+	return nil
+}
+
+func TestRunStreamSendsOutputBeforeResult(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command requires bash")
+	}
+	stream := new(fakeRunStream)
+	err := Server{}.RunStream(&pb.TaskRequest{Id: 1, Command: "echo out; echo err 1>&2"}, stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stream.outputs) < 3 || !stream.outputs[0].Started {
+		t.Fatalf("expected started message, output chunks and result, got %+v", stream.outputs)
+	}
+	var stdout, stderr string
+	for _, output := range stream.outputs[1 : len(stream.outputs)-1] {
+		if output.Result != nil {
+			t.Fatalf("result must be the last message: %+v", stream.outputs)
+		}
+		stdout += output.Stdout
+		stderr += output.Stderr
+	}
+	if stdout != "out\n" || stderr != "err\n" {
+		t.Fatalf("unexpected chunks: stdout %q, stderr %q", stdout, stderr)
+	}
+	result := stream.outputs[len(stream.outputs)-1].Result
+	if result == nil || result.Error != "" || !strings.Contains(result.Output, "out") || !strings.Contains(result.Output, "err") {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestRunSavesFullOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command requires bash")
+	}
+	srv := Server{Output: OutputPolicy{Limit: 1024, Dir: t.TempDir()}}
+	resp, err := srv.Run(context.Background(), &pb.TaskRequest{
+		Id:          7,
+		Command:     "seq 1 2000",
+		OutputLimit: 100,
+		SaveOutput:  true,
+	})
+	if err != nil || resp.Error != "" {
+		t.Fatalf("unexpected error: %v, %+v", err, resp)
+	}
+	if !resp.Truncated || !strings.HasPrefix(resp.OutputFile, "7-") {
+		t.Fatalf("expected truncated output saved to file, got %+v", resp)
+	}
+
+	var full []byte
+	for {
+		chunk, err := srv.FetchOutput(context.Background(), &pb.OutputRequest{File: resp.OutputFile, Offset: int64(len(full)), Limit: 1000})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		full = append(full, chunk.Data...)
+		if int64(len(full)) >= chunk.Size {
+			break
+		}
+	}
+	if !strings.HasPrefix(string(full), "1\n2\n") || !strings.HasSuffix(string(full), "1999\n2000\n") {
+		t.Fatalf("unexpected full output: %d bytes", len(full))
+	}
+
+	for _, file := range []string{"../etc/passwd", "7-1.txt", ""} {
+		if _, err = srv.FetchOutput(context.Background(), &pb.OutputRequest{File: file}); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected invalid file %q to be rejected, got %v", file, err)
+		}
+	}
+}
+
+func TestOutputPolicyLimit(t *testing.T) {
+	policy := OutputPolicy{Limit: 1024}
+	if policy.limit(0) != 1024 || policy.limit(100) != 100 || policy.limit(4096) != 1024 {
+		t.Fatal("task output limit must not exceed node limit")
+	}
+	if (OutputPolicy{}).limit(4096) != 4096 {
+		t.Fatal("task output limit should be used when node has no limit")
+	}
+}
+
+func TestRunTimeoutReturnsSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command requires bash")
+	}
+	resp, err := Server{}.Run(context.Background(), &pb.TaskRequest{
+		Id:          8,
+		Command:     "trap 'echo stopping; exit 1' TERM; echo started; sleep 30 & wait",
+		Timeout:     1,
+		GracePeriod: 5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.TimedOut || resp.Signal != "SIGTERM" || resp.Output != "started\nstopping\n" || resp.Error == "" {
+		t.Fatalf("expected node to terminate timed out command with SIGTERM, got %+v", resp)
+	}
+}
+
+func TestStopRunningJob(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command requires bash")
+	}
+	done := make(chan *pb.TaskResponse, 1)
+	go func() {
+		resp, _ := Server{}.Run(context.Background(), &pb.TaskRequest{
+			Id:      9,
+			Command: "trap 'echo interrupted; exit 2' INT; echo started; sleep 30",
+		})
+		done <- resp
+	}()
+	var list *pb.JobList
+	for i := 0; i < 100; i++ {
+		list, _ = Server{}.ListRunning(context.Background(), &pb.ListRunningRequest{})
+		if len(list.Jobs) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(list.Jobs) != 1 || list.Jobs[0].Id != 9 || !list.Jobs[0].Running {
+		t.Fatalf("expected running job, got %+v", list.Jobs)
+	}
+	// 等待trap生效
+	time.Sleep(200 * time.Millisecond)
+	if _, err := (Server{}).Stop(context.Background(), &pb.StopRequest{Id: 9, Signal: "SIGINT"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp := <-done
+	if !resp.Canceled || resp.Signal != "SIGINT" || !strings.Contains(resp.Output, "interrupted") {
+		t.Fatalf("expected job to be stopped with SIGINT, got %+v", resp)
+	}
+
+	jobStatus, err := Server{}.GetStatus(context.Background(), &pb.JobRequest{Id: 9})
+	if err != nil || jobStatus.Running || jobStatus.Result.GetSignal() != "SIGINT" {
+		t.Fatalf("expected finished job with result, got %+v, %v", jobStatus, err)
+	}
+	if _, err = (Server{}).Stop(context.Background(), &pb.StopRequest{Id: 9}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected finished job not to be stopped, got %v", err)
+	}
+	if _, err = (Server{}).GetStatus(context.Background(), &pb.JobRequest{Id: 404}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected unknown job not found, got %v", err)
+	}
+}
+
+func TestDetachedJobSurvivesDisconnect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command requires bash")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	// 调用方在任务执行过程中断开
+	time.AfterFunc(100*time.Millisecond, cancel)
+	stream := &fakeRunStream{ctx: ctx}
+	err := Server{}.RunStream(&pb.TaskRequest{Id: 10, Command: "sleep 0.5; echo finished", Detached: true}, stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jobStatus, err := Server{}.GetStatus(context.Background(), &pb.JobRequest{Id: 10})
+	if err != nil || jobStatus.Running || jobStatus.Result.GetOutput() != "finished\n" || jobStatus.Result.GetCanceled() {
+		t.Fatalf("expected detached job to finish after disconnect, got %+v, %v", jobStatus, err)
+	}
+}
+
+func TestJobRegistryKeepsRecentFinishedJobs(t *testing.T) {
+	registry := newJobRegistry()
+	for i := int64(1); i <= maxFinishedJobs+2; i++ {
+		_, j := registry.start(context.Background(), &pb.TaskRequest{Id: i})
+		registry.finish(j, &pb.TaskResponse{Output: "done"})
+	}
+	if _, ok := registry.get(1); ok {
+		t.Fatal("oldest finished job should be removed")
+	}
+	if jobStatus, ok := registry.get(maxFinishedJobs + 2); !ok || jobStatus.Result.Output != "done" {
+		t.Fatalf("expected latest finished job, got %+v", jobStatus)
+	}
+	// 同一ID再次执行时以最后一次为准
+	_, j := registry.start(context.Background(), &pb.TaskRequest{Id: 3})
+	registry.finish(j, &pb.TaskResponse{Output: "again"})
+	if jobStatus, ok := registry.get(3); !ok || jobStatus.Result.Output != "again" || len(registry.order) != maxFinishedJobs {
+		t.Fatalf("unexpected registry state: %+v, %d", jobStatus, len(registry.order))
+	}
+	// 分离执行的任务结果不受保留数量限制
+	_, j = registry.start(context.Background(), &pb.TaskRequest{Id: 1000, Detached: true})
+	registry.finish(j, &pb.TaskResponse{Output: "detached"})
+	for i := int64(2000); i < 2000+maxFinishedJobs; i++ {
+		_, j = registry.start(context.Background(), &pb.TaskRequest{Id: i})
+		registry.finish(j, &pb.TaskResponse{Output: "done"})
+	}
+	if jobStatus, ok := registry.get(1000); !ok || jobStatus.Result.Output != "detached" {
+		t.Fatalf("expected detached job to be kept, got %+v", jobStatus)
+	}
+}
+
+func TestInventory(t *testing.T) {
+	info, err := Server{Version: "v1.0.0"}.Inventory(context.Background(), &pb.InventoryRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Version != "v1.0.0" || info.Os != runtime.GOOS || info.Arch != runtime.GOARCH || info.CpuCount <= 0 {
+		t.Fatalf("unexpected node info: %+v", info)
+	}
+	if info.DiskTotal <= 0 || info.DiskFree > info.DiskTotal {
+		t.Fatalf("unexpected disk usage: %+v", info)
+	}
+	if runtime.GOOS == "linux" && (info.MemTotal <= 0 || info.MemAvailable > info.MemTotal) {
+		t.Fatalf("unexpected memory usage: %+v", info)
+	}
+}
@@ -3,6 +3,7 @@ package setting
 import (
 	"errors"
 	"os"
+	"strings"
 
 	"github.com/gocronx-team/gocron/internal/modules/logger"
 	"github.com/gocronx-team/gocron/internal/modules/utils"
@@ -37,6 +38,18 @@ type Setting struct {
 
 	ConcurrencyQueue int
 	AuthSecret       string
+
+	// 异步HTTP任务回调gocron使用的地址, 如 http://gocron.example.com:5920
+	CallbackUrl string
+
+	// 是否允许在gocron服务端本机执行命令
+	EnableLocalShell bool
+
+	// SSH任务主机未配置公钥时使用的known_hosts文件
+	SSHKnownHosts string
+
+	// 扩展执行器目录, 目录中的可执行文件注册为任务执行器
+	ExecutorDir string
 }
 
 // 读取配置
@@ -71,6 +84,11 @@ func Read(filename string) (*Setting, error) {
 		s.AuthSecret = utils.RandAuthToken()
 	}
 
+	s.CallbackUrl = strings.TrimRight(section.Key("callback_url").MustString(""), "/")
+	s.EnableLocalShell = section.Key("local_shell.enable").MustBool(false)
+	s.SSHKnownHosts = section.Key("ssh.known_hosts").MustString("")
+	s.ExecutorDir = section.Key("executor.dir").MustString("")
+
 	s.EnableTLS = section.Key("enable_tls").MustBool(false)
 	s.CAFile = section.Key("ca_file").MustString("")
 	s.CertFile = section.Key("cert_file").MustString("")
@@ -30,6 +30,9 @@ func TestReadReturnsConfiguredValues(t *testing.T) {
 		concurrency.queue=200
 		auth_secret=existing-secret
 		enable_tls=false
+		callback_url=http://gocron.example.com:5920/
+		local_shell.enable=true
+		executor.dir=/opt/gocron/executors
     `
 	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
 		t.Fatalf("write config failed: %v", err)
@@ -49,6 +52,15 @@ func TestReadReturnsConfiguredValues(t *testing.T) {
 	if s.ConcurrencyQueue != 200 || s.AuthSecret != "existing-secret" {
 		t.Fatalf("unexpected concurrency/auth config: %+v", s)
 	}
+	if s.CallbackUrl != "http://gocron.example.com:5920" {
+		t.Fatalf("unexpected callback url: %s", s.CallbackUrl)
+	}
+	if !s.EnableLocalShell {
+		t.Fatalf("expected local shell to be enabled")
+	}
+	if s.ExecutorDir != "/opt/gocron/executors" {
+		t.Fatalf("unexpected executor dir: %s", s.ExecutorDir)
+	}
 }
 
 func TestReadGeneratesAuthSecretWhenMissing(t *testing.T) {
@@ -68,6 +80,9 @@ db.engine=mysql
 	if s.AuthSecret == "" {
 		t.Fatal("expected generated auth secret when config missing")
 	}
+	if s.EnableLocalShell {
+		t.Fatal("expected local shell to be disabled by default")
+	}
 }
 
 func TestReadEnableTLSSucceedsWhenFilesExist(t *testing.T) {
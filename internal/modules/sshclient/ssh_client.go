@@ -0,0 +1,310 @@
+package sshclient
+
+// ssh-client, 通过SSH在未部署gocron-node的主机上执行命令
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	DefaultPort = 22
+	// 建立连接及握手超时时间
+	dialTimeout = 10 * time.Second
+	// 停止任务时结束远程进程组的超时时间
+	killTimeout = 5 * time.Second
+	// 命令输出的第一行为进程组ID, 用于停止任务时结束整个进程组
+	pgidMarker = "__GOCRON_PGID__="
+)
+
+var ErrHostKeyRequired = errors.New("未配置主机公钥或known_hosts文件, 拒绝连接")
+
+type Config struct {
+	Host           string
+	Port           int
+	User           string
+	Password       string
+	PrivateKey     string // PEM格式私钥
+	HostKey        string // 主机公钥, authorized_keys或known_hosts格式
+	KnownHostsFile string // 未配置主机公钥时使用的known_hosts文件
+}
+
+// ParseHostKey 解析authorized_keys或known_hosts格式的主机公钥
+func ParseHostKey(hostKey string) (ssh.PublicKey, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err == nil {
+		return key, nil
+	}
+	_, _, key, _, _, err = ssh.ParseKnownHosts([]byte(hostKey))
+	if err != nil {
+		return nil, fmt.Errorf("主机公钥格式错误-%s", err)
+	}
+
+	return key, nil
+}
+
+// ParsePrivateKey 校验私钥, 暂不支持带密码的私钥
+func ParsePrivateKey(privateKey string) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("私钥格式错误-%s", err)
+	}
+
+	return signer, nil
+}
+
+func (c Config) addr() string {
+	port := c.Port
+	if port <= 0 {
+		port = DefaultPort
+	}
+
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+func (c Config) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if c.HostKey != "" {
+		key, err := ParseHostKey(c.HostKey)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.FixedHostKey(key), nil
+	}
+	if c.KnownHostsFile != "" {
+		callback, err := knownhosts.New(c.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取known_hosts文件失败-%s", err)
+		}
+		return callback, nil
+	}
+
+	return nil, ErrHostKeyRequired
+}
+
+func (c Config) clientConfig() (*ssh.ClientConfig, error) {
+	hostKeyCallback, err := c.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	auth := make([]ssh.AuthMethod, 0, 2)
+	if c.PrivateKey != "" {
+		signer, err := ParsePrivateKey(c.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if c.Password != "" {
+		auth = append(auth, ssh.Password(c.Password))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("未配置SSH密码或私钥")
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         dialTimeout,
+	}, nil
+}
+
+func dial(ctx context.Context, addr string, clientConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// Exec 执行命令并返回标准输出和标准错误, ctx取消或超时时结束远程进程组
+func Exec(ctx context.Context, config Config, command string) (string, error) {
+	clientConfig, err := config.clientConfig()
+	if err != nil {
+		return "", err
+	}
+	client, err := dial(ctx, config.addr(), clientConfig)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	output := new(outputBuffer)
+	session.Stdout = stdoutWriter{output}
+	session.Stderr = output
+	if err = session.Start(wrapCommand(command)); err != nil {
+		return "", err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	select {
+	case err = <-done:
+		return output.String(), exitError(err)
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGKILL)
+		if pgid := output.processGroupId(); pgid > 0 {
+			killProcessGroup(client, pgid)
+		}
+		_ = session.Close()
+		return output.String(), ctx.Err()
+	}
+}
+
+// ScanHostKey 获取主机公钥, 供管理员核对指纹后保存
+func ScanHostKey(ctx context.Context, host string, port int) (ssh.PublicKey, error) {
+	var hostKey ssh.PublicKey
+	errScanned := errors.New("host key scanned")
+	clientConfig := &ssh.ClientConfig{
+		User: "gocron",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errScanned
+		},
+		Timeout: dialTimeout,
+	}
+	client, err := dial(ctx, Config{Host: host, Port: port}.addr(), clientConfig)
+	if client != nil {
+		_ = client.Close()
+	}
+	if hostKey != nil {
+		return hostKey, nil
+	}
+	if err == nil {
+		err = errors.New("未获取到主机公钥")
+	}
+
+	return nil, err
+}
+
+// 先输出当前shell的进程ID, 远程sshd为每个会话创建新的进程组, 该ID即进程组ID
+func wrapCommand(command string) string {
+	return fmt.Sprintf("echo %s$$; exec /bin/sh -c %s", pgidMarker, shellQuote(command))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// 新建会话结束远程进程组
+func killProcessGroup(client *ssh.Client, pgid int) {
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+	done := make(chan struct{})
+	go func() {
+		_ = session.Run(fmt.Sprintf("kill -9 -%d", pgid))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(killTimeout):
+	}
+}
+
+// 转换为与gocron-node一致的错误信息, 如 exit status 2
+func exitError(err error) error {
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.Signal() != "" {
+			return fmt.Errorf("signal: %s", exitErr.Signal())
+		}
+		return fmt.Errorf("exit status %d", exitErr.ExitStatus())
+	}
+
+	return err
+}
+
+// 合并标准输出和标准错误, 并从标准输出第一行解析进程组ID
+type outputBuffer struct {
+	mu         sync.Mutex
+	buf        bytes.Buffer
+	header     []byte
+	headerDone bool
+	pgid       int
+}
+
+func (o *outputBuffer) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.buf.Write(p)
+}
+
+func (o *outputBuffer) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.headerDone {
+		return o.buf.String() + string(o.header)
+	}
+
+	return o.buf.String()
+}
+
+func (o *outputBuffer) processGroupId() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.pgid
+}
+
+type stdoutWriter struct {
+	*outputBuffer
+}
+
+func (w stdoutWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.headerDone {
+		return w.buf.Write(p)
+	}
+	w.header = append(w.header, p...)
+	index := bytes.IndexByte(w.header, '\n')
+	if index < 0 {
+		if len(w.header) > len(pgidMarker)+32 {
+			w.headerDone = true
+			w.buf.Write(w.header)
+			w.header = nil
+		}
+		return len(p), nil
+	}
+	w.headerDone = true
+	line := string(w.header[:index])
+	if strings.HasPrefix(line, pgidMarker) {
+		w.pgid, _ = strconv.Atoi(strings.TrimPrefix(line, pgidMarker))
+	} else {
+		w.buf.Write(w.header[:index+1])
+	}
+	w.buf.Write(w.header[index+1:])
+	w.header = nil
+
+	return len(p), nil
+}
@@ -0,0 +1,300 @@
+//go:build !windows
+
+package sshclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	testUser     = "gocron"
+	testPassword = "secret"
+)
+
+type testServer struct {
+	addr      string
+	host      string
+	port      int
+	hostKey   ssh.PublicKey
+	clientKey string
+}
+
+func newSigner(t *testing.T) (ssh.Signer, string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("create signer: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	return signer, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// 进程内SSH服务, 与sshd一样为每个exec请求创建新的会话(进程组)
+func startTestServer(t *testing.T) testServer {
+	t.Helper()
+	hostSigner, _ := newSigner(t)
+	clientSigner, clientKey := newSigner(t)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == testUser && string(password) == testPassword {
+				return nil, nil
+			}
+			return nil, errors.New("invalid password")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientSigner.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("invalid key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	return testServer{
+		addr:      addr.String(),
+		host:      addr.IP.String(),
+		port:      addr.Port,
+		hostKey:   hostSigner.PublicKey(),
+		clientKey: clientKey,
+	}
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSession(channel, requests)
+	}
+}
+
+func serveSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	for req := range requests {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		cmd := exec.Command("/bin/sh", "-c", payload.Command)
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+		cmd.Stdout = channel
+		cmd.Stderr = channel.Stderr()
+		if err := cmd.Start(); err != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+		go func() {
+			status := make([]byte, 4)
+			if err := cmd.Wait(); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					binary.BigEndian.PutUint32(status, uint32(exitErr.ExitCode()))
+				}
+			}
+			_, _ = channel.SendRequest("exit-status", false, status)
+			_ = channel.Close()
+		}()
+	}
+}
+
+func (s testServer) config() Config {
+	return Config{
+		Host:     s.host,
+		Port:     s.port,
+		User:     testUser,
+		Password: testPassword,
+		HostKey:  string(ssh.MarshalAuthorizedKey(s.hostKey)),
+	}
+}
+
+func TestExecCapturesOutput(t *testing.T) {
+	server := startTestServer(t)
+
+	output, err := Exec(context.Background(), server.config(), "echo out; echo err >&2; echo 'quoted'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(output, pgidMarker) {
+		t.Fatalf("expected process group marker to be stripped, got %q", output)
+	}
+	for _, expected := range []string{"out", "err", "quoted"} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("expected output to contain %q, got %q", expected, output)
+		}
+	}
+
+	output, err = Exec(context.Background(), server.config(), "echo failed; exit 3")
+	if err == nil || err.Error() != "exit status 3" || !strings.Contains(output, "failed") {
+		t.Fatalf("expected exit status 3, got %q, %v", output, err)
+	}
+}
+
+func TestExecWithPrivateKey(t *testing.T) {
+	server := startTestServer(t)
+	config := server.config()
+	config.Password = ""
+	config.PrivateKey = server.clientKey
+
+	output, err := Exec(context.Background(), config, "echo key")
+	if err != nil || strings.TrimSpace(output) != "key" {
+		t.Fatalf("unexpected result %q, %v", output, err)
+	}
+
+	config.PrivateKey = ""
+	if _, err = Exec(context.Background(), config, "echo key"); err == nil {
+		t.Fatal("expected error without credentials")
+	}
+}
+
+func TestExecVerifiesHostKey(t *testing.T) {
+	server := startTestServer(t)
+	otherSigner, _ := newSigner(t)
+
+	config := server.config()
+	config.HostKey = string(ssh.MarshalAuthorizedKey(otherSigner.PublicKey()))
+	if _, err := Exec(context.Background(), config, "echo hi"); err == nil || !strings.Contains(err.Error(), "host key mismatch") {
+		t.Fatalf("expected host key mismatch, got %v", err)
+	}
+
+	config.HostKey = ""
+	if _, err := Exec(context.Background(), config, "echo hi"); !errors.Is(err, ErrHostKeyRequired) {
+		t.Fatalf("expected host key required error, got %v", err)
+	}
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, server.hostKey)
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+	config.KnownHostsFile = knownHostsFile
+	if output, err := Exec(context.Background(), config, "echo known"); err != nil || strings.TrimSpace(output) != "known" {
+		t.Fatalf("unexpected result with known_hosts %q, %v", output, err)
+	}
+}
+
+func TestExecCancelKillsProcessGroup(t *testing.T) {
+	server := startTestServer(t)
+	pidFile := filepath.Join(t.TempDir(), "pid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Exec(ctx, server.config(), "sleep 30 & echo $! > "+pidFile+"; wait")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("expected command to be stopped")
+	}
+
+	content, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("read pid file: %v", err)
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(content)))
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected background process %d to be killed", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// 进程已退出或成为僵尸进程(容器中可能无init进程回收)
+func processAlive(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+
+	return len(fields) == 0 || fields[0] != "Z"
+}
+
+func TestScanHostKey(t *testing.T) {
+	server := startTestServer(t)
+
+	key, err := ScanHostKey(context.Background(), server.host, server.port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ssh.FingerprintSHA256(key) != ssh.FingerprintSHA256(server.hostKey) {
+		t.Fatalf("unexpected host key %s", ssh.FingerprintSHA256(key))
+	}
+}
+
+func TestParseHostKey(t *testing.T) {
+	signer, _ := newSigner(t)
+	authorized := string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	knownHostsLine := knownhosts.Line([]string{"example.com"}, signer.PublicKey())
+	for _, hostKey := range []string{authorized, knownHostsLine} {
+		key, err := ParseHostKey(hostKey)
+		if err != nil || ssh.FingerprintSHA256(key) != ssh.FingerprintSHA256(signer.PublicKey()) {
+			t.Fatalf("unexpected result for %q: %v", hostKey, err)
+		}
+	}
+	if _, err := ParseHostKey("invalid"); err == nil {
+		t.Fatal("expected error for invalid host key")
+	}
+}
@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestAesEncryptDecrypt(t *testing.T) {
+	ciphertext, err := AesEncrypt("secret-key", "p@ssw0rd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ciphertext == "p@ssw0rd" {
+		t.Fatal("ciphertext should not equal plaintext")
+	}
+	another, _ := AesEncrypt("secret-key", "p@ssw0rd")
+	if another == ciphertext {
+		t.Fatal("expected random nonce")
+	}
+	plaintext, err := AesDecrypt("secret-key", ciphertext)
+	if err != nil || plaintext != "p@ssw0rd" {
+		t.Fatalf("unexpected decrypt result %q, %v", plaintext, err)
+	}
+	if _, err = AesDecrypt("other-key", ciphertext); err == nil {
+		t.Fatal("expected error with wrong key")
+	}
+	if _, err = AesEncrypt("", "x"); err == nil {
+		t.Fatal("expected error with empty key")
+	}
+}
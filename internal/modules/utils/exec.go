@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// 未指定时发送停止信号后等待进程退出的时间
+	defaultGracePeriod = 10 * time.Second
+	// 发送SIGKILL后等待进程退出的时间, 子进程脱离进程组并占用输出时不再等待
+	killWaitTimeout = 5 * time.Second
+)
+
+// ExecOptions 命令执行选项
+type ExecOptions struct {
+	Env    []string  // 追加的环境变量, 格式为KEY=VALUE, 覆盖同名变量
+	Dir    string    // 工作目录, 为空时使用执行用户的家目录
+	User   string    // 执行用户, 为空时使用gocron-node的运行用户
+	Group  string    // 执行用户组, 为空时使用执行用户的主组
+	Stdout io.Writer // 执行过程中实时写入标准输出, 可为空
+	Stderr io.Writer // 执行过程中实时写入标准错误, 可为空
+	// 合并输出、标准输出及标准错误各自保留的最大字节数, 超过时保留开头和结尾, 0表示不限制
+	OutputLimit int
+	Spill       io.Writer // 写入完整的合并输出, 可为空, 写入失败不影响命令执行
+	// 超时或停止时先发送的信号, 为空时为SIGTERM, Windows直接强制结束
+	StopSignal  string
+	GracePeriod time.Duration // 发送停止信号后等待进程退出的时间, 超过后发送SIGKILL, 0使用默认值
+}
+
+// RunAs 是否需要切换执行用户或用户组
+func (options ExecOptions) RunAs() bool {
+	return options.User != "" || options.Group != ""
+}
+
+// 支持的停止信号
+var stopSignalNames = []string{"SIGTERM", "SIGINT", "SIGHUP", "SIGQUIT", "SIGUSR1", "SIGUSR2", "SIGKILL"}
+
+// IsStopSignal 是否为支持的停止信号
+func IsStopSignal(name string) bool {
+	for _, item := range stopSignalNames {
+		if item == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StopCause 作为ctx的取消原因时指定停止命令先发送的信号, 为空时使用选项中的信号
+type StopCause struct {
+	Signal string
+}
+
+func (c StopCause) Error() string {
+	return "stopped"
+}
+
+// ctx取消原因中的信号优先于选项中的信号
+func (options ExecOptions) stopSignal(ctx context.Context) string {
+	var cause StopCause
+	if errors.As(context.Cause(ctx), &cause) && cause.Signal != "" {
+		return cause.Signal
+	}
+	if options.StopSignal == "" {
+		return "SIGTERM"
+	}
+
+	return options.StopSignal
+}
+
+func (options ExecOptions) gracePeriod() time.Duration {
+	if options.GracePeriod <= 0 {
+		return defaultGracePeriod
+	}
+
+	return options.GracePeriod
+}
+
+// ExecResult 命令执行结果
+type ExecResult struct {
+	Output     string        // 合并的标准输出及标准错误
+	Stdout     string        // 标准输出
+	Stderr     string        // 标准错误
+	Exited     bool          // 进程已结束, 退出码及资源占用有效, 强制结束后仍未退出时为false
+	ExitCode   int           // 退出码, 被信号结束时为-1
+	Signal     string        // 超时或停止时结束进程的信号, 进程自行结束时为空
+	WallTime   time.Duration // 执行耗时
+	UserTime   time.Duration // 用户态CPU时间
+	SystemTime time.Duration // 内核态CPU时间
+	MaxRSS     int64         // 峰值内存(KB)
+	Truncated  bool          // 输出超过限制, 已省略中间部分
+}
+
+// 分别记录合并输出、标准输出及标准错误, 同时写入选项中的实时输出
+type outputCapture struct {
+	combined outputBuffer
+	stdout   outputBuffer
+	stderr   outputBuffer
+	convert  func(string) string // 输出的编码转换, 可为空
+}
+
+func newOutputCapture(options ExecOptions) *outputCapture {
+	c := new(outputCapture)
+	c.combined.limit = options.OutputLimit
+	c.stdout.limit = options.OutputLimit
+	c.stderr.limit = options.OutputLimit
+
+	return c
+}
+
+func (c *outputCapture) writers(options ExecOptions) (stdout, stderr io.Writer) {
+	stdout = io.MultiWriter(&c.combined, &c.stdout)
+	stderr = io.MultiWriter(&c.combined, &c.stderr)
+	if options.Spill != nil {
+		spill := &spillWriter{w: options.Spill}
+		stdout = io.MultiWriter(stdout, spill)
+		stderr = io.MultiWriter(stderr, spill)
+	}
+	if options.Stdout != nil {
+		stdout = io.MultiWriter(stdout, options.Stdout)
+	}
+	if options.Stderr != nil {
+		stderr = io.MultiWriter(stderr, options.Stderr)
+	}
+
+	return stdout, stderr
+}
+
+func (c *outputCapture) result(state *os.ProcessState, wallTime time.Duration) ExecResult {
+	result := ExecResult{
+		Output:    c.combined.text(c.convert),
+		Stdout:    c.stdout.text(c.convert),
+		Stderr:    c.stderr.text(c.convert),
+		WallTime:  wallTime,
+		Truncated: c.combined.truncated() || c.stdout.truncated() || c.stderr.truncated(),
+	}
+	if state != nil {
+		result.Exited = true
+		result.ExitCode = state.ExitCode()
+		result.UserTime = state.UserTime()
+		result.SystemTime = state.SystemTime()
+		result.MaxRSS = maxRSS(state)
+	}
+
+	return result
+}
+
+// 并发安全的输出缓冲, 设置上限时保留开头和结尾各一半, 省略中间部分
+type outputBuffer struct {
+	mu    sync.Mutex
+	limit int
+	head  []byte
+	tail  []byte
+	total int64
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(p)
+	b.total += int64(n)
+	if b.limit <= 0 {
+		b.head = append(b.head, p...)
+		return n, nil
+	}
+	headLimit := b.limit / 2
+	tailLimit := b.limit - headLimit
+	if size := min(headLimit-len(b.head), len(p)); size > 0 {
+		b.head = append(b.head, p[:size]...)
+		p = p[size:]
+	}
+	b.tail = append(b.tail, p...)
+	// 超过两倍时再丢弃, 避免每次写入都移动数据
+	if len(b.tail) > 2*tailLimit {
+		b.tail = append(b.tail[:0], b.tail[len(b.tail)-tailLimit:]...)
+	}
+
+	return n, nil
+}
+
+func (b *outputBuffer) truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limit > 0 && b.total > int64(b.limit)
+}
+
+func (b *outputBuffer) text(convert func(string) string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if convert == nil {
+		convert = func(s string) string { return s }
+	}
+	if b.limit <= 0 || b.total <= int64(b.limit) {
+		return convert(string(b.head) + string(b.tail))
+	}
+	// 截断位置可能在多字节字符中间, 丢弃不完整的字符
+	head := b.head
+	for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(head[i]) {
+			if !utf8.FullRune(head[i:]) {
+				head = head[:i]
+			}
+			break
+		}
+	}
+	tail := b.tail[len(b.tail)-(b.limit-len(b.head)):]
+	for i := 0; i < len(tail) && i < utf8.UTFMax; i++ {
+		if utf8.RuneStart(tail[i]) {
+			tail = tail[i:]
+			break
+		}
+	}
+	omitted := b.total - int64(len(head)) - int64(len(tail))
+
+	// 标明实际生效的上限, 任务指定的上限可能被节点的上限覆盖
+	return convert(string(head)) + fmt.Sprintf("\n...... 输出超过%d字节的限制, 已省略%d字节 ......\n", b.limit, omitted) + convert(string(tail))
+}
+
+// 写入失败后不再写入, 避免中断命令输出的读取
+type spillWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+func (s *spillWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		_, s.err = s.w.Write(p)
+	}
+
+	return len(p), nil
+}
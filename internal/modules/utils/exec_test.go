@@ -0,0 +1,140 @@
+//go:build !windows
+
+package utils
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestExecShellWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	options := ExecOptions{Env: []string{"GOCRON_TEST=hello", "PATH=" + os.Getenv("PATH")}, Dir: dir}
+	output, err := ExecShellWithOptions(context.Background(), `echo "$GOCRON_TEST $(pwd)"`, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, output: %s", err, output)
+	}
+	if strings.TrimSpace(output) != "hello "+dir {
+		t.Fatalf("unexpected output: %q", output)
+	}
+
+	output, err = ExecScript(context.Background(), "sh", `echo "$GOCRON_TEST"`, "", options)
+	if err != nil || strings.TrimSpace(output) != "hello" {
+		t.Fatalf("unexpected script result: %q, %v", output, err)
+	}
+}
+
+func TestExecShellRunAs(t *testing.T) {
+	options := ExecOptions{User: "nobody"}
+	if os.Geteuid() != 0 {
+		if _, err := ExecShellWithOptions(context.Background(), "id -un", options); err == nil {
+			t.Fatal("expected error when switching user without root")
+		}
+		return
+	}
+	output, err := ExecShellWithOptions(context.Background(), "id -un; echo $HOME", options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, output: %s", err, output)
+	}
+	if !strings.HasPrefix(output, "nobody\n") {
+		t.Fatalf("expected command to run as nobody, got %q", output)
+	}
+	// 脚本文件属主切换为执行用户, 否则无法读取
+	output, err = ExecScript(context.Background(), "sh", "id -un", "", options)
+	if err != nil || strings.TrimSpace(output) != "nobody" {
+		t.Fatalf("unexpected script result: %q, %v", output, err)
+	}
+
+	if _, err = ExecShellWithOptions(context.Background(), "id", ExecOptions{User: "gocron-missing-user"}); err == nil {
+		t.Fatal("expected error for missing user")
+	}
+}
+
+func TestExecShellDetail(t *testing.T) {
+	result, err := ExecShellDetail(context.Background(), "echo out; echo err 1>&2; exit 3", ExecOptions{})
+	if err == nil || err.Error() != "exit status 3" {
+		t.Fatalf("expected exit status 3, got %v", err)
+	}
+	if !result.Exited || result.ExitCode != 3 {
+		t.Fatalf("unexpected exit code: %+v", result)
+	}
+	if result.Stdout != "out\n" || result.Stderr != "err\n" {
+		t.Fatalf("unexpected stdout %q, stderr %q", result.Stdout, result.Stderr)
+	}
+	if !strings.Contains(result.Output, "out") || !strings.Contains(result.Output, "err") {
+		t.Fatalf("unexpected combined output: %q", result.Output)
+	}
+	if result.WallTime <= 0 || result.MaxRSS <= 0 {
+		t.Fatalf("expected resource usage to be recorded: %+v", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = ExecShellDetail(ctx, "sleep 5", ExecOptions{})
+	if err == nil || result.Signal != "SIGTERM" || result.ExitCode != -1 {
+		t.Fatalf("expected command to be terminated by SIGTERM, got %+v, %v", result, err)
+	}
+}
+
+func TestExecShellGracefulTermination(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	command := `trap 'echo cleanup; exit 0' TERM; echo started; sleep 30 & wait`
+	result, err := ExecShellDetail(ctx, command, ExecOptions{GracePeriod: 5 * time.Second})
+	if err == nil || result.Signal != "SIGTERM" || result.Output != "started\ncleanup\n" {
+		t.Fatalf("expected command to clean up after SIGTERM, got %+v, %v", result, err)
+	}
+
+	// 忽略停止信号的命令在宽限期后被SIGKILL结束
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err = ExecShellDetail(ctx, `trap '' INT; sleep 30`, ExecOptions{StopSignal: "SIGINT", GracePeriod: 200 * time.Millisecond})
+	if err == nil || result.Signal != "SIGKILL" || !result.Exited {
+		t.Fatalf("expected command to be killed after grace period, got %+v, %v", result, err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond || elapsed > 5*time.Second {
+		t.Fatalf("unexpected termination time: %s", elapsed)
+	}
+}
+
+func TestExecShellOutputLimit(t *testing.T) {
+	var spill strings.Builder
+	options := ExecOptions{OutputLimit: 100, Spill: &spill}
+	result, err := ExecShellDetail(context.Background(), "echo BEGIN; head -c 10000 /dev/zero | tr '\\0' a; echo; echo END", options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Truncated || !strings.HasPrefix(result.Output, "BEGIN\n") || !strings.HasSuffix(result.Output, "a\nEND\n") {
+		t.Fatalf("expected head and tail to be kept, got %q", result.Output)
+	}
+	if !strings.Contains(result.Output, "输出超过100字节的限制, 已省略9911字节") || len(result.Stdout) != len(result.Output) {
+		t.Fatalf("unexpected truncation marker: %q", result.Output)
+	}
+	if spill.Len() != 10011 {
+		t.Fatalf("expected full output to be spilled, got %d bytes", spill.Len())
+	}
+}
+
+func TestOutputBufferKeepsValidUTF8(t *testing.T) {
+	buffer := outputBuffer{limit: 8}
+	_, _ = buffer.Write([]byte("你好世界你好世界"))
+	if !buffer.truncated() {
+		t.Fatal("expected output to be truncated")
+	}
+	text := buffer.text(nil)
+	if !strings.HasPrefix(text, "你\n") || !strings.HasSuffix(text, "\n界") || !utf8.ValidString(text) {
+		t.Fatalf("unexpected text: %q", text)
+	}
+
+	buffer = outputBuffer{limit: 8}
+	_, _ = buffer.Write([]byte("1234"))
+	_, _ = buffer.Write([]byte("5678"))
+	if buffer.truncated() || buffer.text(nil) != "12345678" {
+		t.Fatalf("output within limit should be kept: %q", buffer.text(nil))
+	}
+}
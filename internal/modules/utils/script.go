@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// 支持的脚本解释器, shebang表示按脚本首行指定的解释器直接执行
+var scriptInterpreters = map[string]struct {
+	command string
+	ext     string
+}{
+	"bash":    {"bash", ".sh"},
+	"sh":      {"sh", ".sh"},
+	"python3": {"python3", ".py"},
+	"perl":    {"perl", ".pl"},
+	"shebang": {"", ""},
+}
+
+// IsScriptInterpreter 是否为支持的脚本解释器
+func IsScriptInterpreter(interpreter string) bool {
+	_, ok := scriptInterpreters[interpreter]
+	return ok
+}
+
+// ExecScript 将脚本写入临时文件后使用解释器执行, 执行结束后删除临时文件
+func ExecScript(ctx context.Context, interpreter, script, args string, options ExecOptions) (string, error) {
+	result, err := ExecScriptDetail(ctx, interpreter, script, args, options)
+	return result.Output, err
+}
+
+// ExecScriptDetail 执行脚本, 返回分离的标准输出、标准错误、退出码及资源占用
+func ExecScriptDetail(ctx context.Context, interpreter, script, args string, options ExecOptions) (ExecResult, error) {
+	item, ok := scriptInterpreters[interpreter]
+	if !ok {
+		return ExecResult{}, fmt.Errorf("不支持的脚本解释器: %s", interpreter)
+	}
+	file, err := os.CreateTemp("", "gocron-script-*"+item.ext)
+	if err != nil {
+		return ExecResult{}, fmt.Errorf("创建脚本文件失败: %s", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(script)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ExecResult{}, fmt.Errorf("写入脚本文件失败: %s", err)
+	}
+	// 仅允许执行用户读取执行
+	if err = os.Chmod(file.Name(), 0700); err != nil {
+		return ExecResult{}, fmt.Errorf("设置脚本文件权限失败: %s", err)
+	}
+	if err = chownScript(file.Name(), options); err != nil {
+		return ExecResult{}, err
+	}
+	command, err := scriptCommand(item.command, file.Name())
+	if err != nil {
+		return ExecResult{}, err
+	}
+	if args != "" {
+		command += " " + args
+	}
+
+	return ExecShellDetail(ctx, command, options)
+}
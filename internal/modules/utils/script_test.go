@@ -0,0 +1,59 @@
+//go:build !windows
+
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecScript(t *testing.T) {
+	tests := []struct {
+		name        string
+		interpreter string
+		script      string
+		args        string
+		expected    string
+	}{
+		{"sh", "sh", "echo \"hello $1 $2\"\n", "a 'b c'", "hello a b c"},
+		{"bash", "bash", "arr=(x y)\necho ${#arr[@]}\n", "", "2"},
+		{"shebang", "shebang", "#!/bin/sh\necho shebang\n", "", "shebang"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := ExecScript(context.Background(), tt.interpreter, tt.script, tt.args, ExecOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v, output: %s", err, output)
+			}
+			if strings.TrimSpace(output) != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestExecScriptRemovesTempFile(t *testing.T) {
+	output, err := ExecScript(context.Background(), "sh", "echo \"$0\"; exit 3", "", ExecOptions{})
+	if err == nil || err.Error() != "exit status 3" {
+		t.Fatalf("expected exit status 3, got %v", err)
+	}
+	path := strings.TrimSpace(output)
+	if !strings.HasPrefix(filepath.Base(path), "gocron-script-") {
+		t.Fatalf("unexpected script path: %s", path)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected temp script to be removed, stat err: %v", err)
+	}
+}
+
+func TestExecScriptUnsupportedInterpreter(t *testing.T) {
+	if _, err := ExecScript(context.Background(), "ruby", "puts 1", "", ExecOptions{}); err == nil {
+		t.Fatal("expected error for unsupported interpreter")
+	}
+	if IsScriptInterpreter("ruby") || !IsScriptInterpreter("python3") {
+		t.Fatal("unexpected interpreter support")
+	}
+}
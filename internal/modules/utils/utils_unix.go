@@ -5,42 +5,221 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/net/context"
 )
 
-type Result struct {
-	output string
-	err    error
-}
-
 // 执行shell命令，可设置执行超时时间
 func ExecShell(ctx context.Context, command string) (string, error) {
+	return ExecShellWithOptions(ctx, command, ExecOptions{})
+}
+
+// ExecShellWithOptions 按指定的环境变量、工作目录及执行用户执行shell命令
+func ExecShellWithOptions(ctx context.Context, command string, options ExecOptions) (string, error) {
+	result, err := ExecShellDetail(ctx, command, options)
+	return result.Output, err
+}
+
+// ExecShellDetail 执行shell命令, 返回分离的标准输出、标准错误、退出码及资源占用
+func ExecShellDetail(ctx context.Context, command string, options ExecOptions) (ExecResult, error) {
+	credential, runAsUser, err := lookupCredential(options)
+	if err != nil {
+		return ExecResult{}, err
+	}
 	cmd := exec.Command("/bin/bash", "-c", command)
 	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
+		Setpgid:    true,
+		Credential: credential,
 	}
-	// 设置工作目录为用户家目录，避免 getcwd 错误
-	if homeDir, err := os.UserHomeDir(); err == nil {
-		cmd.Dir = homeDir
-	} else {
+	cmd.Env = os.Environ()
+	switch {
+	case options.Dir != "":
+		cmd.Dir = options.Dir
+	case runAsUser != nil:
+		// 系统用户的家目录可能不存在
 		cmd.Dir = "/tmp"
+		if info, err := os.Stat(runAsUser.HomeDir); err == nil && info.IsDir() {
+			cmd.Dir = runAsUser.HomeDir
+		}
+	default:
+		// 设置工作目录为用户家目录，避免 getcwd 错误
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			cmd.Dir = homeDir
+		} else {
+			cmd.Dir = "/tmp"
+		}
+	}
+	if runAsUser != nil {
+		cmd.Env = append(cmd.Env, "HOME="+runAsUser.HomeDir, "USER="+runAsUser.Username, "LOGNAME="+runAsUser.Username)
 	}
-	resultChan := make(chan Result)
+	cmd.Env = append(cmd.Env, options.Env...)
+	capture := newOutputCapture(options)
+	cmd.Stdout, cmd.Stderr = capture.writers(options)
+	startTime := time.Now()
+	// 先启动进程, 避免ctx已结束时进程尚未创建
+	if err := cmd.Start(); err != nil {
+		return ExecResult{}, err
+	}
+	errChan := make(chan error, 1)
 	go func() {
-		output, err := cmd.CombinedOutput()
-		resultChan <- Result{string(output), err}
+		errChan <- cmd.Wait()
 	}()
 	select {
 	case <-ctx.Done():
-		if cmd.Process.Pid > 0 {
-			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		signal, exited := terminate(ctx, cmd.Process.Pid, options, errChan)
+		state := cmd.ProcessState
+		if !exited {
+			state = nil
+		}
+		result := capture.result(state, time.Since(startTime))
+		result.Signal = signal
+		return result, errors.New("timeout killed")
+	case err = <-errChan:
+		return capture.result(cmd.ProcessState, time.Since(startTime)), err
+	}
+}
+
+// 支持的停止信号
+var stopSignals = map[string]syscall.Signal{
+	"SIGTERM": syscall.SIGTERM,
+	"SIGINT":  syscall.SIGINT,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGKILL": syscall.SIGKILL,
+}
+
+// 向进程组发送停止信号, 宽限期内未退出时发送SIGKILL, 返回结束进程的信号及进程是否已退出
+func terminate(ctx context.Context, pid int, options ExecOptions, errChan <-chan error) (string, bool) {
+	name := options.stopSignal(ctx)
+	signal, ok := stopSignals[name]
+	if !ok {
+		name, signal = "SIGTERM", syscall.SIGTERM
+	}
+	if signal != syscall.SIGKILL {
+		_ = syscall.Kill(-pid, signal)
+		select {
+		case <-errChan:
+			return name, true
+		case <-time.After(options.gracePeriod()):
 		}
-		return "", errors.New("timeout killed")
-	case result := <-resultChan:
-		return result.output, result.err
 	}
+	_ = syscall.Kill(-pid, syscall.SIGKILL)
+	select {
+	case <-errChan:
+		return "SIGKILL", true
+	case <-time.After(killWaitTimeout):
+		return "SIGKILL", false
+	}
+}
+
+// KillProcessGroupOnCancel 在独立进程组中运行命令, CommandContext的ctx取消时结束整个进程组
+func KillProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// 执行脚本文件的命令, 未指定解释器时直接执行, 由脚本首行决定解释器
+func scriptCommand(interpreter, path string) (string, error) {
+	path = "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	if interpreter == "" {
+		return path, nil
+	}
+
+	return interpreter + " " + path, nil
+}
+
+// 解析执行用户及用户组的进程凭证, 未指定时返回nil, 切换用户需要以root运行
+func lookupCredential(options ExecOptions) (*syscall.Credential, *user.User, error) {
+	if !options.RunAs() {
+		return nil, nil, nil
+	}
+	if os.Geteuid() != 0 {
+		return nil, nil, errors.New("gocron-node未以root运行, 不能切换执行用户")
+	}
+	credential := &syscall.Credential{
+		Uid:         uint32(os.Getuid()),
+		Gid:         uint32(os.Getgid()),
+		NoSetGroups: true,
+	}
+	var runAsUser *user.User
+	if options.User != "" {
+		u, err := user.Lookup(options.User)
+		if err != nil {
+			return nil, nil, fmt.Errorf("执行用户不存在: %s", options.User)
+		}
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("执行用户UID错误: %s", u.Uid)
+		}
+		gid, err := strconv.ParseUint(u.Gid, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("执行用户GID错误: %s", u.Gid)
+		}
+		credential.Uid = uint32(uid)
+		credential.Gid = uint32(gid)
+		credential.NoSetGroups = false
+		// 附加用户所属的其他用户组
+		if groupIds, err := u.GroupIds(); err == nil {
+			for _, groupId := range groupIds {
+				if id, err := strconv.ParseUint(groupId, 10, 32); err == nil {
+					credential.Groups = append(credential.Groups, uint32(id))
+				}
+			}
+		}
+		runAsUser = u
+	}
+	if options.Group != "" {
+		g, err := user.LookupGroup(options.Group)
+		if err != nil {
+			return nil, nil, fmt.Errorf("执行用户组不存在: %s", options.Group)
+		}
+		gid, err := strconv.ParseUint(g.Gid, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("执行用户组GID错误: %s", g.Gid)
+		}
+		credential.Gid = uint32(gid)
+	}
+
+	return credential, runAsUser, nil
+}
+
+// 切换执行用户时将脚本文件属主修改为执行用户
+func chownScript(path string, options ExecOptions) error {
+	credential, _, err := lookupCredential(options)
+	if err != nil || credential == nil {
+		return err
+	}
+	if err = os.Chown(path, int(credential.Uid), int(credential.Gid)); err != nil {
+		return fmt.Errorf("设置脚本文件属主失败: %s", err)
+	}
+
+	return nil
+}
+
+// 进程及其子进程的峰值内存(KB), macOS的ru_maxrss单位为字节
+func maxRSS(state *os.ProcessState) int64 {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+	if runtime.GOOS == "darwin" {
+		return int64(rusage.Maxrss) / 1024
+	}
+
+	return int64(rusage.Maxrss)
 }
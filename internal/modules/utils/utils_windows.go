@@ -5,46 +5,78 @@ package utils
 
 import (
 	"errors"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
 	"syscall"
+	"time"
 
 	"golang.org/x/net/context"
 )
 
-type Result struct {
-	output string
-	err    error
-}
+var errWindowsRunAs = errors.New("Windows不支持切换执行用户")
 
 // 执行shell命令，可设置执行超时时间
 func ExecShell(ctx context.Context, command string) (string, error) {
+	return ExecShellWithOptions(ctx, command, ExecOptions{})
+}
+
+// ExecShellWithOptions 按指定的环境变量及工作目录执行命令, Windows不支持切换执行用户
+func ExecShellWithOptions(ctx context.Context, command string, options ExecOptions) (string, error) {
+	result, err := ExecShellDetail(ctx, command, options)
+	return result.Output, err
+}
+
+// ExecShellDetail 执行命令, 返回分离的标准输出、标准错误、退出码及CPU时间
+func ExecShellDetail(ctx context.Context, command string, options ExecOptions) (ExecResult, error) {
+	if options.RunAs() {
+		return ExecResult{}, errWindowsRunAs
+	}
 	cmd := exec.Command("cmd", "/C", command)
 	// 隐藏cmd窗口
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		HideWindow: true,
 	}
-	// 设置工作目录为用户家目录，避免目录不存在错误
-	if homeDir, err := os.UserHomeDir(); err == nil {
+	if options.Dir != "" {
+		cmd.Dir = options.Dir
+	} else if homeDir, err := os.UserHomeDir(); err == nil {
+		// 设置工作目录为用户家目录，避免目录不存在错误
 		cmd.Dir = homeDir
 	} else {
 		cmd.Dir = os.TempDir()
 	}
-	var resultChan chan Result = make(chan Result)
+	cmd.Env = append(os.Environ(), options.Env...)
+	capture := newOutputCapture(options)
+	capture.convert = ConvertEncoding
+	options.Stdout = encodingWriter(options.Stdout)
+	options.Stderr = encodingWriter(options.Stderr)
+	cmd.Stdout, cmd.Stderr = capture.writers(options)
+	startTime := time.Now()
+	// 先启动进程, 避免ctx已结束时进程尚未创建
+	if err := cmd.Start(); err != nil {
+		return ExecResult{}, err
+	}
+	errChan := make(chan error, 1)
 	go func() {
-		output, err := cmd.CombinedOutput()
-		resultChan <- Result{string(output), err}
+		errChan <- cmd.Wait()
 	}()
 	select {
 	case <-ctx.Done():
-		if cmd.Process.Pid > 0 {
-			exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
-			cmd.Process.Kill()
+		// Windows不支持停止信号, 直接强制结束命令及其子进程
+		exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+		cmd.Process.Kill()
+		var state *os.ProcessState
+		select {
+		case <-errChan:
+			state = cmd.ProcessState
+		case <-time.After(killWaitTimeout):
 		}
-		return "", errors.New("timeout killed")
-	case result := <-resultChan:
-		return ConvertEncoding(result.output), result.err
+		result := capture.result(state, time.Since(startTime))
+		result.Signal = "SIGKILL"
+		return result, errors.New("timeout killed")
+	case err := <-errChan:
+		return capture.result(cmd.ProcessState, time.Since(startTime)), err
 	}
 }
 
@@ -57,3 +89,55 @@ func ConvertEncoding(outputGBK string) string {
 
 	return outputGBK
 }
+
+// 实时输出转换为utf8后写入
+type utf8Writer struct {
+	w io.Writer
+}
+
+func (u utf8Writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(u.w, ConvertEncoding(string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func encodingWriter(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+
+	return utf8Writer{w}
+}
+
+// KillProcessGroupOnCancel CommandContext的ctx取消时结束命令及其子进程
+func KillProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		_ = exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+		return cmd.Process.Kill()
+	}
+}
+
+// 执行脚本文件的命令, Windows不支持按脚本首行选择解释器
+func scriptCommand(interpreter, path string) (string, error) {
+	if interpreter == "" {
+		return "", errors.New("Windows不支持shebang脚本, 请选择解释器")
+	}
+
+	return interpreter + ` "` + path + `"`, nil
+}
+
+// Windows不支持切换执行用户, 脚本文件无需修改属主
+func chownScript(path string, options ExecOptions) error {
+	if options.RunAs() {
+		return errWindowsRunAs
+	}
+
+	return nil
+}
+
+// Windows未提供峰值内存
+func maxRSS(state *os.ProcessState) int64 {
+	return 0
+}
@@ -20,12 +20,21 @@ const tokenExpiration = 3 * time.Hour
 
 // GenerateToken 生成注册token
 func GenerateToken(c *gin.Context) {
+	// 可选的主机标签, 保存在token中, 注册时只设置到新建的主机
+	labels, err := models.ParseHostLabels(c.Query("labels"))
+	if err != nil {
+		json := utils.JsonResponse{}
+		c.String(http.StatusOK, json.CommonFailure(i18n.T(c, "host_labels_invalid")))
+		return
+	}
+
 	token := generateRandomToken()
 	expiresAt := time.Now().Add(tokenExpiration)
 
 	agentToken := &models.AgentToken{
 		Token:     token,
 		ExpiresAt: expiresAt,
+		Labels:    labels.String(),
 	}
 
 	if err := agentToken.Create(); err != nil {
@@ -282,6 +291,8 @@ func Register(c *gin.Context) {
 		Alias:  req.Hostname,
 		Port:   5921,
 		Remark: "Auto registered",
+		// 标签只取自生成token时的设置, 不信任请求中的内容
+		Labels: agentToken.Labels,
 	}
 
 	exists, err := host.NameExists(req.Hostname, 0)
@@ -0,0 +1,255 @@
+package calendar
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/i18n"
+	"github.com/gocronx-team/gocron/internal/modules/ical"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+	"github.com/gocronx-team/gocron/internal/routers/base"
+)
+
+// ics文件大小上限
+const maxImportFileSize = 2 * 1024 * 1024
+
+// Index 日历列表
+func Index(c *gin.Context) {
+	calendarModel := new(models.Calendar)
+	queryParams := parseQueryParams(c)
+	total, err := calendarModel.Total(queryParams)
+	if err != nil {
+		logger.Error(err)
+	}
+	calendars, err := calendarModel.List(queryParams)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	jsonResp := utils.JsonResponse{}
+	result := jsonResp.Success(utils.SuccessContent, map[string]interface{}{
+		"total": total,
+		"data":  calendars,
+	})
+	c.String(http.StatusOK, result)
+}
+
+// All 获取所有日历
+func All(c *gin.Context) {
+	calendarModel := new(models.Calendar)
+	calendars, err := calendarModel.AllList()
+	if err != nil {
+		logger.Error(err)
+	}
+
+	jsonResp := utils.JsonResponse{}
+	result := jsonResp.Success(utils.SuccessContent, calendars)
+	c.String(http.StatusOK, result)
+}
+
+// Detail 日历详情
+func Detail(c *gin.Context) {
+	calendarModel := new(models.Calendar)
+	id, _ := strconv.Atoi(c.Param("id"))
+	err := calendarModel.Find(id)
+	jsonResp := utils.JsonResponse{}
+	var result string
+	if err != nil || calendarModel.Id == 0 {
+		logger.Errorf("获取日历详情失败#日历id-%d", id)
+		result = jsonResp.Success(utils.SuccessContent, nil)
+	} else {
+		result = jsonResp.Success(utils.SuccessContent, calendarModel)
+	}
+	c.String(http.StatusOK, result)
+}
+
+type CalendarForm struct {
+	Id     int    `form:"id" json:"id"`
+	Name   string `form:"name" json:"name" binding:"required,max=32"`
+	Dates  string `form:"dates" json:"dates"`
+	Remark string `form:"remark" json:"remark" binding:"max=100"`
+}
+
+// Store 保存、修改日历
+func Store(c *gin.Context) {
+	var form CalendarForm
+	if err := c.ShouldBind(&form); err != nil {
+		json := utils.JsonResponse{}
+		result := json.CommonFailure(i18n.T(c, "form_validation_failed"))
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	json := utils.JsonResponse{}
+	calendarModel := new(models.Calendar)
+	id := form.Id
+	nameExist, err := calendarModel.NameExists(strings.TrimSpace(form.Name), id)
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "operation_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if nameExist {
+		result := json.CommonFailure(i18n.T(c, "calendar_name_exists"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	if _, err = models.ParseCalendarDates(form.Dates); err != nil {
+		result := json.CommonFailure(i18n.T(c, "calendar_dates_invalid")+": "+err.Error(), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	calendarModel.Name = strings.TrimSpace(form.Name)
+	calendarModel.Dates = strings.TrimSpace(form.Dates)
+	calendarModel.Remark = strings.TrimSpace(form.Remark)
+	if id > 0 {
+		if err = new(models.Calendar).Find(id); err != nil {
+			result := json.CommonFailure(i18n.T(c, "calendar_not_found"))
+			c.String(http.StatusOK, result)
+			return
+		}
+		_, err = calendarModel.UpdateBean(id)
+	} else {
+		id, err = calendarModel.Create()
+	}
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "save_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	result := json.Success(i18n.T(c, "save_success"), map[string]interface{}{"id": id})
+	c.String(http.StatusOK, result)
+}
+
+// Import 从iCalendar(.ics)文件导入日期, 指定id时追加到已有日历, 否则按name新建
+func Import(c *gin.Context) {
+	json := utils.JsonResponse{}
+	fileHeader, err := c.FormFile("file")
+	if err != nil || fileHeader.Size > maxImportFileSize {
+		result := json.CommonFailure(i18n.T(c, "calendar_import_file_invalid"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "calendar_import_file_invalid"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	defer file.Close()
+	events, err := ical.Parse(file)
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "calendar_import_file_invalid")+": "+err.Error(), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		line := models.CalendarDateRange{Start: event.Start, End: event.End}.String()
+		if event.Summary != "" {
+			// #会被当作注释起始, 摘要中的#替换掉以免截断
+			line += " # " + strings.ReplaceAll(event.Summary, "#", "")
+		}
+		lines = append(lines, line)
+	}
+	dates := strings.Join(lines, "\n")
+
+	calendarModel := new(models.Calendar)
+	id, _ := strconv.Atoi(c.PostForm("id"))
+	if id > 0 {
+		if err = calendarModel.Find(id); err != nil {
+			result := json.CommonFailure(i18n.T(c, "calendar_not_found"))
+			c.String(http.StatusOK, result)
+			return
+		}
+		if strings.TrimSpace(calendarModel.Dates) != "" {
+			dates = strings.TrimSpace(calendarModel.Dates) + "\n" + dates
+		}
+		calendarModel.Dates = dates
+		_, err = calendarModel.UpdateBean(id)
+	} else {
+		name := strings.TrimSpace(c.PostForm("name"))
+		if name == "" {
+			name = strings.TrimSuffix(fileHeader.Filename, ".ics")
+		}
+		// 按字符截断, 避免截断多字节字符
+		if runes := []rune(name); len(runes) > 32 {
+			name = string(runes[:32])
+		}
+		nameExist, err := calendarModel.NameExists(name, 0)
+		if err != nil {
+			result := json.CommonFailure(i18n.T(c, "operation_failed"), err)
+			c.String(http.StatusOK, result)
+			return
+		}
+		if nameExist {
+			result := json.CommonFailure(i18n.T(c, "calendar_name_exists"))
+			c.String(http.StatusOK, result)
+			return
+		}
+		calendarModel.Name = name
+		calendarModel.Dates = dates
+		id, err = calendarModel.Create()
+	}
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "save_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	result := json.Success(i18n.T(c, "save_success"), map[string]interface{}{
+		"id":    id,
+		"count": len(events),
+	})
+	c.String(http.StatusOK, result)
+}
+
+// Remove 删除日历
+func Remove(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	json := utils.JsonResponse{}
+	var result string
+	if err != nil {
+		result = json.CommonFailure(i18n.T(c, "param_error"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	calendarModel := new(models.Calendar)
+	inUse, err := calendarModel.InUse(id)
+	if err != nil {
+		result = json.CommonFailure(i18n.T(c, "operation_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if inUse {
+		result = json.CommonFailure(i18n.T(c, "calendar_in_use_cannot_delete"))
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	_, err = calendarModel.Delete(id)
+	if err != nil {
+		result = json.CommonFailure(i18n.T(c, "operation_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	result = json.Success(i18n.T(c, "operation_success"), nil)
+	c.String(http.StatusOK, result)
+}
+
+// 解析查询参数
+func parseQueryParams(c *gin.Context) models.CommonMap {
+	var params = models.CommonMap{}
+	params["Name"] = strings.TrimSpace(c.Query("name"))
+	base.ParsePageAndPageSize(c, params)
+
+	return params
+}
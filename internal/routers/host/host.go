@@ -1,21 +1,26 @@
 package host
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
 	"github.com/gocronx-team/gocron/internal/modules/i18n"
 	"github.com/gocronx-team/gocron/internal/modules/logger"
 	"github.com/gocronx-team/gocron/internal/modules/rpc/client"
 	"github.com/gocronx-team/gocron/internal/modules/rpc/grpcpool"
 	"github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+	"github.com/gocronx-team/gocron/internal/modules/sshclient"
 	"github.com/gocronx-team/gocron/internal/modules/utils"
 	"github.com/gocronx-team/gocron/internal/routers/base"
 	"github.com/gocronx-team/gocron/internal/service"
+	"golang.org/x/crypto/ssh"
 )
 
 const testConnectionCommand = "echo hello"
@@ -73,11 +78,18 @@ func Detail(c *gin.Context) {
 }
 
 type HostForm struct {
-	Id     int16  `form:"id" json:"id"`
-	Name   string `form:"name" json:"name" binding:"required,max=64"`
-	Alias  string `form:"alias" json:"alias" binding:"required,max=32"`
-	Port   int    `form:"port" json:"port" binding:"required,min=1,max=65535"`
-	Remark string `form:"remark" json:"remark"`
+	Id            int16                  `form:"id" json:"id"`
+	Name          string                 `form:"name" json:"name" binding:"required,max=64"`
+	Alias         string                 `form:"alias" json:"alias" binding:"required,max=32"`
+	Port          int                    `form:"port" json:"port" binding:"required,min=1,max=65535"`
+	Remark        string                 `form:"remark" json:"remark"`
+	Labels        string                 `form:"labels" json:"labels" binding:"max=512"`
+	SshPort       int                    `form:"ssh_port" json:"ssh_port" binding:"omitempty,min=1,max=65535"`
+	SshUser       string                 `form:"ssh_user" json:"ssh_user" binding:"max=64"`
+	SshAuthType   models.HostSSHAuthType `form:"ssh_auth_type" json:"ssh_auth_type" binding:"oneof=0 1 2"`
+	SshPassword   string                 `form:"ssh_password" json:"ssh_password" binding:"max=256"`
+	SshPrivateKey string                 `form:"ssh_private_key" json:"ssh_private_key" binding:"max=16384"`
+	SshHostKey    string                 `form:"ssh_host_key" json:"ssh_host_key" binding:"max=1024"`
 }
 
 // Store 保存、修改主机信息
@@ -109,6 +121,13 @@ func Store(c *gin.Context) {
 	hostModel.Alias = strings.TrimSpace(form.Alias)
 	hostModel.Port = form.Port
 	hostModel.Remark = strings.TrimSpace(form.Remark)
+	labels, err := models.ParseHostLabels(form.Labels)
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "host_labels_invalid"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	hostModel.Labels = labels.String()
 	isCreate := false
 	oldHostModel := new(models.Host)
 
@@ -119,6 +138,14 @@ func Store(c *gin.Context) {
 			c.String(http.StatusOK, result)
 			return
 		}
+	}
+	if errKey := fillSSHConfig(hostModel, oldHostModel, form); errKey != "" {
+		result := json.CommonFailure(i18n.T(c, errKey))
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	if id > 0 {
 		_, err = hostModel.UpdateBean(id)
 	} else {
 		isCreate = true
@@ -151,6 +178,89 @@ func Store(c *gin.Context) {
 	c.String(http.StatusOK, result)
 }
 
+// 校验并填充SSH连接信息, 编辑时未填写密码或私钥则沿用原有的加密值, 返回错误信息的i18n key
+func fillSSHConfig(hostModel *models.Host, oldHostModel *models.Host, form HostForm) string {
+	hostModel.SshPort = form.SshPort
+	if hostModel.SshPort == 0 {
+		hostModel.SshPort = sshclient.DefaultPort
+	}
+	hostModel.SshAuthType = form.SshAuthType
+	if hostModel.SshAuthType == models.HostSSHAuthNone {
+		return ""
+	}
+	hostModel.SshUser = strings.TrimSpace(form.SshUser)
+	if hostModel.SshUser == "" {
+		return "ssh_user_required"
+	}
+	hostModel.SshHostKey = strings.TrimSpace(form.SshHostKey)
+	if hostModel.SshHostKey != "" {
+		if _, err := sshclient.ParseHostKey(hostModel.SshHostKey); err != nil {
+			return "ssh_host_key_invalid"
+		}
+	}
+
+	var err error
+	switch hostModel.SshAuthType {
+	case models.HostSSHAuthPassword:
+		hostModel.SshPassword, err = encryptHostSecret(form.SshPassword, oldHostModel.SshPassword)
+	case models.HostSSHAuthPrivateKey:
+		privateKey := strings.TrimSpace(form.SshPrivateKey)
+		if privateKey != "" {
+			if _, err = sshclient.ParsePrivateKey(privateKey); err != nil {
+				return "ssh_private_key_invalid"
+			}
+		}
+		hostModel.SshPrivateKey, err = encryptHostSecret(privateKey, oldHostModel.SshPrivateKey)
+	}
+	if err != nil {
+		return "save_failed"
+	}
+	if hostModel.SshPassword == "" && hostModel.SshPrivateKey == "" {
+		return "ssh_credential_required"
+	}
+
+	return ""
+}
+
+// 加密主机凭据, 未填写则沿用原有的加密值
+func encryptHostSecret(secret string, existing string) (string, error) {
+	if secret == "" {
+		return existing, nil
+	}
+
+	return utils.AesEncrypt(app.Setting.AuthSecret, secret)
+}
+
+// SSHHostKey 获取主机SSH公钥及指纹, 供管理员核对后保存
+func SSHHostKey(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Query("id"))
+	hostModel := new(models.Host)
+	err := hostModel.Find(id)
+	json := utils.JsonResponse{}
+	if err != nil || hostModel.Id <= 0 {
+		result := json.CommonFailure(i18n.T(c, "host_not_exist"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	port, _ := strconv.Atoi(c.Query("ssh_port"))
+	if port <= 0 {
+		port = hostModel.SshPort
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), testConnectionTimeout*time.Second)
+	defer cancel()
+	key, err := sshclient.ScanHostKey(ctx, hostModel.Name, port)
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "connection_failed")+"-"+err.Error(), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	result := json.Success(utils.SuccessContent, map[string]string{
+		"host_key":    strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))),
+		"fingerprint": ssh.FingerprintSHA256(key),
+	})
+	c.String(http.StatusOK, result)
+}
+
 // Remove 删除主机
 func Remove(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -191,6 +301,7 @@ func Remove(c *gin.Context) {
 
 	addr := fmt.Sprintf("%s:%d", hostModel.Name, hostModel.Port)
 	grpcpool.Pool.Release(addr)
+	service.RemoveHostStatus(int16(id))
 
 	result = json.Success(i18n.T(c, "operation_success"), nil)
 	c.String(http.StatusOK, result)
@@ -227,6 +338,8 @@ func parseQueryParams(c *gin.Context) models.CommonMap {
 	id, _ := strconv.Atoi(c.Query("id"))
 	params["Id"] = id
 	params["Name"] = strings.TrimSpace(c.Query("name"))
+	status, _ := strconv.Atoi(c.Query("status"))
+	params["Status"] = status
 	base.ParsePageAndPageSize(c, params)
 
 	return params
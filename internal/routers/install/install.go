@@ -140,6 +140,10 @@ func writeConfig(form InstallForm) error {
 		"enable_tls", "false",
 		"concurrency.queue", "500",
 		"auth_secret", utils.RandAuthToken(),
+		"callback_url", "",
+		"local_shell.enable", "false",
+		"ssh.known_hosts", "",
+		"executor.dir", "",
 		"ca_file", "",
 		"cert_file", "",
 		"key_file", "",
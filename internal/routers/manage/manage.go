@@ -8,6 +8,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	"github.com/gocronx-team/gocron/internal/modules/i18n"
 	"github.com/gocronx-team/gocron/internal/modules/logger"
 	"github.com/gocronx-team/gocron/internal/modules/utils"
 	"github.com/gocronx-team/gocron/internal/service"
@@ -276,4 +278,179 @@ func UpdateLogRetentionDays(c *gin.Context) {
 	c.String(http.StatusOK, result)
 }
 
+// HostAlert 主机离线通知配置
+func HostAlert(c *gin.Context) {
+	settingModel := new(models.Setting)
+	jsonResp := utils.JsonResponse{}
+	result := jsonResp.Success("", settingModel.GetHostAlert())
+	c.String(http.StatusOK, result)
+}
+
+func UpdateHostAlert(c *gin.Context) {
+	var form struct {
+		Threshold        int    `json:"threshold" binding:"min=1,max=100"`
+		NotifyType       int8   `json:"notify_type" binding:"oneof=0 1 2 3"`
+		NotifyReceiverId string `json:"notify_receiver_id" binding:"max=256"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		jsonResp := utils.JsonResponse{}
+		result := jsonResp.CommonFailure(i18n.T(c, "form_validation_failed"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	settingModel := new(models.Setting)
+	err := settingModel.UpdateHostAlert(models.HostAlert{
+		Threshold:        form.Threshold,
+		NotifyType:       form.NotifyType,
+		NotifyReceiverId: strings.TrimSpace(form.NotifyReceiverId),
+	})
+	result := utils.JsonResponseByErr(err)
+	c.String(http.StatusOK, result)
+}
+
+// endregion
+
+// region SQL连接配置
+
+// SQLConnectionForm SQL任务数据库连接表单, 编辑时DSN留空表示不修改
+type SQLConnectionForm struct {
+	Id     int    `form:"id" json:"id"`
+	Name   string `form:"name" json:"name" binding:"required,max=64"`
+	Engine string `form:"engine" json:"engine" binding:"required,oneof=mysql postgres sqlite"`
+	Dsn    string `form:"dsn" json:"dsn" binding:"max=1024"`
+}
+
+func SQLConnections(c *gin.Context) {
+	settingModel := new(models.Setting)
+	connections, err := settingModel.SQLConnections()
+	jsonResp := utils.JsonResponse{}
+	var result string
+	if err != nil {
+		logger.Error(err)
+		result = jsonResp.CommonFailure(i18n.T(c, "operation_failed"), err)
+	} else {
+		result = jsonResp.Success(utils.SuccessContent, connections)
+	}
+	c.String(http.StatusOK, result)
+}
+
+func StoreSQLConnection(c *gin.Context) {
+	var form SQLConnectionForm
+	jsonResp := utils.JsonResponse{}
+	if err := c.ShouldBind(&form); err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "form_validation_failed"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	form.Name = strings.TrimSpace(form.Name)
+	settingModel := new(models.Setting)
+	exists, err := settingModel.IsSQLConnectionNameExist(form.Name, form.Id)
+	if err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "operation_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if exists {
+		result := jsonResp.CommonFailure(i18n.T(c, "sql_connection_name_exists"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	dsn, err := sqlConnectionDsn(form)
+	if err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "sql_connection_not_found"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if dsn == "" {
+		result := jsonResp.CommonFailure(i18n.T(c, "sql_connection_dsn_required"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	connection := models.SQLConnection{Name: form.Name, Engine: form.Engine}
+	connection.Dsn, err = utils.AesEncrypt(app.Setting.AuthSecret, dsn)
+	if err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "save_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	id, err := settingModel.SaveSQLConnection(form.Id, connection)
+	if err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "save_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	result := jsonResp.Success(i18n.T(c, "save_success"), map[string]interface{}{"id": id})
+	c.String(http.StatusOK, result)
+}
+
+// TestSQLConnection 测试数据库连接, DSN留空时使用已保存的连接信息
+func TestSQLConnection(c *gin.Context) {
+	var form SQLConnectionForm
+	jsonResp := utils.JsonResponse{}
+	if err := c.ShouldBind(&form); err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "form_validation_failed"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	dsn, err := sqlConnectionDsn(form)
+	if err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "sql_connection_not_found"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if dsn == "" {
+		result := jsonResp.CommonFailure(i18n.T(c, "sql_connection_dsn_required"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	if err = service.ServiceTask.TestSQLConnection(form.Engine, dsn); err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "sql_connection_test_failed")+": "+err.Error(), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	result := jsonResp.Success(i18n.T(c, "operation_success"), nil)
+	c.String(http.StatusOK, result)
+}
+
+func RemoveSQLConnection(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	jsonResp := utils.JsonResponse{}
+	taskModel := new(models.Task)
+	inUse, err := taskModel.SQLConnectionInUse(id)
+	if err != nil {
+		result := jsonResp.CommonFailure(i18n.T(c, "operation_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if inUse {
+		result := jsonResp.CommonFailure(i18n.T(c, "sql_connection_in_use_cannot_delete"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	settingModel := new(models.Setting)
+	_, err = settingModel.RemoveSQLConnection(id)
+	result := utils.JsonResponseByErr(err)
+	c.String(http.StatusOK, result)
+}
+
+// 表单中的DSN, 编辑时未填写则解密已保存的DSN
+func sqlConnectionDsn(form SQLConnectionForm) (string, error) {
+	dsn := strings.TrimSpace(form.Dsn)
+	if dsn != "" || form.Id <= 0 {
+		return dsn, nil
+	}
+	settingModel := new(models.Setting)
+	connection, err := settingModel.SQLConnection(form.Id)
+	if err != nil {
+		return "", err
+	}
+	if connection.Dsn == "" {
+		return "", nil
+	}
+
+	return utils.AesDecrypt(app.Setting.AuthSecret, connection.Dsn)
+}
+
 // endregion
@@ -14,6 +14,7 @@ import (
 	"github.com/gocronx-team/gocron/internal/modules/logger"
 	"github.com/gocronx-team/gocron/internal/modules/utils"
 	"github.com/gocronx-team/gocron/internal/routers/agent"
+	"github.com/gocronx-team/gocron/internal/routers/calendar"
 	"github.com/gocronx-team/gocron/internal/routers/host"
 	"github.com/gocronx-team/gocron/internal/routers/install"
 	"github.com/gocronx-team/gocron/internal/routers/loginlog"
@@ -78,11 +79,19 @@ func Register(r *gin.Engine) {
 	taskGroup := api.Group("/task")
 	{
 		taskGroup.POST("/store", task.Store)
+		taskGroup.GET("/forecast", task.Forecast)
+		taskGroup.GET("/executors", task.Executors)
+		taskGroup.GET("/script-versions/:id", task.ScriptVersions)
 		taskGroup.GET("/:id", task.Detail)
 		taskGroup.GET("", task.Index)
 		taskGroup.GET("/log", tasklog.Index)
+		taskGroup.GET("/log/attempts", tasklog.Attempts)
+		taskGroup.GET("/log/tail", tasklog.Tail)
+		taskGroup.GET("/log/tail-ticket", tasklog.TailTicket)
+		taskGroup.GET("/log/output", tasklog.Output)
 		taskGroup.POST("/log/clear", tasklog.Clear)
 		taskGroup.POST("/log/stop", tasklog.Stop)
+		taskGroup.POST("/callback", tasklog.Callback)
 		taskGroup.POST("/remove/:id", task.Remove)
 		taskGroup.POST("/enable/:id", task.Enable)
 		taskGroup.POST("/disable/:id", task.Disable)
@@ -100,9 +109,21 @@ func Register(r *gin.Engine) {
 		hostGroup.GET("", host.Index)
 		hostGroup.GET("/all", host.All)
 		hostGroup.GET("/ping/:id", host.Ping)
+		hostGroup.GET("/ssh-host-key", host.SSHHostKey)
 		hostGroup.POST("/remove/:id", host.Remove)
 	}
 
+	// 日历
+	calendarGroup := api.Group("/calendar")
+	{
+		calendarGroup.GET("/:id", calendar.Detail)
+		calendarGroup.POST("/store", calendar.Store)
+		calendarGroup.POST("/import", calendar.Import)
+		calendarGroup.GET("", calendar.Index)
+		calendarGroup.GET("/all", calendar.All)
+		calendarGroup.POST("/remove/:id", calendar.Remove)
+	}
+
 	// Agent注册
 	agentGroup := api.Group("/agent")
 	{
@@ -134,9 +155,18 @@ func Register(r *gin.Engine) {
 			webhookGroup.GET("", manage.WebHook)
 			webhookGroup.POST("/update", manage.UpdateWebHook)
 		}
+		sqlConnectionGroup := systemGroup.Group("/sql-connection")
+		{
+			sqlConnectionGroup.GET("", manage.SQLConnections)
+			sqlConnectionGroup.POST("/store", manage.StoreSQLConnection)
+			sqlConnectionGroup.POST("/test", manage.TestSQLConnection)
+			sqlConnectionGroup.POST("/remove/:id", manage.RemoveSQLConnection)
+		}
 		systemGroup.GET("/login-log", loginlog.Index)
 		systemGroup.GET("/log-retention", manage.GetLogRetentionDays)
 		systemGroup.POST("/log-retention", manage.UpdateLogRetentionDays)
+		systemGroup.GET("/host-alert", manage.HostAlert)
+		systemGroup.POST("/host-alert", manage.UpdateHostAlert)
 	}
 
 	// API
@@ -267,7 +297,7 @@ func userAuth(c *gin.Context) {
 
 	uri := strings.TrimRight(path, "/")
 	// 登录接口和安装状态接口不需要认证
-	excludePaths := []string{"", "/api/user/login", "/api/install/status", "/api/agent/install.sh", "/api/agent/register", "/api/agent/download"}
+	excludePaths := []string{"", "/api/user/login", "/api/install/status", "/api/agent/install.sh", "/api/agent/register", "/api/agent/download", "/api/task/callback"}
 	for _, p := range excludePaths {
 		if uri == p {
 			c.Next()
@@ -281,6 +311,21 @@ func userAuth(c *gin.Context) {
 		return
 	}
 
+	// 浏览器EventSource无法设置请求头, 实时输出接口可使用URL参数中的票据认证
+	if uri == "/api/task/log/tail" && c.GetHeader("Auth-Token") == "" && c.Query("ticket") != "" {
+		logId, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+		if err := user.RestoreLogTicket(c, c.Query("ticket"), logId); err != nil {
+			logger.Warnf("实时输出票据校验失败: %v, path: %s", err, path)
+			jsonResp := utils.JsonResponse{}
+			data := jsonResp.Failure(utils.AuthError, i18n.T(c, "auth_failed"))
+			c.String(http.StatusOK, data)
+			c.Abort()
+			return
+		}
+		c.Next()
+		return
+	}
+
 	// 尝试从token恢复用户信息
 	err := user.RestoreToken(c)
 	if err != nil {
@@ -332,6 +377,12 @@ func urlAuth(c *gin.Context) {
 		"/api/install/status",
 		"/api/task",
 		"/api/task/log",
+		"/api/task/log/attempts",
+		"/api/task/log/tail",
+		"/api/task/log/tail-ticket",
+		"/api/task/log/output",
+		"/api/task/forecast",
+		"/api/task/executors",
 		"/api/host",
 		"/api/host/all",
 		"/api/user/login",
@@ -343,6 +394,7 @@ func urlAuth(c *gin.Context) {
 		"/api/agent/install.sh",
 		"/api/agent/register",
 		"/api/agent/download",
+		"/api/task/callback",
 	}
 	for _, p := range allowPaths {
 		if p == uri {
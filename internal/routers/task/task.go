@@ -1,41 +1,110 @@
 package task
 
 import (
+	"errors"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gocronx-team/cron"
 	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
 	"github.com/gocronx-team/gocron/internal/modules/i18n"
 	"github.com/gocronx-team/gocron/internal/modules/logger"
 	"github.com/gocronx-team/gocron/internal/modules/utils"
 	"github.com/gocronx-team/gocron/internal/routers/base"
+	"github.com/gocronx-team/gocron/internal/routers/user"
 	"github.com/gocronx-team/gocron/internal/service"
 )
 
 type TaskForm struct {
-	Id               int                         `form:"id" json:"id"`
-	Level            models.TaskLevel            `form:"level" json:"level" binding:"required,oneof=1 2"`
-	DependencyStatus models.TaskDependencyStatus `form:"dependency_status" json:"dependency_status"`
-	DependencyTaskId string                      `form:"dependency_task_id" json:"dependency_task_id"`
-	Name             string                      `form:"name" json:"name" binding:"required,max=32"`
-	Spec             string                      `form:"spec" json:"spec"`
-	Protocol         models.TaskProtocol         `form:"protocol" json:"protocol" binding:"oneof=1 2"`
-	Command          string                      `form:"command" json:"command" binding:"required,max=256"`
-	HttpMethod       models.TaskHTTPMethod       `form:"http_method" json:"http_method" binding:"oneof=1 2"`
-	Timeout          int                         `form:"timeout" json:"timeout" binding:"min=0,max=86400"`
-	Multi            int8                        `form:"multi" json:"multi" binding:"oneof=1 2"`
-	RetryTimes       int8                        `form:"retry_times" json:"retry_times"`
-	RetryInterval    int16                       `form:"retry_interval" json:"retry_interval"`
-	HostId           string                      `form:"host_id" json:"host_id"`
-	Tag              string                      `form:"tag" json:"tag"`
-	Remark           string                      `form:"remark" json:"remark"`
-	NotifyStatus     int8                        `form:"notify_status" json:"notify_status" binding:"required,oneof=1 2 3 4"`
-	NotifyType       int8                        `form:"notify_type" json:"notify_type" binding:"required,oneof=1 2 3 4"`
-	NotifyReceiverId string                      `form:"notify_receiver_id" json:"notify_receiver_id"`
-	NotifyKeyword    string                      `form:"notify_keyword" json:"notify_keyword"`
+	Id                int                         `form:"id" json:"id"`
+	Level             models.TaskLevel            `form:"level" json:"level" binding:"required,oneof=1 2"`
+	DependencyStatus  models.TaskDependencyStatus `form:"dependency_status" json:"dependency_status"`
+	DependencyTaskId  string                      `form:"dependency_task_id" json:"dependency_task_id"`
+	Name              string                      `form:"name" json:"name" binding:"required,max=32"`
+	Spec              string                      `form:"spec" json:"spec"`
+	Protocol          models.TaskProtocol         `form:"protocol" json:"protocol" binding:"oneof=1 2 3 4 5 6"`
+	Command           string                      `form:"command" json:"command" binding:"max=256"`
+	ScriptInterpreter string                      `form:"script_interpreter" json:"script_interpreter" binding:"omitempty,oneof=bash sh python3 perl shebang"`
+	Script            string                      `form:"script" json:"script" binding:"max=65535"`
+	Env               string                      `form:"env" json:"env" binding:"max=4096"`
+	WorkDir           string                      `form:"work_dir" json:"work_dir" binding:"max=256"`
+	RunAsUser         string                      `form:"run_as_user" json:"run_as_user" binding:"max=32"`
+	RunAsGroup        string                      `form:"run_as_group" json:"run_as_group" binding:"max=32"`
+	HttpMethod        models.TaskHTTPMethod       `form:"http_method" json:"http_method" binding:"oneof=1 2 3 4 5 6"`
+	HttpHeaders       string                      `form:"http_headers" json:"http_headers" binding:"max=2048"`
+	HttpQuery         string                      `form:"http_query" json:"http_query" binding:"max=1024"`
+	HttpBody          string                      `form:"http_body" json:"http_body" binding:"max=65535"`
+	HttpContentType   string                      `form:"http_content_type" json:"http_content_type" binding:"max=128"`
+	HttpAuthType      models.TaskHTTPAuthType     `form:"http_auth_type" json:"http_auth_type" binding:"min=0,max=2"`
+	HttpAuthUser      string                      `form:"http_auth_user" json:"http_auth_user" binding:"max=64"`
+	HttpAuthSecret    string                      `form:"http_auth_secret" json:"http_auth_secret" binding:"max=256"`
+	HttpCaCert        string                      `form:"http_ca_cert" json:"http_ca_cert" binding:"max=16384"`
+	HttpClientCert    string                      `form:"http_client_cert" json:"http_client_cert" binding:"max=16384"`
+	HttpClientKey     string                      `form:"http_client_key" json:"http_client_key" binding:"max=16384"`
+	HttpSkipVerify    int8                        `form:"http_skip_verify" json:"http_skip_verify" binding:"oneof=0 1"`
+	HttpNoRedirect    int8                        `form:"http_no_redirect" json:"http_no_redirect" binding:"oneof=0 1"`
+	HttpProxy         string                      `form:"http_proxy" json:"http_proxy" binding:"max=256"`
+	HttpAsync         int8                        `form:"http_async" json:"http_async" binding:"oneof=0 1"`
+	HttpAsyncTimeout  int                         `form:"http_async_timeout" json:"http_async_timeout" binding:"gte=0"`
+	HttpSuccessCodes  string                      `form:"http_success_codes" json:"http_success_codes" binding:"max=128"`
+	HttpBodyContains  string                      `form:"http_body_contains" json:"http_body_contains" binding:"max=256"`
+	HttpBodyRegex     string                      `form:"http_body_regex" json:"http_body_regex" binding:"max=256"`
+	HttpJsonPath      string                      `form:"http_json_path" json:"http_json_path" binding:"max=128"`
+	HttpJsonValue     string                      `form:"http_json_value" json:"http_json_value" binding:"max=256"`
+	HttpHeaderChecks  string                      `form:"http_header_checks" json:"http_header_checks" binding:"max=1024"`
+	SqlConnectionId   int                         `form:"sql_connection_id" json:"sql_connection_id" binding:"min=0"`
+	SqlMaxRows        int                         `form:"sql_max_rows" json:"sql_max_rows" binding:"min=0,max=1000"`
+	SqlFailOnZero     int8                        `form:"sql_fail_on_zero" json:"sql_fail_on_zero" binding:"oneof=0 1"`
+	Executor          string                      `form:"executor" json:"executor" binding:"max=64"`
+	ExecutorConfig    string                      `form:"executor_config" json:"executor_config" binding:"max=65535"`
+	Timeout           int                         `form:"timeout" json:"timeout" binding:"min=0,max=86400"`
+	Multi             int8                        `form:"multi" json:"multi" binding:"oneof=1 2"`
+	RetryTimes        int8                        `form:"retry_times" json:"retry_times"`
+	RetryInterval     int16                       `form:"retry_interval" json:"retry_interval"`
+	HostId            string                      `form:"host_id" json:"host_id"`
+	HostSelector      string                      `form:"host_selector" json:"host_selector" binding:"max=256"`
+	Tag               string                      `form:"tag" json:"tag"`
+	Remark            string                      `form:"remark" json:"remark"`
+	NotifyStatus      int8                        `form:"notify_status" json:"notify_status" binding:"required,oneof=1 2 3 4 5"`
+	NotifyType        int8                        `form:"notify_type" json:"notify_type" binding:"required,oneof=1 2 3 4"`
+	NotifyReceiverId  string                      `form:"notify_receiver_id" json:"notify_receiver_id"`
+	NotifyKeyword     string                      `form:"notify_keyword" json:"notify_keyword"`
+	NotifyExitCodes   string                      `form:"notify_exit_codes" json:"notify_exit_codes" binding:"max=128"`
+	SuccessExitCodes  string                      `form:"success_exit_codes" json:"success_exit_codes" binding:"max=128"`
+	FailOnStderr      int8                        `form:"fail_on_stderr" json:"fail_on_stderr" binding:"oneof=0 1"`
+	OutputLimit       int                         `form:"output_limit" json:"output_limit" binding:"min=0,max=10240"`
+	SaveOutput        int8                        `form:"save_output" json:"save_output" binding:"oneof=0 1"`
+	StopSignal        string                      `form:"stop_signal" json:"stop_signal" binding:"omitempty,oneof=SIGTERM SIGINT SIGHUP SIGQUIT SIGUSR1 SIGUSR2 SIGKILL"`
+	StopGracePeriod   int                         `form:"stop_grace_period" json:"stop_grace_period" binding:"min=0,max=3600"`
+	Detached          int8                        `form:"detached" json:"detached" binding:"oneof=0 1"`
+	ValidFrom         string                      `form:"valid_from" json:"valid_from"`
+	ValidUntil        string                      `form:"valid_until" json:"valid_until"`
+	MaxRunCount       int                         `form:"max_run_count" json:"max_run_count" binding:"min=0"`
+	CalendarId        int                         `form:"calendar_id" json:"calendar_id" binding:"min=0"`
+	CalendarMode      models.TaskCalendarMode     `form:"calendar_mode" json:"calendar_mode" binding:"omitempty,oneof=1 2"`
+	DelaySeconds      int                         `form:"delay_seconds" json:"delay_seconds"`
+	JitterSeconds     int                         `form:"jitter_seconds" json:"jitter_seconds"`
+	DispatchMode      models.TaskDispatchMode     `form:"dispatch_mode" json:"dispatch_mode" binding:"omitempty,oneof=1 2"`
+	RetryBackoff      models.TaskRetryBackoff     `form:"retry_backoff" json:"retry_backoff" binding:"omitempty,oneof=1 2"`
+	RetryMultiplier   float64                     `form:"retry_multiplier" json:"retry_multiplier"`
+	RetryMaxInterval  int                         `form:"retry_max_interval" json:"retry_max_interval"`
+	RetryJitter       int                         `form:"retry_jitter" json:"retry_jitter"`
+	RetryOn           models.TaskRetryOn          `form:"retry_on" json:"retry_on" binding:"min=0,max=3"`
+	RetryExitCodes    string                      `form:"retry_exit_codes" json:"retry_exit_codes" binding:"max=128"`
+	RetryStatusCodes  string                      `form:"retry_status_codes" json:"retry_status_codes" binding:"max=128"`
+	RetryOutputRegex  string                      `form:"retry_output_regex" json:"retry_output_regex" binding:"max=256"`
+}
+
+// Executors 已注册的执行器及其配置项
+func Executors(c *gin.Context) {
+	jsonResp := utils.JsonResponse{}
+	result := jsonResp.Success(utils.SuccessContent, service.Executors())
+	c.String(http.StatusOK, result)
 }
 
 // 首页
@@ -102,15 +171,22 @@ func Store(c *gin.Context) {
 		return
 	}
 
-	if form.Protocol == models.TaskRPC && form.HostId == "" {
-		result := json.CommonFailure(i18n.T(c, "select_hostname"))
-		c.String(http.StatusOK, result)
-		return
-	}
-
+	// 各协议的校验由执行器完成, 见service.ValidateExecutor
 	taskModel.Name = form.Name
 	taskModel.Protocol = form.Protocol
 	taskModel.Command = strings.TrimSpace(form.Command)
+	if form.ScriptInterpreter != "" {
+		taskModel.ScriptInterpreter = form.ScriptInterpreter
+		// 统一换行符, 避免在Linux主机上执行时因\r报错
+		taskModel.Script = strings.ReplaceAll(form.Script, "\r\n", "\n")
+	}
+	// 环境变量及工作目录仅用于执行命令的任务
+	if form.Protocol == models.TaskRPC || form.Protocol == models.TaskLocal {
+		taskModel.Env = strings.TrimSpace(strings.ReplaceAll(form.Env, "\r\n", "\n"))
+		taskModel.WorkDir = strings.TrimSpace(form.WorkDir)
+	}
+	taskModel.RunAsUser = strings.TrimSpace(form.RunAsUser)
+	taskModel.RunAsGroup = strings.TrimSpace(form.RunAsGroup)
 	taskModel.Timeout = form.Timeout
 	taskModel.Tag = form.Tag
 	taskModel.Remark = form.Remark
@@ -124,6 +200,7 @@ func Store(c *gin.Context) {
 	taskModel.NotifyType = form.NotifyType - 1
 	taskModel.NotifyReceiverId = form.NotifyReceiverId
 	taskModel.NotifyKeyword = form.NotifyKeyword
+	taskModel.NotifyExitCodes = strings.TrimSpace(form.NotifyExitCodes)
 	taskModel.Spec = form.Spec
 	taskModel.Level = form.Level
 	taskModel.DependencyStatus = form.DependencyStatus
@@ -133,21 +210,108 @@ func Store(c *gin.Context) {
 		c.String(http.StatusOK, result)
 		return
 	}
+	if taskModel.NotifyStatus == 4 && taskModel.NotifyExitCodes == "" {
+		result := json.CommonFailure(i18n.T(c, "notify_exit_codes_required"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	// 退出码、标准错误、输出上限及停止信号仅用于执行命令的任务
+	if form.Protocol == models.TaskRPC || form.Protocol == models.TaskLocal {
+		taskModel.SuccessExitCodes = strings.TrimSpace(form.SuccessExitCodes)
+		taskModel.FailOnStderr = form.FailOnStderr
+		taskModel.OutputLimit = form.OutputLimit
+		taskModel.StopSignal = form.StopSignal
+		taskModel.StopGracePeriod = form.StopGracePeriod
+	}
+	// 完整输出保存及分离执行由gocron-node支持
+	if form.Protocol == models.TaskRPC {
+		taskModel.SaveOutput = form.SaveOutput
+		taskModel.Detached = form.Detached
+	}
+	if taskModel.UseHosts() {
+		taskModel.Hosts = parseHostIds(form.HostId)
+		taskModel.HostSelector = strings.TrimSpace(form.HostSelector)
+	}
+	_, notifyCodesErr := models.ParseCodeRanges(taskModel.NotifyExitCodes)
+	_, successCodesErr := models.ParseCodeRanges(taskModel.SuccessExitCodes)
+	if notifyCodesErr != nil || successCodesErr != nil {
+		result := json.CommonFailure(i18n.T(c, "exit_codes_invalid"))
+		c.String(http.StatusOK, result)
+		return
+	}
 	taskModel.HttpMethod = form.HttpMethod
 	if taskModel.Protocol == models.TaskHTTP {
-		command := strings.ToLower(taskModel.Command)
-		if !strings.HasPrefix(command, "http://") && !strings.HasPrefix(command, "https://") {
-			result := json.CommonFailure(i18n.T(c, "invalid_url"))
-			c.String(http.StatusOK, result)
-			return
+		taskModel.HttpAsync = form.HttpAsync
+		taskModel.HttpAsyncTimeout = form.HttpAsyncTimeout
+		taskModel.HttpSuccessCodes = strings.TrimSpace(form.HttpSuccessCodes)
+		taskModel.HttpBodyContains = form.HttpBodyContains
+		taskModel.HttpBodyRegex = form.HttpBodyRegex
+		taskModel.HttpJsonPath = strings.TrimSpace(form.HttpJsonPath)
+		taskModel.HttpJsonValue = form.HttpJsonValue
+		taskModel.HttpHeaderChecks = strings.TrimSpace(form.HttpHeaderChecks)
+		taskModel.HttpHeaders = strings.TrimSpace(form.HttpHeaders)
+		taskModel.HttpQuery = strings.TrimSpace(form.HttpQuery)
+		taskModel.HttpBody = form.HttpBody
+		taskModel.HttpContentType = strings.TrimSpace(form.HttpContentType)
+		oldTask := models.Task{}
+		if id > 0 {
+			oldTask, err = taskModel.Detail(id)
+			if err != nil {
+				result := json.CommonFailure(i18n.T(c, "get_task_info_failed"), err)
+				c.String(http.StatusOK, result)
+				return
+			}
 		}
-		if taskModel.Timeout > 300 {
-			result := json.CommonFailure(i18n.T(c, "http_task_timeout_max_300"))
-			c.String(http.StatusOK, result)
-			return
+		taskModel.HttpAuthType = form.HttpAuthType
+		if taskModel.HttpAuthType != models.TaskHTTPAuthNone {
+			taskModel.HttpAuthUser = strings.TrimSpace(form.HttpAuthUser)
+			taskModel.HttpAuthSecret, err = encryptTaskSecret(form.HttpAuthSecret, oldTask.HttpAuthSecret)
+			if err != nil {
+				result := json.CommonFailure(i18n.T(c, "save_failed"), err)
+				c.String(http.StatusOK, result)
+				return
+			}
+		}
+
+		taskModel.HttpCaCert = strings.TrimSpace(form.HttpCaCert)
+		taskModel.HttpClientCert = strings.TrimSpace(form.HttpClientCert)
+		taskModel.HttpProxy = strings.TrimSpace(form.HttpProxy)
+		taskModel.HttpSkipVerify = form.HttpSkipVerify
+		taskModel.HttpNoRedirect = form.HttpNoRedirect
+		// 编辑时未填写私钥则沿用原有的私钥
+		if taskModel.HttpClientCert != "" {
+			taskModel.HttpClientKey, err = encryptTaskSecret(strings.TrimSpace(form.HttpClientKey), oldTask.HttpClientKey)
+			if err != nil {
+				result := json.CommonFailure(i18n.T(c, "save_failed"), err)
+				c.String(http.StatusOK, result)
+				return
+			}
 		}
 	}
 
+	if taskModel.Protocol == models.TaskSQL {
+		taskModel.SqlConnectionId = form.SqlConnectionId
+		taskModel.SqlMaxRows = form.SqlMaxRows
+		taskModel.SqlFailOnZero = form.SqlFailOnZero
+	}
+	if taskModel.Protocol == models.TaskExecutor {
+		taskModel.Executor = strings.TrimSpace(form.Executor)
+		taskModel.ExecutorConfig = strings.TrimSpace(form.ExecutorConfig)
+	}
+	if err = service.ServiceTask.ValidateExecutor(taskModel); err != nil {
+		message := i18n.T(c, "executor_config_invalid") + ": " + err.Error()
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			message = i18n.T(c, validationErr.Key)
+			if validationErr.Err != nil {
+				message += ": " + validationErr.Err.Error()
+			}
+		}
+		result := json.CommonFailure(message, err)
+		c.String(http.StatusOK, result)
+		return
+	}
+
 	if taskModel.RetryTimes > 10 || taskModel.RetryTimes < 0 {
 		result := json.CommonFailure(i18n.T(c, "retry_times_range_0_10"))
 		c.String(http.StatusOK, result)
@@ -160,6 +324,50 @@ func Store(c *gin.Context) {
 		return
 	}
 
+	taskModel.DispatchMode = form.DispatchMode
+	if taskModel.DispatchMode != models.TaskDispatchSingle {
+		taskModel.DispatchMode = models.TaskDispatchAll
+	}
+
+	taskModel.RetryBackoff = form.RetryBackoff
+	if taskModel.RetryBackoff != models.TaskRetryBackoffExponential {
+		taskModel.RetryBackoff = models.TaskRetryBackoffFixed
+	}
+	taskModel.RetryMultiplier = form.RetryMultiplier
+	if taskModel.RetryMultiplier == 0 {
+		taskModel.RetryMultiplier = 2
+	}
+	if taskModel.RetryMultiplier < 1 || taskModel.RetryMultiplier > 10 {
+		result := json.CommonFailure(i18n.T(c, "retry_multiplier_range_1_10"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	if form.RetryMaxInterval < 0 || form.RetryMaxInterval > 86400 || form.RetryJitter < 0 || form.RetryJitter > 3600 {
+		result := json.CommonFailure(i18n.T(c, "retry_max_interval_jitter_invalid"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	taskModel.RetryMaxInterval = form.RetryMaxInterval
+	taskModel.RetryJitter = form.RetryJitter
+	taskModel.RetryOn = form.RetryOn
+	taskModel.RetryExitCodes = strings.TrimSpace(form.RetryExitCodes)
+	taskModel.RetryStatusCodes = strings.TrimSpace(form.RetryStatusCodes)
+	taskModel.RetryOutputRegex = form.RetryOutputRegex
+	_, exitCodesErr := models.ParseCodeRanges(taskModel.RetryExitCodes)
+	_, statusCodesErr := models.ParseCodeRanges(taskModel.RetryStatusCodes)
+	if exitCodesErr != nil || statusCodesErr != nil {
+		result := json.CommonFailure(i18n.T(c, "retry_codes_invalid"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	if taskModel.RetryOutputRegex != "" {
+		if _, err = regexp.Compile(taskModel.RetryOutputRegex); err != nil {
+			result := json.CommonFailure(i18n.T(c, "retry_output_regex_invalid"), err)
+			c.String(http.StatusOK, result)
+			return
+		}
+	}
+
 	if taskModel.DependencyStatus != models.TaskDependencyStatusStrong &&
 		taskModel.DependencyStatus != models.TaskDependencyStatusWeak {
 		result := json.CommonFailure(i18n.T(c, "select_dependency"))
@@ -168,14 +376,71 @@ func Store(c *gin.Context) {
 	}
 
 	if taskModel.Level == models.TaskLevelParent {
-		err = utils.PanicToError(func() {
-			cron.Parse(form.Spec)
-		})
+		specs := taskModel.Specs()
+		if len(specs) == 0 || len(specs) > models.TaskMaxSpecs {
+			result := json.CommonFailure(i18n.T(c, "crontab_count_invalid"))
+			c.String(http.StatusOK, result)
+			return
+		}
+		for _, spec := range specs {
+			err = utils.PanicToError(func() {
+				cron.Parse(spec)
+			})
+			if err != nil || len(spec) > 64 {
+				result := json.CommonFailure(i18n.T(c, "crontab_parse_failed")+": "+spec, err)
+				c.String(http.StatusOK, result)
+				return
+			}
+		}
+		taskModel.Spec = strings.Join(specs, models.TaskSpecSeparator)
+		if len(taskModel.Spec) > 512 {
+			result := json.CommonFailure(i18n.T(c, "crontab_count_invalid"))
+			c.String(http.StatusOK, result)
+			return
+		}
+
+		taskModel.ValidFrom, err = parseOptionalTime(form.ValidFrom)
 		if err != nil {
-			result := json.CommonFailure(i18n.T(c, "crontab_parse_failed"), err)
+			result := json.CommonFailure(i18n.T(c, "valid_period_invalid"), err)
+			c.String(http.StatusOK, result)
+			return
+		}
+		taskModel.ValidUntil, err = parseOptionalTime(form.ValidUntil)
+		if err != nil {
+			result := json.CommonFailure(i18n.T(c, "valid_period_invalid"), err)
+			c.String(http.StatusOK, result)
+			return
+		}
+		if taskModel.ValidFrom != nil && taskModel.ValidUntil != nil &&
+			!time.Time(*taskModel.ValidUntil).After(time.Time(*taskModel.ValidFrom)) {
+			result := json.CommonFailure(i18n.T(c, "valid_period_invalid"))
 			c.String(http.StatusOK, result)
 			return
 		}
+		taskModel.MaxRunCount = form.MaxRunCount
+
+		if form.CalendarId > 0 {
+			calendarModel := new(models.Calendar)
+			if err = calendarModel.Find(form.CalendarId); err != nil {
+				result := json.CommonFailure(i18n.T(c, "calendar_not_found"))
+				c.String(http.StatusOK, result)
+				return
+			}
+			taskModel.CalendarId = form.CalendarId
+			taskModel.CalendarMode = form.CalendarMode
+		}
+		if taskModel.CalendarMode != models.TaskCalendarModeInclude {
+			taskModel.CalendarMode = models.TaskCalendarModeExclude
+		}
+
+		if form.DelaySeconds < 0 || form.DelaySeconds > models.TaskMaxStartOffset ||
+			form.JitterSeconds < 0 || form.JitterSeconds > models.TaskMaxStartOffset {
+			result := json.CommonFailure(i18n.T(c, "start_offset_range_0_3600"))
+			c.String(http.StatusOK, result)
+			return
+		}
+		taskModel.DelaySeconds = form.DelaySeconds
+		taskModel.JitterSeconds = form.JitterSeconds
 	} else {
 		taskModel.DependencyTaskId = ""
 		taskModel.Spec = ""
@@ -190,6 +455,18 @@ func Store(c *gin.Context) {
 		}
 	}
 
+	scriptChanged := taskModel.IsScript()
+	if scriptChanged && id > 0 {
+		oldTask, err := taskModel.Detail(id)
+		if err != nil {
+			result := json.CommonFailure(i18n.T(c, "get_task_info_failed"), err)
+			c.String(http.StatusOK, result)
+			return
+		}
+		scriptChanged = oldTask.ScriptVersion == 0 || oldTask.Script != taskModel.Script ||
+			oldTask.ScriptInterpreter != taskModel.ScriptInterpreter
+	}
+
 	if id == 0 {
 		taskModel.Status = models.Running
 		id, err = taskModel.Create()
@@ -203,12 +480,25 @@ func Store(c *gin.Context) {
 		return
 	}
 
+	if scriptChanged {
+		scriptVersion := &models.TaskScriptVersion{
+			TaskId:      id,
+			Interpreter: taskModel.ScriptInterpreter,
+			Script:      taskModel.Script,
+			Username:    user.Username(c),
+		}
+		if _, err = scriptVersion.Create(); err != nil {
+			result := json.CommonFailure(i18n.T(c, "save_failed"), err)
+			c.String(http.StatusOK, result)
+			return
+		}
+	}
+
 	taskHostModel := new(models.TaskHost)
-	if form.Protocol == models.TaskRPC {
-		hostIdStrList := strings.Split(form.HostId, ",")
-		hostIds := make([]int, len(hostIdStrList))
-		for i, hostIdStr := range hostIdStrList {
-			hostIds[i], _ = strconv.Atoi(hostIdStr)
+	if len(taskModel.Hosts) > 0 {
+		hostIds := make([]int, len(taskModel.Hosts))
+		for i, host := range taskModel.Hosts {
+			hostIds[i] = int(host.HostId)
 		}
 		_ = taskHostModel.Add(id, hostIds)
 	} else {
@@ -220,7 +510,26 @@ func Store(c *gin.Context) {
 		addTaskToTimer(id)
 	}
 
-	result := json.Success(i18n.T(c, "save_success"), nil)
+	message := i18n.T(c, "save_success")
+	if taskModel.Protocol == models.TaskHTTP && taskModel.HttpSkipVerify > 0 {
+		message = i18n.T(c, "http_skip_verify_warning")
+	}
+	result := json.Success(message, nil)
+	c.String(http.StatusOK, result)
+}
+
+// ScriptVersions 脚本任务的历史版本
+func ScriptVersions(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	jsonResp := utils.JsonResponse{}
+	versionModel := new(models.TaskScriptVersion)
+	versions, err := versionModel.List(id)
+	var result string
+	if err != nil {
+		result = jsonResp.CommonFailure(utils.FailureContent, err)
+	} else {
+		result = jsonResp.Success(utils.SuccessContent, versions)
+	}
 	c.String(http.StatusOK, result)
 }
 
@@ -236,6 +545,7 @@ func Remove(c *gin.Context) {
 	} else {
 		taskHostModel := new(models.TaskHost)
 		_ = taskHostModel.Remove(id)
+		_ = new(models.TaskScriptVersion).RemoveByTaskId(id)
 		service.ServiceTask.Remove(id)
 		result = json.Success(utils.SuccessContent, nil)
 	}
@@ -336,6 +646,7 @@ func BatchRemove(c *gin.Context) {
 		if err == nil {
 			successCount++
 			_ = taskHostModel.Remove(id)
+			_ = new(models.TaskScriptVersion).RemoveByTaskId(id)
 			service.ServiceTask.Remove(id)
 		}
 	}
@@ -381,6 +692,102 @@ func addTaskToTimer(id int) {
 	service.ServiceTask.RemoveAndAdd(task)
 }
 
+// Forecast 调度预测, 统计时间窗口内各主机每分钟的计划执行数量并标记热点
+func Forecast(c *gin.Context) {
+	json := utils.JsonResponse{}
+	now := time.Now()
+	start := now
+	end := now.Add(24 * time.Hour)
+	var err error
+	if value := strings.TrimSpace(c.Query("start")); value != "" {
+		start, err = time.ParseInLocation(models.DefaultTimeFormat, value, time.Local)
+		if err != nil {
+			c.String(http.StatusOK, json.CommonFailure(i18n.T(c, "forecast_time_format_invalid")))
+			return
+		}
+	}
+	if value := strings.TrimSpace(c.Query("end")); value != "" {
+		end, err = time.ParseInLocation(models.DefaultTimeFormat, value, time.Local)
+		if err != nil {
+			c.String(http.StatusOK, json.CommonFailure(i18n.T(c, "forecast_time_format_invalid")))
+			return
+		}
+	}
+	if !end.After(start) || end.Sub(start) > service.ForecastMaxWindow {
+		c.String(http.StatusOK, json.CommonFailure(i18n.T(c, "forecast_window_invalid")))
+		return
+	}
+	threshold, _ := strconv.Atoi(c.Query("threshold"))
+
+	taskModel := new(models.Task)
+	tasks := make([]models.Task, 0)
+	for page := 1; ; page++ {
+		list, err := taskModel.ActiveList(page, models.MaxPageSize)
+		if err != nil {
+			c.String(http.StatusOK, json.CommonFailure(utils.FailureContent, err))
+			return
+		}
+		if len(list) == 0 {
+			break
+		}
+		tasks = append(tasks, list...)
+	}
+
+	calendarModel := new(models.Calendar)
+	calendarList, err := calendarModel.AllList()
+	if err != nil {
+		c.String(http.StatusOK, json.CommonFailure(utils.FailureContent, err))
+		return
+	}
+	calendars := make(map[int]models.Calendar, len(calendarList))
+	for _, item := range calendarList {
+		calendars[item.Id] = item
+	}
+
+	report := service.ServiceTask.Forecast(tasks, calendars, start, end, threshold)
+	c.String(http.StatusOK, json.Success(utils.SuccessContent, report))
+}
+
+// 解析逗号分隔的主机ID
+func parseHostIds(value string) []models.TaskHostDetail {
+	hosts := make([]models.TaskHostDetail, 0)
+	for _, item := range strings.Split(value, ",") {
+		hostId, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || hostId <= 0 {
+			continue
+		}
+		host := models.TaskHostDetail{}
+		host.HostId = int16(hostId)
+		hosts = append(hosts, host)
+	}
+
+	return hosts
+}
+
+// 加密任务凭据, 编辑时未填写则沿用原有的加密值
+func encryptTaskSecret(secret string, existing string) (string, error) {
+	if secret == "" {
+		return existing, nil
+	}
+
+	return utils.AesEncrypt(app.Setting.AuthSecret, secret)
+}
+
+// 解析可选的时间参数, 为空时返回nil
+func parseOptionalTime(value string) (*models.LocalTime, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation(models.DefaultTimeFormat, value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	localTime := models.LocalTime(t)
+
+	return &localTime, nil
+}
+
 // 解析查询参数
 func parseQueryParams(c *gin.Context) models.CommonMap {
 	var params models.CommonMap = models.CommonMap{}
@@ -3,8 +3,11 @@ package tasklog
 // 任务日志
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gocronx-team/gocron/internal/models"
@@ -12,9 +15,17 @@ import (
 	"github.com/gocronx-team/gocron/internal/modules/logger"
 	"github.com/gocronx-team/gocron/internal/modules/utils"
 	"github.com/gocronx-team/gocron/internal/routers/base"
+	"github.com/gocronx-team/gocron/internal/routers/user"
 	"github.com/gocronx-team/gocron/internal/service"
 )
 
+const (
+	// 回调请求体上限
+	callbackMaxBodySize = 4 << 20
+	// 实时输出无新内容时发送心跳的间隔, 避免被代理断开
+	tailHeartbeatInterval = 15 * time.Second
+)
+
 func Index(c *gin.Context) {
 	logModel := new(models.TaskLog)
 	queryParams := parseQueryParams(c)
@@ -34,6 +45,123 @@ func Index(c *gin.Context) {
 	c.String(http.StatusOK, result)
 }
 
+// Attempts 任务日志的每次执行尝试
+func Attempts(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	attemptModel := new(models.TaskLogAttempt)
+	attempts, err := attemptModel.ListByTaskLogId(id)
+	if err != nil {
+		logger.Error(err)
+	}
+	jsonResp := utils.JsonResponse{}
+	result := jsonResp.Success(utils.SuccessContent, attempts)
+	c.String(http.StatusOK, result)
+}
+
+// TailTicket 生成读取实时输出的短期票据, 供EventSource通过URL参数ticket认证
+func TailTicket(c *gin.Context) {
+	json := utils.JsonResponse{}
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	if id <= 0 {
+		result := json.CommonFailure(i18n.T(c, "invalid_log_id"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	ticket, expiresAt, err := user.GenerateLogTicket(c, id)
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "operation_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	result := json.Success(utils.SuccessContent, map[string]interface{}{
+		"ticket":     ticket,
+		"expires_at": expiresAt,
+	})
+	c.String(http.StatusOK, result)
+}
+
+// Tail 以Server-Sent Events推送运行中任务的实时输出, 任务结束时发送end事件
+// 除Auth-Token请求头外, 也可使用TailTicket生成的票据(URL参数ticket)认证
+func Tail(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	history, chunks, cancel, err := service.ServiceTask.TailOutput(id)
+	if err != nil {
+		json := utils.JsonResponse{}
+		result := json.CommonFailure(i18n.T(c, "task_log_not_running"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// 禁用nginx代理缓冲
+	c.Header("X-Accel-Buffering", "no")
+	for _, chunk := range history {
+		c.SSEvent("output", chunk)
+	}
+	c.Writer.Flush()
+	heartbeat := time.NewTicker(tailHeartbeatInterval)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				c.SSEvent("end", id)
+				return false
+			}
+			c.SSEvent("output", chunk)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// Output 下载gocron-node上保存的完整输出, 多台主机时通过host_id指定主机
+func Output(c *gin.Context) {
+	json := utils.JsonResponse{}
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	hostId, _ := strconv.Atoi(c.Query("host_id"))
+	taskLogModel := new(models.TaskLog)
+	taskLog, err := taskLogModel.Detail(id)
+	if err != nil {
+		result := json.CommonFailure(i18n.T(c, "invalid_log_id"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	var outputFile *models.TaskLogOutputFile
+	outputFiles := taskLog.ParseOutputFiles()
+	for i := range outputFiles {
+		if hostId == 0 || int(outputFiles[i].HostId) == hostId {
+			outputFile = &outputFiles[i]
+			break
+		}
+	}
+	if outputFile == nil {
+		result := json.CommonFailure(i18n.T(c, "task_log_output_not_found"))
+		c.String(http.StatusOK, result)
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+outputFile.File)
+	// 已开始写入响应时无法再返回错误信息
+	if err = service.ServiceTask.CopyOutput(c.Writer, *outputFile); err != nil {
+		logger.Errorf("读取完整输出失败#taskLogId-%d#主机-%s#错误-%s", id, outputFile.Host, err.Error())
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			result := json.CommonFailure(i18n.T(c, "task_log_output_fetch_failed")+": "+err.Error(), err)
+			c.String(http.StatusOK, result)
+		}
+	}
+}
+
 // 清空日志
 func Clear(c *gin.Context) {
 	taskLogModel := new(models.TaskLog)
@@ -73,24 +201,128 @@ func Stop(c *gin.Context) {
 		c.String(http.StatusOK, result)
 		return
 	}
+	if err = task.ResolveHosts(); err != nil {
+		logger.Warnf("按标签选择器匹配主机失败#任务ID-%d#%s", task.Id, err)
+	}
+	// 失败后等待重试的任务没有运行中的进程, 取消等待即可
+	if service.ServiceTask.StopRetry(id) {
+		result = json.Success(i18n.T(c, "stop_task_sent"), nil)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if task.Protocol == models.TaskLocal {
+		service.ServiceTask.StopLocal(id)
+		result = json.Success(i18n.T(c, "stop_task_sent"), nil)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if task.Protocol == models.TaskSSH {
+		service.ServiceTask.StopSSH(id)
+		result = json.Success(i18n.T(c, "stop_task_sent"), nil)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if task.Protocol == models.TaskSQL {
+		service.ServiceTask.StopSQL(id)
+		result = json.Success(i18n.T(c, "stop_task_sent"), nil)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if task.Protocol == models.TaskExecutor {
+		if err = service.ServiceTask.StopExecutor(task, id); err != nil {
+			result = json.CommonFailure(i18n.T(c, "stop_task_failed")+": "+err.Error(), err)
+		} else {
+			result = json.Success(i18n.T(c, "stop_task_sent"), nil)
+		}
+		c.String(http.StatusOK, result)
+		return
+	}
 	if task.Protocol != models.TaskRPC {
 		result = json.CommonFailure(i18n.T(c, "only_shell_task_can_stop"))
 		c.String(http.StatusOK, result)
 		return
 	}
-	if len(task.Hosts) == 0 {
+	// 只停止执行本次任务的主机, 任务的主机或标签可能在执行后被修改
+	hosts := task.Hosts
+	taskLog, err := new(models.TaskLog).Detail(id)
+	if err != nil {
+		result = json.CommonFailure(i18n.T(c, "stop_task_failed")+": "+err.Error(), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	execHosts, err := taskLog.ExecHosts()
+	if err != nil {
+		result = json.CommonFailure(i18n.T(c, "stop_task_failed")+": "+err.Error(), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	// 旧日志未记录主机时使用任务当前的主机
+	if taskLog.HostIds != "" {
+		hosts = execHosts
+	}
+	if len(hosts) == 0 {
 		result = json.CommonFailure(i18n.T(c, "task_node_list_empty"))
 		c.String(http.StatusOK, result)
 		return
 	}
-	for _, host := range task.Hosts {
-		service.ServiceTask.Stop(host.Name, host.Port, id)
+	// 可指定先发送的停止信号, 为空时使用任务设置的信号
+	signal := c.PostForm("signal")
+	if signal != "" && !utils.IsStopSignal(signal) {
+		result = json.CommonFailure(i18n.T(c, "stop_signal_invalid"))
+		c.String(http.StatusOK, result)
+		return
 	}
+	service.ServiceTask.Stop(hosts, id, signal)
 
 	result = json.Success(i18n.T(c, "stop_task_sent"), nil)
 	c.String(http.StatusOK, result)
 }
 
+// 异步HTTP任务回调参数, 令牌也可通过请求头X-Gocron-Callback-Token传递
+type CallbackForm struct {
+	Id     int64  `form:"id" json:"id"`
+	Token  string `form:"token" json:"token"`
+	Status string `form:"status" json:"status" binding:"omitempty,oneof=success failure"`
+	Result string `form:"result" json:"result"`
+}
+
+// 异步HTTP任务回调, 通过回调令牌认证
+func Callback(c *gin.Context) {
+	json := utils.JsonResponse{}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, callbackMaxBodySize)
+	var form CallbackForm
+	if err := c.ShouldBind(&form); err != nil {
+		result := json.CommonFailure(i18n.T(c, "form_validation_failed"), err)
+		c.String(http.StatusOK, result)
+		return
+	}
+	if form.Id <= 0 {
+		form.Id, _ = strconv.ParseInt(c.Query("id"), 10, 64)
+	}
+	if form.Id <= 0 {
+		result := json.CommonFailure(i18n.T(c, "invalid_log_id"))
+		c.String(http.StatusOK, result)
+		return
+	}
+	if form.Token == "" {
+		form.Token = c.GetHeader(service.AsyncCallbackTokenHeader)
+	}
+	err := service.ServiceTask.CompleteAsync(form.Id, form.Token, form.Status != "failure", form.Result)
+	var result string
+	switch {
+	case err == nil:
+		result = json.Success(utils.SuccessContent, nil)
+	case errors.Is(err, service.ErrAsyncCallbackTokenInvalid):
+		logger.Warnf("异步任务回调令牌无效#taskLogId-%d#IP-%s", form.Id, c.ClientIP())
+		result = json.CommonFailure(i18n.T(c, "async_callback_token_invalid"))
+	case errors.Is(err, service.ErrAsyncTaskNotRunning):
+		result = json.CommonFailure(i18n.T(c, "async_task_not_running"))
+	default:
+		result = json.CommonFailure(utils.FailureContent, err)
+	}
+	c.String(http.StatusOK, result)
+}
+
 // 删除N个月前的日志
 func Remove(c *gin.Context) {
 	month, _ := strconv.Atoi(c.Param("id"))
@@ -21,6 +21,9 @@ import (
 
 const tokenDuration = 4 * time.Hour
 
+// 任务日志实时输出票据的有效期, 仅在建立连接时校验
+const logTicketDuration = time.Minute
+
 // UserForm 用户表单
 type UserForm struct {
 	Id              int           `form:"id" json:"id"`
@@ -460,6 +463,63 @@ func generateToken(user *models.User) (string, error) {
 	return token.SignedString([]byte(app.Setting.AuthSecret))
 }
 
+// GenerateLogTicket 生成只能读取指定任务日志实时输出的短期票据
+// 浏览器EventSource无法设置请求头, 票据通过URL参数传递
+func GenerateLogTicket(c *gin.Context, logId int64) (string, time.Time, error) {
+	isAdmin := 0
+	if IsAdmin(c) {
+		isAdmin = 1
+	}
+	expiresAt := time.Now().Add(logTicketDuration)
+	claims := jwt.MapClaims{
+		"exp":      expiresAt.Unix(),
+		"uid":      Uid(c),
+		"iat":      time.Now().Unix(),
+		"issuer":   "gocron",
+		"username": Username(c),
+		"is_admin": isAdmin,
+		"log_id":   logId,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	ticket, err := token.SignedString(logTicketSecret())
+
+	return ticket, expiresAt, err
+}
+
+// RestoreLogTicket 校验任务日志实时输出票据, 票据只对签发时指定的任务日志有效
+func RestoreLogTicket(c *gin.Context, ticket string, logId int64) error {
+	token, err := jwt.Parse(ticket, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return logTicketSecret(), nil
+	})
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("ticket is invalid")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return errors.New("invalid claims")
+	}
+	ticketLogId, ok := claims["log_id"].(float64)
+	if !ok || logId <= 0 || int64(ticketLogId) != logId {
+		return errors.New("ticket does not match task log")
+	}
+	c.Set("uid", int(claims["uid"].(float64)))
+	c.Set("username", claims["username"])
+	c.Set("is_admin", int(claims["is_admin"].(float64)))
+
+	return nil
+}
+
+// 票据使用单独的密钥签名, 不能作为登录token使用
+func logTicketSecret() []byte {
+	return []byte(app.Setting.AuthSecret + "#task-log-ticket")
+}
+
 // 还原jwt
 func RestoreToken(c *gin.Context) error {
 	authToken := c.GetHeader("Auth-Token")
@@ -0,0 +1,183 @@
+package service
+
+// 命令执行详情: 退出码、标准输出、标准错误及资源占用, 由gocron-node或本机执行返回
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+)
+
+var (
+	ErrStderrNotEmpty = errors.New("标准错误输出不为空")
+
+	execDetails = execDetailRegistry{byId: make(map[int64]*ExecDetail)}
+)
+
+// ExecDetail 命令执行详情, 多台主机执行时为合并后的结果
+type ExecDetail struct {
+	ExitCode   int    // 退出码, 多台主机时为首个非0退出码
+	Stdout     string // 标准输出
+	Stderr     string // 标准错误
+	WallTime   int64  // 执行耗时(毫秒), 多台主机时取最大值
+	UserTime   int64  // 用户态CPU时间(毫秒), 多台主机时累加
+	SystemTime int64  // 内核态CPU时间(毫秒), 多台主机时累加
+	MaxRss     int64  // 峰值内存(KB), 多台主机时取最大值
+	Truncated  bool   // 输出超过限制, 已省略中间部分
+	Signal     string // 超时或停止时结束进程的信号, 多台主机时为首个非空信号
+	// gocron-node上保存的完整输出文件
+	OutputFiles []models.TaskLogOutputFile
+}
+
+// ExitCodeError 命令以非0退出码结束
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// 节点返回的执行详情, 旧版本节点及超时强制结束时不返回
+func detailFromResponse(resp *pb.TaskResponse) (ExecDetail, bool) {
+	if !resp.GetExited() {
+		return ExecDetail{}, false
+	}
+
+	return ExecDetail{
+		ExitCode:   int(resp.ExitCode),
+		Stdout:     resp.Stdout,
+		Stderr:     resp.Stderr,
+		WallTime:   resp.WallTime,
+		UserTime:   resp.UserTime,
+		SystemTime: resp.SystemTime,
+		MaxRss:     resp.MaxRss,
+		Truncated:  resp.Truncated,
+		Signal:     resp.Signal,
+	}, true
+}
+
+// 本机执行的执行详情
+func detailFromResult(result utils.ExecResult) (ExecDetail, bool) {
+	if !result.Exited {
+		return ExecDetail{}, false
+	}
+
+	return ExecDetail{
+		ExitCode:   result.ExitCode,
+		Stdout:     result.Stdout,
+		Stderr:     result.Stderr,
+		WallTime:   result.WallTime.Milliseconds(),
+		UserTime:   result.UserTime.Milliseconds(),
+		SystemTime: result.SystemTime.Milliseconds(),
+		MaxRss:     result.MaxRSS,
+		Truncated:  result.Truncated,
+		Signal:     result.Signal,
+	}, true
+}
+
+// 按任务的成功规则判断执行结果, 非0退出码的错误中携带退出码, 供重试条件判断
+// 超时或停止后结束的进程保留原错误
+func checkExecDetail(taskModel models.Task, detail ExecDetail, err error) error {
+	if detail.Signal != "" {
+		return err
+	}
+	if detail.ExitCode != 0 && err != nil {
+		ranges, _ := models.ParseCodeRanges(taskModel.SuccessExitCodes)
+		if ranges.Contains(detail.ExitCode) {
+			err = nil
+		} else {
+			err = &ExitCodeError{Code: detail.ExitCode, Err: err}
+		}
+	}
+	if err == nil && taskModel.FailOnStderr > 0 && strings.TrimSpace(detail.Stderr) != "" {
+		err = ErrStderrNotEmpty
+	}
+
+	return err
+}
+
+// 执行详情, 任务日志ID作为Key, 每次执行结束后由execJob取出
+type execDetailRegistry struct {
+	sync.Mutex
+	byId map[int64]*ExecDetail
+}
+
+// 记录一台主机的执行详情, host不为空时标准输出和标准错误以主机信息开头
+func (r *execDetailRegistry) record(id int64, host string, detail ExecDetail) {
+	if host != "" {
+		if detail.Stdout != "" {
+			detail.Stdout = fmt.Sprintf("主机: [%s]\n%s\n", host, strings.TrimRight(detail.Stdout, "\n"))
+		}
+		if detail.Stderr != "" {
+			detail.Stderr = fmt.Sprintf("主机: [%s]\n%s\n", host, strings.TrimRight(detail.Stderr, "\n"))
+		}
+	}
+	r.Lock()
+	defer r.Unlock()
+	merged, ok := r.byId[id]
+	if !ok {
+		r.byId[id] = &detail
+		return
+	}
+	if merged.ExitCode == 0 {
+		merged.ExitCode = detail.ExitCode
+	}
+	merged.Stdout += detail.Stdout
+	merged.Stderr += detail.Stderr
+	merged.WallTime = max(merged.WallTime, detail.WallTime)
+	merged.UserTime += detail.UserTime
+	merged.SystemTime += detail.SystemTime
+	merged.MaxRss = max(merged.MaxRss, detail.MaxRss)
+	merged.Truncated = merged.Truncated || detail.Truncated
+	if merged.Signal == "" {
+		merged.Signal = detail.Signal
+	}
+	merged.OutputFiles = append(merged.OutputFiles, detail.OutputFiles...)
+}
+
+// 取出本次执行的详情, 未返回执行详情时为nil
+func (r *execDetailRegistry) take(id int64) *ExecDetail {
+	r.Lock()
+	defer r.Unlock()
+	detail := r.byId[id]
+	delete(r.byId, id)
+
+	return detail
+}
+
+// CopyOutput 分段读取gocron-node上保存的完整输出并写入w
+func (task Task) CopyOutput(w io.Writer, outputFile models.TaskLogOutputFile) error {
+	hostModel := new(models.Host)
+	if err := hostModel.Find(int(outputFile.HostId)); err != nil {
+		return err
+	}
+	var offset int64
+	for {
+		chunk, err := rpcFetchOutputFunc(hostModel.Name, hostModel.Port, outputFile.File, offset)
+		if err != nil {
+			return err
+		}
+		if len(chunk.Data) == 0 {
+			return nil
+		}
+		if _, err = w.Write(chunk.Data); err != nil {
+			return err
+		}
+		offset += int64(len(chunk.Data))
+		if offset >= chunk.Size {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+)
+
+func TestCheckExecDetail(t *testing.T) {
+	exitErr := errors.New("exit status 3")
+	task := models.Task{}
+	err := checkExecDetail(task, ExecDetail{ExitCode: 3}, exitErr)
+	var codeErr *ExitCodeError
+	if !errors.As(err, &codeErr) || codeErr.Code != 3 || err.Error() != "exit status 3" {
+		t.Fatalf("expected exit code error, got %v", err)
+	}
+	if code, ok := exitCodeFromError(err); !ok || code != 3 {
+		t.Fatalf("expected retry rules to read exit code, got %d %v", code, ok)
+	}
+
+	task.SuccessExitCodes = "1-3"
+	if err = checkExecDetail(task, ExecDetail{ExitCode: 3}, exitErr); err != nil {
+		t.Fatalf("expected exit code 3 to be treated as success, got %v", err)
+	}
+
+	// 超时结束的进程不按退出码判断
+	if err = checkExecDetail(task, ExecDetail{ExitCode: 3, Signal: "SIGTERM"}, rpcClient.ErrTimeout); err != rpcClient.ErrTimeout {
+		t.Fatalf("expected timeout error to be kept, got %v", err)
+	}
+
+	task.FailOnStderr = 1
+	if err = checkExecDetail(task, ExecDetail{Stderr: "warning\n"}, nil); !errors.Is(err, ErrStderrNotEmpty) {
+		t.Fatalf("expected stderr failure, got %v", err)
+	}
+	if err = checkExecDetail(task, ExecDetail{Stderr: " \n"}, nil); err != nil {
+		t.Fatalf("blank stderr should not fail, got %v", err)
+	}
+}
+
+func TestRPCHandlerRecordsExecDetail(t *testing.T) {
+	originalExec := rpcExecFunc
+	defer func() {
+		rpcExecFunc = originalExec
+	}()
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+		if ip == "10.0.0.2" {
+			return &pb.TaskResponse{Output: "old node"}, nil
+		}
+		return &pb.TaskResponse{
+			Output: "out\nerr\n", Error: "exit status 2", Exited: true, ExitCode: 2,
+			Stdout: "out\n", Stderr: "err\n", WallTime: 30, UserTime: 10, SystemTime: 5, MaxRss: 2048,
+			Truncated: true, OutputFile: "21-100.log",
+		}, errors.New("exit status 2")
+	}
+	task := models.Task{
+		Id:               1,
+		Command:          "run",
+		NotifyStatus:     4,
+		NotifyType:       3,
+		NotifyExitCodes:  "2",
+		SuccessExitCodes: "",
+		Hosts: []models.TaskHostDetail{
+			{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921, Alias: "a"},
+			{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Port: 5921, Alias: "b"},
+		},
+	}
+	result := execJob(&RPCHandler{}, task, 21)
+	if result.Detail == nil {
+		t.Fatal("expected exec detail to be recorded")
+	}
+	detail := result.Detail
+	if detail.ExitCode != 2 || detail.MaxRss != 2048 || detail.UserTime != 10 {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if !detail.Truncated || len(detail.OutputFiles) != 1 || detail.OutputFiles[0].HostId != 1 || detail.OutputFiles[0].File != "21-100.log" {
+		t.Fatalf("expected output file of the first host, got %+v", detail.OutputFiles)
+	}
+	if detail.Stdout != "主机: [a-10.0.0.1:5921]\nout\n" || !strings.Contains(detail.Stderr, "err") {
+		t.Fatalf("unexpected stdout/stderr: %+v", detail)
+	}
+	if code, ok := exitCodeFromError(result.Err); !ok || code != 2 {
+		t.Fatalf("expected exit code error, got %v", result.Err)
+	}
+	if execDetails.take(21) != nil {
+		t.Fatal("exec detail should be removed after execution")
+	}
+
+	messages := stubNotifyPush(t)
+	SendNotification(task, result)
+	if len(*messages) != 1 || (*messages)[0]["exit_code"] != "2" {
+		t.Fatalf("expected exit code notification, got %+v", *messages)
+	}
+	task.NotifyExitCodes = "1"
+	SendNotification(task, result)
+	if len(*messages) != 1 {
+		t.Fatal("notification should not be sent when exit code does not match")
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+// 任务执行器注册表, 内置协议与扩展执行器统一通过注册表创建Handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocronx-team/gocron/internal/models"
+)
+
+// 执行器配置项类型
+const (
+	ExecutorFieldString = "string"
+	ExecutorFieldNumber = "number"
+	ExecutorFieldBool   = "bool"
+)
+
+var (
+	ErrExecutorNotFound = errors.New("执行器不存在")
+
+	executors = executorRegistry{byName: make(map[string]Executor)}
+)
+
+// ExecutorField 执行器配置项, 供前端渲染任务表单
+type ExecutorField struct {
+	Name        string      `json:"name"`
+	Label       string      `json:"label"`
+	Type        string      `json:"type"` // string, number, bool
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// Executor 任务执行器
+type Executor struct {
+	Name     string              `json:"name"`
+	Protocol models.TaskProtocol `json:"protocol"` // 内置执行器对应的任务协议, 扩展执行器为TaskExecutor
+	Fields   []ExecutorField     `json:"fields"`
+	Handler  Handler             `json:"-"`
+	// 保存任务前校验配置, config已按配置项填充默认值
+	Validate func(taskModel models.Task, config map[string]interface{}) error `json:"-"`
+	// 停止运行中的任务, 未设置时不支持停止
+	Stop func(taskLogId int64) `json:"-"`
+}
+
+type executorRegistry struct {
+	sync.RWMutex
+	byName map[string]Executor
+}
+
+func init() {
+	builtin := []Executor{
+		{Name: "http", Protocol: models.TaskHTTP, Handler: new(HTTPHandler), Validate: validateHTTPTask},
+		{Name: "rpc", Protocol: models.TaskRPC, Handler: new(RPCHandler), Validate: validateRPCTask},
+		{Name: "local", Protocol: models.TaskLocal, Handler: new(LocalHandler), Validate: validateLocalTask, Stop: ServiceTask.StopLocal},
+		{Name: "ssh", Protocol: models.TaskSSH, Handler: new(SSHHandler), Validate: validateSSHTask, Stop: ServiceTask.StopSSH},
+		{Name: "sql", Protocol: models.TaskSQL, Handler: new(SQLHandler), Validate: validateSQLTask, Stop: ServiceTask.StopSQL},
+	}
+	for _, executor := range builtin {
+		if err := RegisterExecutor(executor); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterExecutor 注册执行器, 扩展执行器的Protocol为TaskExecutor, 任务通过名称引用
+func RegisterExecutor(executor Executor) error {
+	executor.Name = strings.TrimSpace(executor.Name)
+	if executor.Name == "" || len(executor.Name) > 64 {
+		return errors.New("执行器名称不能为空且长度不能超过64")
+	}
+	if executor.Handler == nil {
+		return fmt.Errorf("执行器[%s]未实现Run", executor.Name)
+	}
+	if executor.Protocol == 0 {
+		executor.Protocol = models.TaskExecutor
+	}
+	for _, field := range executor.Fields {
+		switch field.Type {
+		case ExecutorFieldString, ExecutorFieldNumber, ExecutorFieldBool:
+		default:
+			return fmt.Errorf("执行器[%s]配置项[%s]类型错误-%s", executor.Name, field.Name, field.Type)
+		}
+	}
+
+	executors.Lock()
+	defer executors.Unlock()
+	if _, ok := executors.byName[executor.Name]; ok {
+		return fmt.Errorf("执行器[%s]已注册", executor.Name)
+	}
+	if executor.Protocol != models.TaskExecutor {
+		for _, item := range executors.byName {
+			if item.Protocol == executor.Protocol {
+				return fmt.Errorf("任务协议[%d]已注册执行器[%s]", executor.Protocol, item.Name)
+			}
+		}
+	}
+	executors.byName[executor.Name] = executor
+
+	return nil
+}
+
+// Executors 已注册的执行器列表, 按名称排序
+func Executors() []Executor {
+	executors.RLock()
+	defer executors.RUnlock()
+	list := make([]Executor, 0, len(executors.byName))
+	for _, executor := range executors.byName {
+		list = append(list, executor)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// 查找任务使用的执行器, 扩展执行器按名称查找, 内置执行器按协议查找
+func lookupExecutor(taskModel models.Task) (Executor, bool) {
+	executors.RLock()
+	defer executors.RUnlock()
+	if taskModel.Protocol == models.TaskExecutor {
+		executor, ok := executors.byName[taskModel.Executor]
+		return executor, ok && executor.Protocol == models.TaskExecutor
+	}
+	for _, executor := range executors.byName {
+		if executor.Protocol == taskModel.Protocol {
+			return executor, true
+		}
+	}
+
+	return Executor{}, false
+}
+
+// ValidateExecutor 保存任务前校验执行器配置
+func (task Task) ValidateExecutor(taskModel models.Task) error {
+	executor, ok := lookupExecutor(taskModel)
+	if !ok {
+		return ErrExecutorNotFound
+	}
+	config, err := executor.config(taskModel.ExecutorConfig)
+	if err != nil {
+		return err
+	}
+	if executor.Validate == nil {
+		return nil
+	}
+
+	return executor.Validate(taskModel, config)
+}
+
+// StopExecutor 停止扩展执行器运行中的任务
+func (task Task) StopExecutor(taskModel models.Task, taskLogId int64) error {
+	executor, ok := lookupExecutor(taskModel)
+	if !ok {
+		return ErrExecutorNotFound
+	}
+	if executor.Stop == nil {
+		return fmt.Errorf("执行器[%s]不支持停止任务", executor.Name)
+	}
+	executor.Stop(taskLogId)
+
+	return nil
+}
+
+// 解析任务中JSON格式的执行器配置, 按配置项校验类型并填充默认值
+func (executor Executor) config(value string) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	if strings.TrimSpace(value) != "" {
+		if err := json.Unmarshal([]byte(value), &config); err != nil {
+			return nil, fmt.Errorf("执行器配置必须是JSON对象-%s", err)
+		}
+	}
+	for _, field := range executor.Fields {
+		v, ok := config[field.Name]
+		if !ok || v == nil {
+			if field.Default != nil {
+				config[field.Name] = field.Default
+				continue
+			}
+			if field.Required {
+				return nil, fmt.Errorf("执行器配置项[%s]不能为空", field.Name)
+			}
+			continue
+		}
+		valid := false
+		switch field.Type {
+		case ExecutorFieldString:
+			var s string
+			s, valid = v.(string)
+			if valid && field.Required && strings.TrimSpace(s) == "" {
+				return nil, fmt.Errorf("执行器配置项[%s]不能为空", field.Name)
+			}
+		case ExecutorFieldNumber:
+			_, valid = v.(float64)
+		case ExecutorFieldBool:
+			_, valid = v.(bool)
+		}
+		if !valid {
+			return nil, fmt.Errorf("执行器配置项[%s]类型必须是%s", field.Name, field.Type)
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,200 @@
+package service
+
+// 内置执行器保存任务前的校验
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	"github.com/gocronx-team/gocron/internal/modules/httpclient"
+)
+
+// ValidationError 任务配置校验失败, Key为提示信息的翻译键, Err为附加的错误详情
+type ValidationError struct {
+	Key string
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Err != nil {
+		return e.Key + ": " + e.Err.Error()
+	}
+
+	return e.Key
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func validationError(key string, err error) error {
+	return &ValidationError{Key: key, Err: err}
+}
+
+func validateHTTPTask(taskModel models.Task, _ map[string]interface{}) error {
+	if err := validateTaskCommand(taskModel, false); err != nil {
+		return err
+	}
+	if err := validateRunAs(taskModel); err != nil {
+		return err
+	}
+	command := strings.ToLower(taskModel.Command)
+	if !strings.HasPrefix(command, "http://") && !strings.HasPrefix(command, "https://") {
+		return validationError("invalid_url", nil)
+	}
+	if taskModel.Timeout > 300 {
+		return validationError("http_task_timeout_max_300", nil)
+	}
+	if taskModel.HttpAsync > 0 && app.Setting.CallbackUrl == "" {
+		return validationError("http_callback_url_required", nil)
+	}
+	if taskModel.HttpAsyncTimeout > models.TaskMaxAsyncTimeout {
+		return validationError("http_async_timeout_max_86400", nil)
+	}
+	_, headersErr := models.ParseHTTPParams(taskModel.HttpHeaders)
+	_, queryErr := models.ParseHTTPParams(taskModel.HttpQuery)
+	_, headerChecksErr := models.ParseHTTPParams(taskModel.HttpHeaderChecks)
+	if headersErr != nil || queryErr != nil || headerChecksErr != nil {
+		return validationError("http_params_invalid", nil)
+	}
+	if _, err := models.ParseCodeRanges(taskModel.HttpSuccessCodes); err != nil {
+		return validationError("http_success_codes_invalid", nil)
+	}
+	if taskModel.HttpBodyRegex != "" {
+		if _, err := regexp.Compile(taskModel.HttpBodyRegex); err != nil {
+			return validationError("http_body_regex_invalid", nil)
+		}
+	}
+	if taskModel.HttpAuthType != models.TaskHTTPAuthNone && taskModel.HttpAuthSecret == "" {
+		return validationError("http_auth_secret_required", nil)
+	}
+	clientKey, err := decryptSecret(taskModel.HttpClientKey)
+	if err != nil {
+		return validationError("http_tls_options_invalid", err)
+	}
+	options := httpclient.Options{
+		CACert:     taskModel.HttpCaCert,
+		ClientCert: taskModel.HttpClientCert,
+		ClientKey:  clientKey,
+		Proxy:      taskModel.HttpProxy,
+	}
+	if err = options.Validate(); err != nil {
+		return validationError("http_tls_options_invalid", err)
+	}
+
+	return nil
+}
+
+func validateRPCTask(taskModel models.Task, _ map[string]interface{}) error {
+	if err := validateTaskCommand(taskModel, true); err != nil {
+		return err
+	}
+	if err := validateTaskEnv(taskModel); err != nil {
+		return err
+	}
+
+	return validateTaskHosts(taskModel, false)
+}
+
+func validateLocalTask(taskModel models.Task, _ map[string]interface{}) error {
+	if !app.Setting.EnableLocalShell {
+		return validationError("local_shell_disabled", nil)
+	}
+	if err := validateTaskCommand(taskModel, true); err != nil {
+		return err
+	}
+	if err := validateRunAs(taskModel); err != nil {
+		return err
+	}
+
+	return validateTaskEnv(taskModel)
+}
+
+func validateSSHTask(taskModel models.Task, _ map[string]interface{}) error {
+	if err := validateTaskCommand(taskModel, false); err != nil {
+		return err
+	}
+	if err := validateRunAs(taskModel); err != nil {
+		return err
+	}
+
+	return validateTaskHosts(taskModel, true)
+}
+
+func validateSQLTask(taskModel models.Task, _ map[string]interface{}) error {
+	if err := validateTaskCommand(taskModel, false); err != nil {
+		return err
+	}
+	if err := validateRunAs(taskModel); err != nil {
+		return err
+	}
+	settingModel := new(models.Setting)
+	if _, err := settingModel.SQLConnection(taskModel.SqlConnectionId); err != nil {
+		return validationError("sql_connection_not_found", nil)
+	}
+
+	return nil
+}
+
+// 校验命令或脚本, script为false时不支持脚本任务
+func validateTaskCommand(taskModel models.Task, script bool) error {
+	if taskModel.ScriptInterpreter == "" {
+		if taskModel.Command == "" {
+			return validationError("command_required", nil)
+		}
+		return nil
+	}
+	if !script {
+		return validationError("script_protocol_unsupported", nil)
+	}
+	if strings.TrimSpace(taskModel.Script) == "" {
+		return validationError("script_required", nil)
+	}
+	if taskModel.ScriptInterpreter == "shebang" && !strings.HasPrefix(taskModel.Script, "#!") {
+		return validationError("script_shebang_required", nil)
+	}
+
+	return nil
+}
+
+// 执行用户及用户组仅RPC任务支持, 其他执行器不能设置
+func validateRunAs(taskModel models.Task) error {
+	if taskModel.RunAsUser != "" || taskModel.RunAsGroup != "" {
+		return validationError("run_as_protocol_unsupported", nil)
+	}
+
+	return nil
+}
+
+func validateTaskEnv(taskModel models.Task) error {
+	if _, err := models.ParseTaskEnv(taskModel.Env); err != nil {
+		return validationError("task_env_invalid", err)
+	}
+
+	return nil
+}
+
+// 校验执行主机, 需关联主机或设置标签选择器, ssh为true时关联的主机需配置SSH连接信息
+func validateTaskHosts(taskModel models.Task, ssh bool) error {
+	if len(taskModel.Hosts) == 0 && taskModel.HostSelector == "" {
+		return validationError("select_hostname", nil)
+	}
+	if taskModel.HostSelector != "" {
+		if _, err := models.ParseLabelSelector(taskModel.HostSelector); err != nil {
+			return validationError("host_selector_invalid", nil)
+		}
+	}
+	if !ssh {
+		return nil
+	}
+	for _, item := range taskModel.Hosts {
+		hostModel := new(models.Host)
+		if err := hostModel.Find(int(item.HostId)); err != nil || !hostModel.HasSSH() {
+			return validationError("host_ssh_not_configured", nil)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package service
+
+// 外部程序实现的扩展执行器, 放置在配置项executor.dir指定的目录中
+//
+// gocron通过标准输入写入一个JSON请求, 程序通过标准输出返回一个JSON响应:
+//   获取配置项  {"action":"schema"}
+//            -> {"name":"mongo","fields":[{"name":"uri","label":"URI","type":"string","required":true}]}
+//   校验配置    {"action":"validate","command":"...","config":{...}}
+//            -> {"error":""}
+//   执行任务    {"action":"run","task_id":1,"task_log_id":2,"command":"...","config":{...},"timeout":60}
+//            -> {"output":"...","error":""}
+// error不为空时视为失败, 程序退出码非0且未返回合法响应时以标准错误作为失败原因
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+)
+
+const (
+	// 获取配置项、校验配置的超时时间
+	processCallTimeout = 10 * time.Second
+	// 任务结束后等待输出关闭的时间, 避免子进程持有管道导致无法返回
+	processWaitDelay = 5 * time.Second
+)
+
+// 运行中的外部程序任务, 任务日志ID作为Key
+var processTasks sync.Map
+
+type processRequest struct {
+	Action    string                 `json:"action"`
+	TaskId    int                    `json:"task_id,omitempty"`
+	TaskLogId int64                  `json:"task_log_id,omitempty"`
+	Command   string                 `json:"command,omitempty"`
+	Config    map[string]interface{} `json:"config,omitempty"`
+	Timeout   int                    `json:"timeout,omitempty"`
+}
+
+type processResponse struct {
+	Name   string          `json:"name"`
+	Fields []ExecutorField `json:"fields"`
+	Output string          `json:"output"`
+	Error  string          `json:"error"`
+}
+
+// ProcessExecutor 通过标准输入输出与外部程序交互的执行器
+type ProcessExecutor struct {
+	Path   string
+	name   string
+	fields []ExecutorField
+}
+
+// LoadProcessExecutors 注册目录中的可执行文件为扩展执行器, 获取配置项失败的程序将被忽略
+func LoadProcessExecutors(dir string) {
+	if dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		logger.Errorf("读取扩展执行器目录失败#%s#%s", dir, err)
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		executor, err := NewProcessExecutor(path)
+		if err != nil {
+			logger.Errorf("加载扩展执行器失败#%s#%s", path, err)
+			continue
+		}
+		if err = RegisterExecutor(executor.Executor()); err != nil {
+			logger.Errorf("注册扩展执行器失败#%s#%s", path, err)
+			continue
+		}
+		logger.Infof("注册扩展执行器#%s#%s", executor.name, path)
+	}
+}
+
+// NewProcessExecutor 调用外部程序获取执行器名称及配置项
+func NewProcessExecutor(path string) (*ProcessExecutor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), processCallTimeout)
+	defer cancel()
+	p := &ProcessExecutor{Path: path}
+	response, err := p.call(ctx, processRequest{Action: "schema"})
+	if err != nil {
+		return nil, err
+	}
+	p.name = strings.TrimSpace(response.Name)
+	if p.name == "" {
+		p.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	p.fields = response.Fields
+
+	return p, nil
+}
+
+// Executor 转换为注册表中的执行器
+func (p *ProcessExecutor) Executor() Executor {
+	return Executor{
+		Name:     p.name,
+		Protocol: models.TaskExecutor,
+		Fields:   p.fields,
+		Handler:  p,
+		Validate: p.validate,
+		Stop: func(taskLogId int64) {
+			stopServerSideTask(&processTasks, taskLogId)
+		},
+	}
+}
+
+func (p *ProcessExecutor) validate(taskModel models.Task, config map[string]interface{}) error {
+	if err := validateTaskCommand(taskModel, false); err != nil {
+		return err
+	}
+	if err := validateRunAs(taskModel); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), processCallTimeout)
+	defer cancel()
+	response, err := p.call(ctx, processRequest{Action: "validate", Command: taskModel.Command, Config: config})
+	if err != nil {
+		return err
+	}
+	if response.Error != "" {
+		return errors.New(response.Error)
+	}
+
+	return nil
+}
+
+func (p *ProcessExecutor) Run(taskModel models.Task, taskUniqueId int64) (string, error) {
+	executor, ok := lookupExecutor(taskModel)
+	if !ok {
+		return "", ErrExecutorNotFound
+	}
+	config, err := executor.config(taskModel.ExecutorConfig)
+	if err != nil {
+		return "", err
+	}
+	timeout := taskModel.Timeout
+	if timeout <= 0 || timeout > localExecMaxTimeout {
+		timeout = localExecMaxTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	processTasks.Store(taskUniqueId, cancel)
+	defer processTasks.Delete(taskUniqueId)
+
+	logger.Infof("扩展执行器任务开始执行#执行器-%s#任务ID-%d#taskLogId-%d", p.name, taskModel.Id, taskUniqueId)
+	response, err := p.call(ctx, processRequest{
+		Action:    "run",
+		TaskId:    taskModel.Id,
+		TaskLogId: taskUniqueId,
+		Command:   taskModel.Command,
+		Config:    config,
+		Timeout:   timeout,
+	})
+	// 超时或手动停止时使用与RPC任务相同的错误, 便于重试条件判断
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		err = rpcClient.ErrTimeout
+	case context.Canceled:
+		err = rpcClient.ErrCanceled
+	}
+	if err == nil && response.Error != "" {
+		err = errors.New(response.Error)
+	}
+	logger.Infof("扩展执行器任务执行完成#执行器-%s#任务ID-%d#taskLogId-%d#错误-%v", p.name, taskModel.Id, taskUniqueId, err)
+
+	return response.Output, err
+}
+
+// 启动外部程序, 写入请求并解析响应
+func (p *ProcessExecutor) call(ctx context.Context, request processRequest) (processResponse, error) {
+	response := processResponse{}
+	input, err := json.Marshal(request)
+	if err != nil {
+		return response, err
+	}
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = processWaitDelay
+	utils.KillProcessGroupOnCancel(cmd)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	if len(output) > 0 && json.Unmarshal(output, &response) == nil {
+		return response, nil
+	}
+	if runErr != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return response, runErr
+		}
+		return response, fmt.Errorf("%s\n%s", runErr, message)
+	}
+
+	return response, fmt.Errorf("执行器响应格式错误: %s", output)
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	"github.com/gocronx-team/gocron/internal/modules/setting"
+)
+
+type stubExecutorHandler struct {
+	output string
+}
+
+func (h stubExecutorHandler) Run(taskModel models.Task, taskUniqueId int64) (string, error) {
+	return h.output, nil
+}
+
+func registerTestExecutor(t *testing.T, executor Executor) {
+	t.Helper()
+	if err := RegisterExecutor(executor); err != nil {
+		t.Fatalf("register executor: %v", err)
+	}
+	t.Cleanup(func() {
+		executors.Lock()
+		delete(executors.byName, executor.Name)
+		executors.Unlock()
+	})
+}
+
+func TestCreateHandlerUsesRegistry(t *testing.T) {
+	if _, ok := createHandler(models.Task{Protocol: models.TaskSSH}).(*SSHHandler); !ok {
+		t.Fatal("expected builtin ssh handler")
+	}
+	if createHandler(models.Task{Protocol: models.TaskExecutor, Executor: "missing"}) != nil {
+		t.Fatal("expected nil handler for unknown executor")
+	}
+
+	registerTestExecutor(t, Executor{Name: "stub", Handler: stubExecutorHandler{"stub output"}})
+	handler := createHandler(models.Task{Protocol: models.TaskExecutor, Executor: "stub"})
+	if handler == nil {
+		t.Fatal("expected registered executor handler")
+	}
+	output, _ := handler.Run(models.Task{}, 1)
+	if output != "stub output" {
+		t.Fatalf("unexpected output: %s", output)
+	}
+	// 扩展执行器不能通过名称引用内置执行器
+	if createHandler(models.Task{Protocol: models.TaskExecutor, Executor: "http"}) != nil {
+		t.Fatal("builtin executor should not be referenced by name")
+	}
+}
+
+func TestRegisterExecutorRejectsInvalid(t *testing.T) {
+	cases := []Executor{
+		{Name: "", Handler: stubExecutorHandler{}},
+		{Name: "no-handler"},
+		{Name: "http", Handler: stubExecutorHandler{}},
+		{Name: "another-sql", Protocol: models.TaskSQL, Handler: stubExecutorHandler{}},
+		{Name: "bad-field", Handler: stubExecutorHandler{}, Fields: []ExecutorField{{Name: "x", Type: "array"}}},
+	}
+	for _, executor := range cases {
+		if err := RegisterExecutor(executor); err == nil {
+			t.Errorf("expected error when registering %+v", executor)
+		}
+	}
+}
+
+func TestValidateExecutorConfig(t *testing.T) {
+	var validated map[string]interface{}
+	registerTestExecutor(t, Executor{
+		Name:    "configurable",
+		Handler: stubExecutorHandler{},
+		Fields: []ExecutorField{
+			{Name: "uri", Type: ExecutorFieldString, Required: true},
+			{Name: "limit", Type: ExecutorFieldNumber, Default: float64(10)},
+			{Name: "dry_run", Type: ExecutorFieldBool},
+		},
+		Validate: func(taskModel models.Task, config map[string]interface{}) error {
+			validated = config
+			if taskModel.Command == "" {
+				return errors.New("command required")
+			}
+			return nil
+		},
+	})
+
+	task := models.Task{Protocol: models.TaskExecutor, Executor: "configurable", Command: "run"}
+	cases := map[string]string{
+		``:                             "不能为空",
+		`[1]`:                          "JSON对象",
+		`{"uri":"  "}`:                 "不能为空",
+		`{"uri":"db","limit":"ten"}`:   "类型必须是number",
+		`{"uri":"db","dry_run":"yes"}`: "类型必须是bool",
+	}
+	for config, message := range cases {
+		task.ExecutorConfig = config
+		err := ServiceTask.ValidateExecutor(task)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("config %s: expected error containing %q, got %v", config, message, err)
+		}
+	}
+
+	task.ExecutorConfig = `{"uri":"db"}`
+	if err := ServiceTask.ValidateExecutor(task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if validated["limit"] != float64(10) {
+		t.Fatalf("expected default value to be filled, got %v", validated)
+	}
+	task.Command = ""
+	if err := ServiceTask.ValidateExecutor(task); err == nil || err.Error() != "command required" {
+		t.Fatalf("expected validate hook error, got %v", err)
+	}
+
+	if err := ServiceTask.ValidateExecutor(models.Task{Protocol: models.TaskExecutor, Executor: "missing"}); !errors.Is(err, ErrExecutorNotFound) {
+		t.Fatalf("expected executor not found, got %v", err)
+	}
+	if err := ServiceTask.ValidateExecutor(models.Task{Protocol: models.TaskHTTP, Command: "https://example.com"}); err != nil {
+		t.Fatalf("unexpected error for builtin executor: %v", err)
+	}
+}
+
+func TestBuiltinExecutorValidate(t *testing.T) {
+	originalSetting := app.Setting
+	defer func() { app.Setting = originalSetting }()
+	app.Setting = &setting.Setting{}
+
+	host := models.TaskHostDetail{}
+	host.HostId = 1
+	cases := []struct {
+		task models.Task
+		key  string
+	}{
+		{models.Task{Protocol: models.TaskHTTP}, "command_required"},
+		{models.Task{Protocol: models.TaskHTTP, Command: "example.com"}, "invalid_url"},
+		{models.Task{Protocol: models.TaskHTTP, Command: "https://example.com", RunAsUser: "app"}, "run_as_protocol_unsupported"},
+		{models.Task{Protocol: models.TaskHTTP, Command: "https://example.com", HttpBodyRegex: "("}, "http_body_regex_invalid"},
+		{models.Task{Protocol: models.TaskHTTP, Command: "https://example.com", HttpAsync: 1}, "http_callback_url_required"},
+		{models.Task{Protocol: models.TaskRPC, Command: "date"}, "select_hostname"},
+		{models.Task{Protocol: models.TaskRPC, Command: "date", HostSelector: "role=="}, "host_selector_invalid"},
+		{models.Task{Protocol: models.TaskRPC, ScriptInterpreter: "bash", Hosts: []models.TaskHostDetail{host}}, "script_required"},
+		{models.Task{Protocol: models.TaskRPC, Command: "date", Env: "1A=b", Hosts: []models.TaskHostDetail{host}}, "task_env_invalid"},
+		{models.Task{Protocol: models.TaskLocal, Command: "date"}, "local_shell_disabled"},
+		{models.Task{Protocol: models.TaskSSH, ScriptInterpreter: "bash", Script: "date"}, "script_protocol_unsupported"},
+		{models.Task{Protocol: models.TaskExecutor, Executor: "strict", Command: "run", RunAsGroup: "app"}, "run_as_protocol_unsupported"},
+	}
+	registerTestExecutor(t, (&ProcessExecutor{name: "strict"}).Executor())
+	for _, item := range cases {
+		err := ServiceTask.ValidateExecutor(item.task)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Key != item.key {
+			t.Errorf("protocol %d: expected %s, got %v", item.task.Protocol, item.key, err)
+		}
+	}
+
+	err := ServiceTask.ValidateExecutor(models.Task{Protocol: models.TaskRPC, Command: "date", HostSelector: "role=web"})
+	if err != nil {
+		t.Fatalf("host selector without explicit hosts should be valid, got %v", err)
+	}
+}
+
+// 外部执行器示例, 使用shell解析请求中的action
+const processExecutorScript = `#!/bin/sh
+request=$(cat)
+case "$request" in
+*'"action":"schema"'*)
+	echo '{"name":"echo-executor","fields":[{"name":"greeting","label":"Greeting","type":"string","default":"hello"}]}'
+	;;
+*'"action":"validate"'*)
+	case "$request" in
+	*'"command":"bad"'*) echo '{"error":"bad command"}' ;;
+	*) echo '{}' ;;
+	esac
+	;;
+*'"command":"sleep"'*)
+	sleep 30
+	;;
+*'"command":"crash"'*)
+	echo 'boom' >&2
+	exit 3
+	;;
+*)
+	printf '{"output":%s}\n' "$(printf '%s' "$request" | sed 's/"/\\"/g; s/^/"/; s/$/"/')"
+	;;
+esac
+`
+
+func loadTestProcessExecutor(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("process executor test script requires sh")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "echo"), []byte(processExecutorScript), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not executable"), 0o644); err != nil {
+		t.Fatalf("write readme: %v", err)
+	}
+	LoadProcessExecutors(dir)
+	t.Cleanup(func() {
+		executors.Lock()
+		delete(executors.byName, "echo-executor")
+		executors.Unlock()
+	})
+	if len(Executors()) != 6 {
+		t.Fatalf("expected only the executable file to be registered, got %d executors", len(Executors()))
+	}
+}
+
+func TestProcessExecutor(t *testing.T) {
+	loadTestProcessExecutor(t)
+
+	task := models.Task{Id: 9, Protocol: models.TaskExecutor, Executor: "echo-executor", Command: "bad"}
+	if err := ServiceTask.ValidateExecutor(task); err == nil || err.Error() != "bad command" {
+		t.Fatalf("expected validation error from executable, got %v", err)
+	}
+
+	task.Command = "greet"
+	handler := createHandler(task)
+	output, err := handler.Run(task, 11)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{`"action":"run"`, `"task_id":9`, `"task_log_id":11`, `"greeting":"hello"`} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("request %s not found in output %s", expected, output)
+		}
+	}
+
+	task.Command = "crash"
+	_, err = handler.Run(task, 12)
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected exit error with stderr, got %v", err)
+	}
+}
+
+func TestProcessExecutorStop(t *testing.T) {
+	loadTestProcessExecutor(t)
+
+	task := models.Task{Protocol: models.TaskExecutor, Executor: "echo-executor", Command: "sleep"}
+	done := make(chan error, 1)
+	go func() {
+		_, err := createHandler(task).Run(task, 13)
+		done <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := processTasks.Load(int64(13)); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("process executor task did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := ServiceTask.StopExecutor(task, 13); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, rpcClient.ErrCanceled) {
+			t.Fatalf("expected canceled error, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("process executor task was not stopped")
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+// 调度预测, 计算时间窗口内所有任务的计划执行时间, 按主机、分钟聚合并标记热点
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gocronx-team/cron"
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+)
+
+const (
+	// 预测时间窗口最大跨度
+	ForecastMaxWindow = 7 * 24 * time.Hour
+	// 单次预测最多计算的执行次数, 防止秒级任务撑爆内存
+	ForecastMaxRuns = 200000
+	// 默认热点阈值: 同一主机同一分钟内超过该数量的任务视为热点
+	ForecastDefaultThreshold = 5
+
+	forecastMinuteFormat = "2006-01-02 15:04"
+	// HTTP任务在gocron服务端执行, 使用虚拟主机汇总
+	forecastServerHostName = "gocron-server"
+)
+
+type ForecastTask struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// 某台主机某一分钟内的计划执行
+type ForecastSlot struct {
+	Minute string         `json:"minute"`
+	Count  int            `json:"count"`
+	Tasks  []ForecastTask `json:"tasks"`
+	Hot    bool           `json:"hot"`
+}
+
+type ForecastHost struct {
+	HostId   int16          `json:"host_id"`
+	Name     string         `json:"name"`
+	Alias    string         `json:"alias"`
+	Total    int            `json:"total"`
+	MaxCount int            `json:"max_count"`
+	Slots    []ForecastSlot `json:"slots"`
+}
+
+type ForecastHotSpot struct {
+	HostId int16          `json:"host_id"`
+	Name   string         `json:"name"`
+	Alias  string         `json:"alias"`
+	Minute string         `json:"minute"`
+	Count  int            `json:"count"`
+	Tasks  []ForecastTask `json:"tasks"`
+}
+
+type ForecastReport struct {
+	Start     models.LocalTime  `json:"start"`
+	End       models.LocalTime  `json:"end"`
+	Threshold int               `json:"threshold"`
+	TotalRuns int               `json:"total_runs"`
+	Truncated bool              `json:"truncated"`
+	Hosts     []ForecastHost    `json:"hosts"`
+	HotSpots  []ForecastHotSpot `json:"hot_spots"`
+}
+
+// 日历无法解析时与调度一致, 不执行
+func calendarAllows(calendar *models.Calendar, mode models.TaskCalendarMode, t time.Time) bool {
+	if calendar == nil {
+		return true
+	}
+	allowed, err := calendar.Allow(mode, t)
+
+	return err == nil && allowed
+}
+
+// 计算任务在[start, end)内的计划执行时间, 合并所有cron表达式并考虑有效期、日历和剩余执行次数
+func forecastTaskRuns(taskModel models.Task, calendar *models.Calendar, start, end time.Time, limit int) ([]time.Time, error) {
+	if taskModel.MaxRunCount > 0 {
+		remaining := taskModel.MaxRunCount - taskModel.RunCount
+		if remaining < limit {
+			limit = remaining
+		}
+	}
+	runs := make([]time.Time, 0)
+	for _, spec := range taskModel.Specs() {
+		var schedule cron.Schedule
+		err := utils.PanicToError(func() {
+			schedule = cron.Parse(spec)
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Schedule.Next返回严格晚于给定时间的时刻, 往前挪1秒以包含start本身
+		next := schedule.Next(start.Add(-time.Second))
+		for !next.IsZero() && next.Before(end) && len(runs) < ForecastMaxRuns {
+			if taskModel.InValidPeriod(next) && calendarAllows(calendar, taskModel.CalendarMode, next) {
+				runs = append(runs, next)
+			}
+			next = schedule.Next(next)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Before(runs[j])
+	})
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	return runs, nil
+}
+
+// Forecast 生成调度预测报告, calendars为任务关联的日历, 按日历ID索引
+func (task Task) Forecast(tasks []models.Task, calendars map[int]models.Calendar, start, end time.Time, threshold int) ForecastReport {
+	if threshold <= 0 {
+		threshold = ForecastDefaultThreshold
+	}
+	report := ForecastReport{
+		Start:     models.LocalTime(start),
+		End:       models.LocalTime(end),
+		Threshold: threshold,
+		Hosts:     make([]ForecastHost, 0),
+		HotSpots:  make([]ForecastHotSpot, 0),
+	}
+
+	type hostKey struct {
+		id   int16
+		name string
+	}
+	hosts := make(map[hostKey]*ForecastHost)
+	slots := make(map[hostKey]map[string]*ForecastSlot)
+	remaining := ForecastMaxRuns
+	for _, item := range tasks {
+		if item.Level != models.TaskLevelParent || item.Status != models.Enabled {
+			continue
+		}
+		if remaining <= 0 {
+			report.Truncated = true
+			break
+		}
+		var calendar *models.Calendar
+		if item.CalendarId > 0 {
+			if value, ok := calendars[item.CalendarId]; ok {
+				calendar = &value
+			}
+		}
+		runs, err := forecastTaskRuns(item, calendar, start, end, remaining)
+		if err != nil {
+			continue
+		}
+		if len(runs) == remaining {
+			report.Truncated = true
+		}
+		remaining -= len(runs)
+		report.TotalRuns += len(runs)
+
+		if err = item.ResolveHosts(); err != nil {
+			logger.Warnf("按标签选择器匹配主机失败#任务ID-%d#%s", item.Id, err)
+		}
+		targets := item.Hosts
+		if !item.UseHosts() {
+			targets = []models.TaskHostDetail{{Name: forecastServerHostName, Alias: forecastServerHostName}}
+		}
+		for _, target := range targets {
+			key := hostKey{target.HostId, target.Name}
+			host, ok := hosts[key]
+			if !ok {
+				host = &ForecastHost{HostId: target.HostId, Name: target.Name, Alias: target.Alias, Slots: make([]ForecastSlot, 0)}
+				hosts[key] = host
+				slots[key] = make(map[string]*ForecastSlot)
+			}
+			for _, run := range runs {
+				minute := run.Format(forecastMinuteFormat)
+				slot, ok := slots[key][minute]
+				if !ok {
+					slot = &ForecastSlot{Minute: minute, Tasks: make([]ForecastTask, 0)}
+					slots[key][minute] = slot
+				}
+				slot.Count++
+				slot.Tasks = append(slot.Tasks, ForecastTask{Id: item.Id, Name: item.Name})
+				host.Total++
+			}
+		}
+	}
+
+	for key, host := range hosts {
+		for _, slot := range slots[key] {
+			if slot.Count > host.MaxCount {
+				host.MaxCount = slot.Count
+			}
+			if slot.Count > threshold {
+				slot.Hot = true
+				report.HotSpots = append(report.HotSpots, ForecastHotSpot{
+					HostId: host.HostId,
+					Name:   host.Name,
+					Alias:  host.Alias,
+					Minute: slot.Minute,
+					Count:  slot.Count,
+					Tasks:  slot.Tasks,
+				})
+			}
+			host.Slots = append(host.Slots, *slot)
+		}
+		sort.Slice(host.Slots, func(i, j int) bool {
+			return host.Slots[i].Minute < host.Slots[j].Minute
+		})
+		report.Hosts = append(report.Hosts, *host)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		if report.Hosts[i].MaxCount != report.Hosts[j].MaxCount {
+			return report.Hosts[i].MaxCount > report.Hosts[j].MaxCount
+		}
+		return report.Hosts[i].Name < report.Hosts[j].Name
+	})
+	// 热点按拥挤程度排序, 同等数量按时间先后
+	sort.Slice(report.HotSpots, func(i, j int) bool {
+		a, b := report.HotSpots[i], report.HotSpots[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Minute != b.Minute {
+			return a.Minute < b.Minute
+		}
+		return a.Name < b.Name
+	})
+
+	return report
+}
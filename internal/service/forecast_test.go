@@ -0,0 +1,143 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+)
+
+func TestForecastGroupsByHostAndMinute(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	end := start.Add(time.Hour)
+	web := models.TaskHostDetail{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Alias: "web"}
+	db := models.TaskHostDetail{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Alias: "db"}
+	tasks := []models.Task{
+		{Id: 1, Name: "a", Spec: "0 0 * * * *", Protocol: models.TaskRPC, Level: models.TaskLevelParent, Status: models.Enabled, Hosts: []models.TaskHostDetail{web, db}},
+		{Id: 2, Name: "b", Spec: "30 0 * * * *", Protocol: models.TaskRPC, Level: models.TaskLevelParent, Status: models.Enabled, Hosts: []models.TaskHostDetail{web}},
+		{Id: 3, Name: "c", Spec: "0 */30 * * * *", Protocol: models.TaskHTTP, Level: models.TaskLevelParent, Status: models.Enabled},
+		{Id: 4, Name: "disabled", Spec: "* * * * * *", Protocol: models.TaskRPC, Level: models.TaskLevelParent, Status: models.Disabled, Hosts: []models.TaskHostDetail{web}},
+		{Id: 5, Name: "child", Protocol: models.TaskRPC, Level: models.TaskLevelChild, Status: models.Enabled, Hosts: []models.TaskHostDetail{web}},
+	}
+
+	report := ServiceTask.Forecast(tasks, nil, start, end, 1)
+	// a: 00:00, b: 00:00:30, c: 00:00 + 00:30
+	if report.TotalRuns != 4 {
+		t.Fatalf("expected 4 runs, got %d", report.TotalRuns)
+	}
+	if len(report.Hosts) != 3 {
+		t.Fatalf("expected 3 hosts, got %d", len(report.Hosts))
+	}
+	first := report.Hosts[0]
+	if first.Alias != "web" || first.MaxCount != 2 || first.Total != 2 {
+		t.Fatalf("unexpected busiest host: %+v", first)
+	}
+	if len(first.Slots) != 1 || first.Slots[0].Minute != "2025-01-01 00:00" || !first.Slots[0].Hot {
+		t.Fatalf("unexpected slots: %+v", first.Slots)
+	}
+	if len(report.HotSpots) != 1 || report.HotSpots[0].HostId != 1 || len(report.HotSpots[0].Tasks) != 2 {
+		t.Fatalf("unexpected hot spots: %+v", report.HotSpots)
+	}
+
+	var server *ForecastHost
+	for i := range report.Hosts {
+		if report.Hosts[i].Name == forecastServerHostName {
+			server = &report.Hosts[i]
+		}
+	}
+	if server == nil || server.Total != 2 || len(server.Slots) != 2 {
+		t.Fatalf("expected http task grouped on server host, got %+v", server)
+	}
+}
+
+func TestForecastDefaultThresholdAndInvalidSpec(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	end := start.Add(time.Minute)
+	tasks := []models.Task{
+		{Id: 1, Name: "broken", Spec: "not a spec", Protocol: models.TaskHTTP, Level: models.TaskLevelParent, Status: models.Enabled},
+		{Id: 2, Name: "every-10s", Spec: "*/10 * * * * *", Protocol: models.TaskHTTP, Level: models.TaskLevelParent, Status: models.Enabled},
+	}
+
+	report := ServiceTask.Forecast(tasks, nil, start, end, 0)
+	if report.Threshold != ForecastDefaultThreshold {
+		t.Fatalf("expected default threshold, got %d", report.Threshold)
+	}
+	if report.TotalRuns != 6 {
+		t.Fatalf("expected 6 runs, got %d", report.TotalRuns)
+	}
+	if len(report.HotSpots) != 1 || report.HotSpots[0].Count != 6 {
+		t.Fatalf("expected one hot spot with 6 runs, got %+v", report.HotSpots)
+	}
+}
+
+func TestForecastMergesSpecsAndRespectsLimits(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	end := start.Add(24 * time.Hour)
+	validFrom := models.LocalTime(start.Add(2 * time.Hour))
+	tasks := []models.Task{
+		{
+			Id: 1, Name: "multi", Spec: "0 0 9 * * *;0 30 22 * * *", Protocol: models.TaskHTTP,
+			Level: models.TaskLevelParent, Status: models.Enabled,
+		},
+		{
+			Id: 2, Name: "window", Spec: "0 0 * * * *", Protocol: models.TaskHTTP,
+			Level: models.TaskLevelParent, Status: models.Enabled,
+			ValidFrom: &validFrom, MaxRunCount: 5, RunCount: 2,
+		},
+	}
+
+	runs, err := forecastTaskRuns(tasks[0], nil, start, end, ForecastMaxRuns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Hour() != 9 || runs[1].Hour() != 22 {
+		t.Fatalf("unexpected merged runs: %v", runs)
+	}
+
+	runs, err = forecastTaskRuns(tasks[1], nil, start, end, ForecastMaxRuns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 3 || runs[0].Hour() != 2 {
+		t.Fatalf("expected 3 runs starting at 02:00, got %v", runs)
+	}
+
+	report := ServiceTask.Forecast(tasks, nil, start, end, 0)
+	if report.TotalRuns != 5 {
+		t.Fatalf("expected 5 runs, got %d", report.TotalRuns)
+	}
+}
+
+func TestForecastRespectsCalendar(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 5)
+	calendars := map[int]models.Calendar{
+		1: {Id: 1, Name: "holiday", Dates: "2025-01-01 # 元旦\n2025-01-03~2025-01-04"},
+	}
+	tasks := []models.Task{
+		{
+			Id: 1, Name: "exclude", Spec: "0 0 9 * * *", Protocol: models.TaskHTTP,
+			Level: models.TaskLevelParent, Status: models.Enabled,
+			CalendarId: 1, CalendarMode: models.TaskCalendarModeExclude,
+		},
+		{
+			Id: 2, Name: "include", Spec: "0 0 10 * * *", Protocol: models.TaskHTTP,
+			Level: models.TaskLevelParent, Status: models.Enabled,
+			CalendarId: 1, CalendarMode: models.TaskCalendarModeInclude,
+		},
+	}
+
+	calendar := calendars[1]
+	runs, err := forecastTaskRuns(tasks[0], &calendar, start, end, ForecastMaxRuns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Day() != 2 || runs[1].Day() != 5 {
+		t.Fatalf("expected runs on 2nd and 5th, got %v", runs)
+	}
+
+	report := ServiceTask.Forecast(tasks, calendars, start, end, 0)
+	if report.TotalRuns != 5 {
+		t.Fatalf("expected 5 runs, got %d", report.TotalRuns)
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+// 定期查询各主机上gocron-node的版本、系统及资源使用情况, 记录最后在线时间及在线状态
+// 连续检测失败达到阈值后标记主机离线并发送通知, 主机恢复后发送恢复通知
+// 只通过SSH执行任务、未部署gocron-node的主机改为检测SSH端口是否可连接
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	"github.com/gocronx-team/gocron/internal/modules/notify"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+)
+
+const heartbeatSpec = "@every 30s"
+
+const sshCheckTimeout = 5 * time.Second
+
+var (
+	rpcInventoryFunc = rpcClient.Inventory
+	sshCheckFunc     = checkSSHPort
+)
+
+// 主机连续心跳检测失败的次数
+var heartbeatFailures = &failureCounter{count: make(map[int16]int)}
+
+type failureCounter struct {
+	sync.Mutex
+	count map[int16]int
+}
+
+func (c *failureCounter) add(hostId int16) int {
+	c.Lock()
+	defer c.Unlock()
+	c.count[hostId]++
+	return c.count[hostId]
+}
+
+func (c *failureCounter) reset(hostId int16) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.count, hostId)
+}
+
+// 初始化主机心跳检测任务
+func (task Task) initHeartbeatTask() {
+	serviceCron.AddFunc(heartbeatSpec, task.heartbeatHosts, "host-heartbeat")
+	logger.Info("主机心跳检测任务已添加")
+}
+
+// 恢复gocron重启前已离线的主机, 首次心跳检测前任务不调用离线主机
+func (task Task) restoreOfflineHosts() {
+	hostModel := new(models.Host)
+	hostModel.PageSize = -1
+	hosts, err := hostModel.List(models.CommonMap{})
+	if err != nil {
+		logger.Error("主机心跳检测#获取主机列表失败", err)
+		return
+	}
+	markOfflineHosts(hosts)
+}
+
+func markOfflineHosts(hosts []models.Host) {
+	for _, host := range hosts {
+		if host.Status == models.HostOffline {
+			hostHealthStatus.setOffline(host.Id, true)
+		}
+	}
+}
+
+// RemoveHostStatus 删除主机后清除心跳检测失败次数及健康状态
+func RemoveHostStatus(hostId int16) {
+	heartbeatFailures.reset(hostId)
+	hostHealthStatus.remove(hostId)
+}
+
+// 并发查询所有主机, 更新节点信息及在线状态
+func (task Task) heartbeatHosts() {
+	hostModel := new(models.Host)
+	hostModel.PageSize = -1
+	hosts, err := hostModel.List(models.CommonMap{})
+	if err != nil {
+		logger.Error("主机心跳检测#获取主机列表失败", err)
+		return
+	}
+	settingModel := new(models.Setting)
+	alert := settingModel.GetHostAlert()
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host models.Host) {
+			defer wg.Done()
+			data := checkHost(host, alert)
+			if len(data) == 0 {
+				return
+			}
+			if _, err := hostModel.Update(int(host.Id), data); err != nil {
+				logger.Errorf("主机心跳检测#更新主机失败#主机-%s#%s", host.Name, err)
+			}
+		}(host)
+	}
+	wg.Wait()
+}
+
+// 检测一台主机, 在线状态变化时发送通知, 返回需要更新的主机字段
+func checkHost(host models.Host, alert models.HostAlert) models.CommonMap {
+	data, err := heartbeatData(host)
+	if err == nil {
+		heartbeatFailures.reset(host.Id)
+		hostHealthStatus.setOffline(host.Id, false)
+		if host.Status == models.HostOffline {
+			logger.Infof("主机心跳检测#主机恢复在线#主机-%s:%d", host.Name, host.Port)
+			sendHostNotification(host, alert, "恢复在线", "心跳检测成功")
+		}
+		return data
+	}
+	// 未达到阈值前保持原有状态, gocron重启前已离线的主机仍为离线
+	failures := heartbeatFailures.add(host.Id)
+	if failures < alert.Threshold && host.Status != models.HostOffline {
+		logger.Warnf("主机心跳检测#检测失败#主机-%s:%d#连续失败-%d次#%s", host.Name, host.Port, failures, err)
+		return nil
+	}
+	hostHealthStatus.setOffline(host.Id, true)
+	if host.Status == models.HostOffline {
+		return nil
+	}
+	logger.Warnf("主机心跳检测#主机离线#主机-%s:%d#%s", host.Name, host.Port, err)
+	sendHostNotification(host, alert, "离线", fmt.Sprintf("连续%d次心跳检测失败: %s", failures, err))
+
+	return models.CommonMap{"status": models.HostOffline}
+}
+
+// 查询节点信息, 返回需要更新的主机字段, 旧版本节点只更新在线状态
+func heartbeatData(host models.Host) (models.CommonMap, error) {
+	info, err := rpcInventoryFunc(host.Name, host.Port)
+	if err != nil && !errors.Is(err, rpcClient.ErrUnsupported) {
+		if !sshOnlyHost(host) {
+			return nil, err
+		}
+		// 未部署节点的主机不更新最后在线时间, 之后部署节点仍可识别
+		if err = sshCheckFunc(host); err != nil {
+			return nil, err
+		}
+		return models.CommonMap{"status": models.HostOnline}, nil
+	}
+	data := models.CommonMap{"status": models.HostOnline, "last_seen": time.Now()}
+	if info == nil {
+		return data, nil
+	}
+	data["node_version"] = info.Version
+	data["os"] = info.Os
+	data["arch"] = info.Arch
+	data["cpu_count"] = info.CpuCount
+	data["load1"] = info.Load1
+	data["load5"] = info.Load5
+	data["load15"] = info.Load15
+	data["mem_total"] = info.MemTotal
+	data["mem_available"] = info.MemAvailable
+	data["disk_total"] = info.DiskTotal
+	data["disk_free"] = info.DiskFree
+	data["running_jobs"] = info.RunningJobs
+	data["uptime"] = info.Uptime
+
+	return data, nil
+}
+
+// 配置了SSH且从未收到过gocron-node心跳的主机
+func sshOnlyHost(host models.Host) bool {
+	return host.HasSSH() && host.NodeVersion == "" && host.LastSeen == nil
+}
+
+// 检测SSH端口是否可连接
+func checkSSHPort(host models.Host) error {
+	addr := net.JoinHostPort(host.Name, strconv.Itoa(host.SshPort))
+	conn, err := net.DialTimeout("tcp", addr, sshCheckTimeout)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// 通过任务通知的渠道发送主机离线及恢复通知
+func sendHostNotification(host models.Host, alert models.HostAlert, statusName, output string) {
+	if alert.NotifyType == 0 {
+		return
+	}
+	if alert.NotifyType != 3 && alert.NotifyReceiverId == "" {
+		return
+	}
+	notifyPushFunc(notify.Message{
+		"task_type":        alert.NotifyType,
+		"task_receiver_id": alert.NotifyReceiverId,
+		"name":             fmt.Sprintf("主机 %s-%s:%d", host.Alias, host.Name, host.Port),
+		"output":           output,
+		"status":           statusName,
+		"task_id":          "",
+		"remark":           host.Remark,
+		"exit_code":        "",
+		"stdout":           "",
+		"stderr":           "",
+	})
+}
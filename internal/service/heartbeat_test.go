@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+)
+
+func TestHeartbeatData(t *testing.T) {
+	originalInventory := rpcInventoryFunc
+	defer func() {
+		rpcInventoryFunc = originalInventory
+	}()
+	rpcInventoryFunc = func(ip string, port int) (*pb.NodeInfo, error) {
+		switch ip {
+		case "10.0.0.1":
+			return &pb.NodeInfo{Version: "v1.5.0", Os: "linux", CpuCount: 4, MemTotal: 1024, RunningJobs: 2}, nil
+		case "10.0.0.2":
+			return nil, rpcClient.ErrUnsupported
+		}
+		return nil, errors.New("connection refused")
+	}
+
+	data, err := heartbeatData(models.Host{Name: "10.0.0.1", Port: 5921})
+	if err != nil || data["status"] != models.HostOnline || data["last_seen"] == nil || data["node_version"] != "v1.5.0" ||
+		data["cpu_count"] != int32(4) || data["running_jobs"] != int32(2) {
+		t.Fatalf("unexpected heartbeat data: %+v, %v", data, err)
+	}
+	// 旧版本节点可连接但不支持上报节点信息
+	data, err = heartbeatData(models.Host{Name: "10.0.0.2", Port: 5921})
+	if err != nil || data["status"] != models.HostOnline || data["last_seen"] == nil || len(data) != 2 {
+		t.Fatalf("unexpected heartbeat data for old node: %+v, %v", data, err)
+	}
+	if _, err = heartbeatData(models.Host{Name: "10.0.0.3", Port: 5921}); err == nil {
+		t.Fatal("expected error for unreachable node")
+	}
+}
+
+func TestCheckHostOfflineAndRecovery(t *testing.T) {
+	originalInventory := rpcInventoryFunc
+	originalHealth := hostHealthStatus
+	originalFailures := heartbeatFailures
+	defer func() {
+		rpcInventoryFunc = originalInventory
+		hostHealthStatus = originalHealth
+		heartbeatFailures = originalFailures
+	}()
+	hostHealthStatus = newHostHealth()
+	heartbeatFailures = &failureCounter{count: make(map[int16]int)}
+	messages := stubNotifyPush(t)
+
+	reachable := false
+	rpcInventoryFunc = func(ip string, port int) (*pb.NodeInfo, error) {
+		if reachable {
+			return &pb.NodeInfo{Version: "v1.5.0"}, nil
+		}
+		return nil, rpcClient.ErrUnavailable
+	}
+	host := models.Host{Id: 1, Name: "10.0.0.1", Port: 5921, Alias: "web", Status: models.HostOnline}
+	alert := models.HostAlert{Threshold: 3, NotifyType: 3}
+
+	// 未达到阈值前不标记离线
+	for i := 0; i < 2; i++ {
+		if data := checkHost(host, alert); data != nil || hostHealthStatus.isOffline(1) {
+			t.Fatalf("host should stay online before threshold, got %+v", data)
+		}
+	}
+	data := checkHost(host, alert)
+	if data["status"] != models.HostOffline || !hostHealthStatus.isOffline(1) {
+		t.Fatalf("expected host offline after threshold, got %+v", data)
+	}
+	if len(*messages) != 1 || (*messages)[0]["status"] != "离线" || !strings.Contains((*messages)[0]["output"].(string), "连续3次") {
+		t.Fatalf("expected offline notification, got %+v", *messages)
+	}
+
+	// 离线期间不重复通知
+	host.Status = models.HostOffline
+	if data = checkHost(host, alert); data != nil || len(*messages) != 1 {
+		t.Fatalf("unexpected repeated offline notification: %+v, %+v", data, *messages)
+	}
+
+	reachable = true
+	data = checkHost(host, alert)
+	if data["status"] != models.HostOnline || hostHealthStatus.isOffline(1) {
+		t.Fatalf("expected host recovered, got %+v", data)
+	}
+	if len(*messages) != 2 || (*messages)[1]["status"] != "恢复在线" {
+		t.Fatalf("expected recovery notification, got %+v", *messages)
+	}
+}
+
+func TestCheckHostRestoresOfflineStatus(t *testing.T) {
+	originalInventory := rpcInventoryFunc
+	originalHealth := hostHealthStatus
+	originalFailures := heartbeatFailures
+	defer func() {
+		rpcInventoryFunc = originalInventory
+		hostHealthStatus = originalHealth
+		heartbeatFailures = originalFailures
+	}()
+	hostHealthStatus = newHostHealth()
+	heartbeatFailures = &failureCounter{count: make(map[int16]int)}
+	messages := stubNotifyPush(t)
+	rpcInventoryFunc = func(ip string, port int) (*pb.NodeInfo, error) {
+		return nil, rpcClient.ErrUnavailable
+	}
+
+	// gocron重启前已离线的主机第一次检测失败即视为离线, 不再发送通知
+	host := models.Host{Id: 2, Name: "10.0.0.2", Port: 5921, Status: models.HostOffline}
+	if data := checkHost(host, models.HostAlert{Threshold: 3, NotifyType: 3}); data != nil || !hostHealthStatus.isOffline(2) {
+		t.Fatalf("expected offline host restored, got %+v", data)
+	}
+	if len(*messages) != 0 {
+		t.Fatalf("unexpected notification: %+v", *messages)
+	}
+}
+
+func TestHostStatusRestoredAndRemoved(t *testing.T) {
+	originalHealth := hostHealthStatus
+	originalFailures := heartbeatFailures
+	defer func() {
+		hostHealthStatus = originalHealth
+		heartbeatFailures = originalFailures
+	}()
+	hostHealthStatus = newHostHealth()
+	heartbeatFailures = &failureCounter{count: make(map[int16]int)}
+
+	// gocron启动时按数据库中的状态恢复离线主机
+	markOfflineHosts([]models.Host{{Id: 1, Status: models.HostOnline}, {Id: 2, Status: models.HostOffline}})
+	if hostHealthStatus.isOffline(1) || !hostHealthStatus.isOffline(2) {
+		t.Fatal("expected only host 2 to be offline")
+	}
+
+	heartbeatFailures.add(2)
+	hostHealthStatus.markUnhealthy(2)
+	RemoveHostStatus(2)
+	if hostHealthStatus.isOffline(2) || !hostHealthStatus.isHealthy(2) {
+		t.Fatal("expected removed host status to be cleared")
+	}
+	if _, ok := heartbeatFailures.count[2]; ok {
+		t.Fatal("expected removed host failures to be cleared")
+	}
+}
+
+func TestCheckHostSSHOnly(t *testing.T) {
+	originalInventory := rpcInventoryFunc
+	originalHealth := hostHealthStatus
+	originalFailures := heartbeatFailures
+	defer func() {
+		rpcInventoryFunc = originalInventory
+		hostHealthStatus = originalHealth
+		heartbeatFailures = originalFailures
+	}()
+	hostHealthStatus = newHostHealth()
+	heartbeatFailures = &failureCounter{count: make(map[int16]int)}
+	messages := stubNotifyPush(t)
+	rpcInventoryFunc = func(ip string, port int) (*pb.NodeInfo, error) {
+		return nil, rpcClient.ErrUnavailable
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// 未部署gocron-node的主机, SSH端口可连接时保持在线
+	host := models.Host{Id: 3, Name: "127.0.0.1", Port: 5921, SshPort: listener.Addr().(*net.TCPAddr).Port,
+		SshUser: "root", SshAuthType: models.HostSSHAuthPassword, Status: models.HostOnline}
+	alert := models.HostAlert{Threshold: 3, NotifyType: 3}
+	for i := 0; i < 5; i++ {
+		data := checkHost(host, alert)
+		if data["status"] != models.HostOnline || data["last_seen"] != nil || hostHealthStatus.isOffline(3) {
+			t.Fatalf("ssh only host should stay online, got %+v", data)
+		}
+	}
+	if len(*messages) != 0 {
+		t.Fatalf("unexpected notification: %+v", *messages)
+	}
+
+	// 曾收到过节点心跳的主机按节点检测
+	host.NodeVersion = "v1.5.0"
+	if data := checkHost(host, alert); data != nil {
+		t.Fatalf("expected node failure counted, got %+v", data)
+	}
+}
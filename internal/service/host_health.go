@@ -0,0 +1,96 @@
+package service
+
+// 主机临时健康状态, 单主机分发的任务在节点不可达时切换主机, 并在一段时间内避开该主机
+// 心跳检测标记为离线的主机直到恢复前都不健康, 任务不再调用离线主机
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+)
+
+// 主机不可达后被标记为不健康的时长
+const hostUnhealthyDuration = 2 * time.Minute
+
+// ErrHostOffline 心跳检测标记为离线的主机不再调用, 与节点不可达一样可切换主机或重试
+var ErrHostOffline = fmt.Errorf("%w, 主机已离线", rpcClient.ErrUnavailable)
+
+type hostHealth struct {
+	sync.RWMutex
+	unhealthyUntil map[int16]time.Time
+	offline        map[int16]bool
+}
+
+var hostHealthStatus = newHostHealth()
+
+func newHostHealth() *hostHealth {
+	return &hostHealth{unhealthyUntil: make(map[int16]time.Time), offline: make(map[int16]bool)}
+}
+
+// 标记主机暂时不健康
+func (h *hostHealth) markUnhealthy(hostId int16) {
+	h.Lock()
+	defer h.Unlock()
+	h.unhealthyUntil[hostId] = time.Now().Add(hostUnhealthyDuration)
+}
+
+// 主机调用成功, 清除不健康标记
+func (h *hostHealth) markHealthy(hostId int16) {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.unhealthyUntil, hostId)
+}
+
+// 心跳检测更新主机的离线状态
+func (h *hostHealth) setOffline(hostId int16, offline bool) {
+	h.Lock()
+	defer h.Unlock()
+	if offline {
+		h.offline[hostId] = true
+	} else {
+		delete(h.offline, hostId)
+	}
+}
+
+// 删除主机时清除健康状态
+func (h *hostHealth) remove(hostId int16) {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.unhealthyUntil, hostId)
+	delete(h.offline, hostId)
+}
+
+func (h *hostHealth) isOffline(hostId int16) bool {
+	h.RLock()
+	defer h.RUnlock()
+	return h.offline[hostId]
+}
+
+func (h *hostHealth) isHealthy(hostId int16) bool {
+	h.RLock()
+	defer h.RUnlock()
+	if h.offline[hostId] {
+		return false
+	}
+	until, ok := h.unhealthyUntil[hostId]
+
+	return !ok || time.Now().After(until)
+}
+
+// 按健康状态排序主机, 健康主机在前, 同一状态内保持原有顺序
+func (h *hostHealth) orderHosts(hosts []models.TaskHostDetail) []models.TaskHostDetail {
+	healthy := make([]models.TaskHostDetail, 0, len(hosts))
+	unhealthy := make([]models.TaskHostDetail, 0)
+	for _, host := range hosts {
+		if h.isHealthy(host.HostId) {
+			healthy = append(healthy, host)
+		} else {
+			unhealthy = append(unhealthy, host)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+	rpcServer "github.com/gocronx-team/gocron/internal/modules/rpc/server"
+)
+
+func TestRPCHandlerSingleHostFailover(t *testing.T) {
+	originalExec := rpcExecFunc
+	originalHealth := hostHealthStatus
+	defer func() {
+		rpcExecFunc = originalExec
+		hostHealthStatus = originalHealth
+	}()
+	hostHealthStatus = newHostHealth()
+
+	var called []string
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+		called = append(called, ip)
+		if ip == "10.0.0.1" {
+			return nil, rpcClient.ErrUnavailable
+		}
+		return &pb.TaskResponse{Output: "done"}, nil
+	}
+	task := models.Task{
+		Id:           1,
+		Command:      "echo done",
+		DispatchMode: models.TaskDispatchSingle,
+		Hosts: []models.TaskHostDetail{
+			{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921, Alias: "a"},
+			{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Port: 5921, Alias: "b"},
+			{TaskHost: models.TaskHost{HostId: 3}, Name: "10.0.0.3", Port: 5921, Alias: "c"},
+		},
+	}
+
+	handler := &RPCHandler{}
+	output, err := handler.Run(task, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(called, ",") != "10.0.0.1,10.0.0.2" {
+		t.Fatalf("unexpected call order: %v", called)
+	}
+	if !strings.Contains(output, "切换主机") || !strings.HasSuffix(output, "done") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+	if hostHealthStatus.isHealthy(1) || !hostHealthStatus.isHealthy(2) {
+		t.Fatal("expected host 1 marked unhealthy")
+	}
+
+	// 后续执行跳过不健康主机
+	called = nil
+	if _, err = handler.Run(task, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(called, ",") != "10.0.0.2" {
+		t.Fatalf("expected unhealthy host skipped, got %v", called)
+	}
+}
+
+func TestRPCHandlerSingleHostNoFailoverAfterStart(t *testing.T) {
+	originalExec := rpcExecFunc
+	originalHealth := hostHealthStatus
+	defer func() {
+		rpcExecFunc = originalExec
+		hostHealthStatus = originalHealth
+	}()
+	hostHealthStatus = newHostHealth()
+
+	var called []string
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+		called = append(called, ip)
+		return nil, rpcClient.ErrConnectionLost
+	}
+	task := models.Task{
+		Id:           1,
+		Command:      "echo done",
+		DispatchMode: models.TaskDispatchSingle,
+		Hosts: []models.TaskHostDetail{
+			{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921, Alias: "a"},
+			{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Port: 5921, Alias: "b"},
+		},
+	}
+	_, err := (&RPCHandler{}).Run(task, 1)
+	if !errors.Is(err, rpcClient.ErrConnectionLost) {
+		t.Fatalf("expected connection lost, got %v", err)
+	}
+	if strings.Join(called, ",") != "10.0.0.1" {
+		t.Fatalf("started task should not run on another host, got %v", called)
+	}
+}
+
+func TestRPCHandlerSingleHostAllUnavailable(t *testing.T) {
+	originalExec := rpcExecFunc
+	originalHealth := hostHealthStatus
+	defer func() {
+		rpcExecFunc = originalExec
+		hostHealthStatus = originalHealth
+	}()
+	hostHealthStatus = newHostHealth()
+
+	calls := 0
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+		calls++
+		return nil, rpcClient.ErrUnavailable
+	}
+	task := models.Task{
+		Id:           2,
+		DispatchMode: models.TaskDispatchSingle,
+		Hosts: []models.TaskHostDetail{
+			{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921},
+			{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Port: 5921},
+		},
+	}
+	_, err := (&RPCHandler{}).Run(task, 1)
+	if !errors.Is(err, rpcClient.ErrUnavailable) || calls != 2 {
+		t.Fatalf("expected unavailable after trying all hosts, err=%v calls=%d", err, calls)
+	}
+}
+
+func TestRPCHandlerShipsScript(t *testing.T) {
+	originalExec := rpcExecFunc
+	defer func() {
+		rpcExecFunc = originalExec
+	}()
+
+	var request *pb.TaskRequest
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+		request = taskReq
+		return &pb.TaskResponse{Output: "ok"}, nil
+	}
+	task := models.Task{
+		Id:                1,
+		Command:           "--dry-run",
+		ScriptInterpreter: "python3",
+		Script:            "import sys\nprint(sys.argv)\n",
+		Hosts:             []models.TaskHostDetail{{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921}},
+	}
+	if _, err := (&RPCHandler{}).Run(task, 9); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Script != task.Script || request.Interpreter != "python3" || request.Args != "--dry-run" || request.Id != 9 {
+		t.Fatalf("unexpected script request: %+v", request)
+	}
+	// 旧版本节点忽略脚本字段时执行的命令必须返回错误
+	if request.Command != scriptUnsupportedCommand {
+		t.Fatalf("unexpected fallback command: %s", request.Command)
+	}
+}
+
+func TestRPCHandlerShipsExecOptions(t *testing.T) {
+	originalExec := rpcExecFunc
+	defer func() {
+		rpcExecFunc = originalExec
+	}()
+
+	var request *pb.TaskRequest
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+		request = taskReq
+		return &pb.TaskResponse{Output: "ok"}, nil
+	}
+	task := models.Task{
+		Id:          1,
+		Command:     "env",
+		Env:         "# comment\nAPP_ENV=prod\n\nGREETING=hello world",
+		WorkDir:     "/srv/app",
+		RunAsUser:   "deploy",
+		RunAsGroup:  "www",
+		OutputLimit: 64,
+		SaveOutput:  1,
+		Hosts:       []models.TaskHostDetail{{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921}},
+	}
+	if _, err := (&RPCHandler{}).Run(task, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(request.Env, ",") != "APP_ENV=prod,GREETING=hello world" {
+		t.Fatalf("unexpected env: %v", request.Env)
+	}
+	if request.WorkDir != "/srv/app" || request.User != "deploy" || request.Group != "www" || request.OutputLimit != 64*1024 || !request.SaveOutput {
+		t.Fatalf("unexpected exec options: %+v", request)
+	}
+	// 旧版本节点忽略执行选项及exec字段, 只能执行返回错误的命令
+	if request.Command != execOptionsUnsupportedCommand || request.ExecCommand != "env" {
+		t.Fatalf("expected command to be hidden from old nodes: %+v", request)
+	}
+
+	task.ScriptInterpreter = "bash"
+	task.Script = "id -un"
+	if _, err := (&RPCHandler{}).Run(task, 11); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Script != "" || request.ExecScript != "id -un" || request.Command != execOptionsUnsupportedCommand {
+		t.Fatalf("expected script to be hidden from old nodes: %+v", request)
+	}
+}
+
+func TestExecOptionsNotRunByOldNode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command requires bash")
+	}
+	originalExec := rpcExecFunc
+	defer func() {
+		rpcExecFunc = originalExec
+	}()
+
+	var request *pb.TaskRequest
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+		request = taskReq
+		return &pb.TaskResponse{Output: "ok"}, nil
+	}
+	workDir := t.TempDir()
+	task := models.Task{
+		Id:        1,
+		Command:   "echo executed in $(pwd)",
+		WorkDir:   workDir,
+		RunAsUser: "deploy",
+		Hosts:     []models.TaskHostDetail{{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921}},
+	}
+	if _, err := (&RPCHandler{}).Run(task, 12); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 不支持执行选项的节点只识别以下字段, 不能以节点自身的用户执行实际的命令
+	oldRequest := &pb.TaskRequest{
+		Id:          request.Id,
+		Command:     request.Command,
+		Timeout:     request.Timeout,
+		Script:      request.Script,
+		Interpreter: request.Interpreter,
+		Args:        request.Args,
+	}
+	resp, err := rpcServer.Server{}.Run(context.Background(), oldRequest)
+	if err != nil || resp.Error == "" || strings.Contains(resp.Output, "executed") {
+		t.Fatalf("old node should refuse to run the command, got %+v, %v", resp, err)
+	}
+
+	// 新版本节点执行实际的命令, 执行用户由节点的允许列表控制
+	request.User = ""
+	resp, err = rpcServer.Server{}.Run(context.Background(), request)
+	if err != nil || resp.Error != "" || !strings.Contains(resp.Output, "executed in "+workDir) {
+		t.Fatalf("new node should run the command, got %+v, %v", resp, err)
+	}
+}
+
+func TestRPCHandlerFailsFastOnOfflineHost(t *testing.T) {
+	originalExec := rpcExecFunc
+	originalHealth := hostHealthStatus
+	defer func() {
+		rpcExecFunc = originalExec
+		hostHealthStatus = originalHealth
+	}()
+	hostHealthStatus = newHostHealth()
+	hostHealthStatus.setOffline(1, true)
+
+	var called []string
+	rpcExecFunc = func(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+		called = append(called, ip)
+		return &pb.TaskResponse{Output: "done"}, nil
+	}
+	hosts := []models.TaskHostDetail{
+		{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921, Alias: "a"},
+		{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Port: 5921, Alias: "b"},
+	}
+	output, err := (&RPCHandler{}).Run(models.Task{Id: 1, Command: "echo done", Hosts: hosts}, 1)
+	if !errors.Is(err, ErrHostOffline) || !errors.Is(err, rpcClient.ErrUnavailable) || !strings.Contains(output, "主机已离线") {
+		t.Fatalf("expected offline host to fail fast, got %q, %v", output, err)
+	}
+	if strings.Join(called, ",") != "10.0.0.2" {
+		t.Fatalf("offline host must not be called, got %v", called)
+	}
+
+	// 单主机分发时离线主机排在最后
+	called = nil
+	output, err = (&RPCHandler{}).Run(models.Task{Id: 1, Command: "echo done", DispatchMode: models.TaskDispatchSingle, Hosts: hosts}, 2)
+	if err != nil || strings.Join(called, ",") != "10.0.0.2" || !strings.HasSuffix(output, "done") {
+		t.Fatalf("expected online host to run task, got %q, %v, %v", output, err, called)
+	}
+}
+
+func TestStopOnlyOrphansWhenNoHostHasJob(t *testing.T) {
+	originalStop := rpcStopFunc
+	originalOrphan := orphanTaskLogFunc
+	defer func() {
+		rpcStopFunc = originalStop
+		orphanTaskLogFunc = originalOrphan
+	}()
+
+	var orphaned []int64
+	orphanTaskLogFunc = func(id int64) { orphaned = append(orphaned, id) }
+	var stopped []string
+	rpcStopFunc = func(ip string, port int, id int64, signal string) bool {
+		stopped = append(stopped, ip)
+		return ip == "10.0.0.2"
+	}
+	hosts := []models.TaskHostDetail{
+		{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921},
+		{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Port: 5921},
+	}
+
+	// 单主机执行时只有一台主机在运行任务, 其余主机未找到任务不影响日志状态
+	Task{}.Stop(hosts, 81, "")
+	if strings.Join(stopped, ",") != "10.0.0.1,10.0.0.2" || len(orphaned) != 0 {
+		t.Fatalf("unexpected stop: stopped %v, orphaned %v", stopped, orphaned)
+	}
+
+	stopped = nil
+	Task{}.Stop(hosts[:1], 82, "")
+	if len(orphaned) != 1 || orphaned[0] != 82 {
+		t.Fatalf("expected log marked orphaned, got %v", orphaned)
+	}
+}
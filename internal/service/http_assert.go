@@ -0,0 +1,184 @@
+package service
+
+// HTTP任务成功判定: 状态码范围、响应体包含/正则/JSON路径、响应头检查
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/httpclient"
+)
+
+// HTTP响应断言失败
+type HTTPAssertionError struct {
+	Message string
+}
+
+func (e *HTTPAssertionError) Error() string {
+	return "断言失败: " + e.Message
+}
+
+// 校验HTTP响应是否满足任务配置的成功条件
+func checkHTTPResponse(taskModel models.Task, resp httpclient.ResponseWrapper) error {
+	if resp.StatusCode == 0 {
+		return &HTTPStatusError{StatusCode: resp.StatusCode, Err: resp.Err}
+	}
+	if taskModel.HttpSuccessCodes == "" {
+		// 返回状态码非200，均为失败
+		if resp.StatusCode != http.StatusOK {
+			return &HTTPStatusError{StatusCode: resp.StatusCode}
+		}
+	} else {
+		ranges, err := models.ParseCodeRanges(taskModel.HttpSuccessCodes)
+		if err != nil {
+			return &HTTPAssertionError{Message: fmt.Sprintf("成功状态码配置错误 %s", err)}
+		}
+		if !ranges.Contains(resp.StatusCode) {
+			return &HTTPStatusError{StatusCode: resp.StatusCode}
+		}
+	}
+
+	if taskModel.HttpBodyContains != "" && !strings.Contains(resp.Body, taskModel.HttpBodyContains) {
+		return &HTTPAssertionError{Message: fmt.Sprintf("响应内容不包含 %q", taskModel.HttpBodyContains)}
+	}
+	if taskModel.HttpBodyRegex != "" {
+		pattern, err := regexp.Compile(taskModel.HttpBodyRegex)
+		if err != nil {
+			return &HTTPAssertionError{Message: fmt.Sprintf("正则表达式错误 %s", err)}
+		}
+		if !pattern.MatchString(resp.Body) {
+			return &HTTPAssertionError{Message: fmt.Sprintf("响应内容不匹配正则 %s", taskModel.HttpBodyRegex)}
+		}
+	}
+	if taskModel.HttpJsonPath != "" {
+		value, err := jsonPathValue(resp.Body, taskModel.HttpJsonPath)
+		if err != nil {
+			return &HTTPAssertionError{Message: fmt.Sprintf("JSON路径 %s %s", taskModel.HttpJsonPath, err)}
+		}
+		if value != taskModel.HttpJsonValue {
+			return &HTTPAssertionError{Message: fmt.Sprintf("JSON路径 %s 的值为 %s, 期望 %s",
+				taskModel.HttpJsonPath, value, taskModel.HttpJsonValue)}
+		}
+	}
+	checks, err := models.ParseHTTPParams(taskModel.HttpHeaderChecks)
+	if err != nil {
+		return &HTTPAssertionError{Message: fmt.Sprintf("响应头检查配置错误 %s", err)}
+	}
+	for _, check := range checks {
+		values, ok := resp.Header[http.CanonicalHeaderKey(check.Name)]
+		if !ok {
+			return &HTTPAssertionError{Message: fmt.Sprintf("缺少响应头 %s", check.Name)}
+		}
+		// 未设置期望值时只检查响应头是否存在
+		if check.Value != "" && !containsString(values, check.Value) {
+			return &HTTPAssertionError{Message: fmt.Sprintf("响应头 %s 的值为 %s, 期望 %s",
+				check.Name, strings.Join(values, ","), check.Value)}
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 按路径读取JSON中的值, 路径形如 $.data.items[0].status, 返回值的字符串形式
+func jsonPathValue(body string, path string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return "", fmt.Errorf("响应内容不是有效的JSON")
+	}
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+	current := data
+	for _, segment := range segments {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[segment]
+			if !ok {
+				return "", fmt.Errorf("不存在")
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", fmt.Errorf("不存在")
+			}
+			current = node[index]
+		default:
+			return "", fmt.Errorf("不存在")
+		}
+	}
+
+	switch value := current.(type) {
+	case string:
+		return value, nil
+	case nil:
+		return "null", nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	default:
+		buf := new(bytes.Buffer)
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(buf.String()), nil
+	}
+}
+
+// 拆分JSON路径, 数组下标作为单独的段
+func parseJSONPath(path string) ([]string, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.TrimPrefix(path, ".")
+	segments := make([]string, 0)
+	if path == "" {
+		return segments, nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		index := strings.Index(part, "[")
+		if index >= 0 {
+			name = part[:index]
+		}
+		if name != "" {
+			segments = append(segments, name)
+		} else if index < 0 {
+			return nil, fmt.Errorf("路径格式错误")
+		}
+		for index >= 0 {
+			end := strings.Index(part[index:], "]")
+			if end < 0 {
+				return nil, fmt.Errorf("路径格式错误")
+			}
+			segments = append(segments, part[index+1:index+end])
+			part = part[index+end+1:]
+			index = strings.Index(part, "[")
+			if index != 0 && part != "" {
+				return nil, fmt.Errorf("路径格式错误")
+			}
+		}
+	}
+
+	return segments, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/httpclient"
+)
+
+func TestCheckHTTPResponse(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Version", "2")
+	body := `{"code":0,"data":{"items":[{"status":"ok","count":3}],"done":true}}`
+	resp := httpclient.ResponseWrapper{StatusCode: http.StatusNoContent, Body: body, Header: header}
+
+	cases := []struct {
+		name    string
+		task    models.Task
+		message string
+	}{
+		{"legacy non-200", models.Task{}, "HTTP状态码非200-->204"},
+		{"status range", models.Task{HttpSuccessCodes: "2xx"}, ""},
+		{"status mismatch", models.Task{HttpSuccessCodes: "200"}, "HTTP状态码非200-->204"},
+		{"contains", models.Task{HttpSuccessCodes: "2xx", HttpBodyContains: `"code":0`}, ""},
+		{"contains mismatch", models.Task{HttpSuccessCodes: "2xx", HttpBodyContains: "error"}, `响应内容不包含 "error"`},
+		{"regex", models.Task{HttpSuccessCodes: "2xx", HttpBodyRegex: `"count":\d+`}, ""},
+		{"regex mismatch", models.Task{HttpSuccessCodes: "2xx", HttpBodyRegex: `^ok$`}, "响应内容不匹配正则"},
+		{"json path", models.Task{HttpSuccessCodes: "2xx", HttpJsonPath: "$.data.items[0].status", HttpJsonValue: "ok"}, ""},
+		{"json number", models.Task{HttpSuccessCodes: "2xx", HttpJsonPath: "data.items[0].count", HttpJsonValue: "3"}, ""},
+		{"json bool", models.Task{HttpSuccessCodes: "2xx", HttpJsonPath: "data.done", HttpJsonValue: "true"}, ""},
+		{"json mismatch", models.Task{HttpSuccessCodes: "2xx", HttpJsonPath: "code", HttpJsonValue: "1"}, "JSON路径 code 的值为 0, 期望 1"},
+		{"json missing", models.Task{HttpSuccessCodes: "2xx", HttpJsonPath: "data.items[5]", HttpJsonValue: "1"}, "不存在"},
+		{"header", models.Task{HttpSuccessCodes: "2xx", HttpHeaderChecks: `[{"name":"x-version","value":"2"},{"name":"Content-Type"}]`}, ""},
+		{"header mismatch", models.Task{HttpSuccessCodes: "2xx", HttpHeaderChecks: `[{"name":"X-Version","value":"3"}]`}, "响应头 X-Version 的值为 2, 期望 3"},
+		{"header missing", models.Task{HttpSuccessCodes: "2xx", HttpHeaderChecks: `[{"name":"X-Missing"}]`}, "缺少响应头 X-Missing"},
+	}
+	for _, c := range cases {
+		err := checkHTTPResponse(c.task, resp)
+		if c.message == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", c.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.message) {
+			t.Fatalf("%s: expected error containing %q, got %v", c.name, c.message, err)
+		}
+	}
+}
+
+func TestHTTPHandlerShowsFailedAssertion(t *testing.T) {
+	original := httpGetFunc
+	defer func() { httpGetFunc = original }()
+	httpGetFunc = func(url string, timeout int) httpclient.ResponseWrapper {
+		return httpclient.ResponseWrapper{StatusCode: http.StatusOK, Body: `{"status":"fail"}`, Header: http.Header{}}
+	}
+
+	task := models.Task{
+		Command: "http://example.com", HttpMethod: models.TaskHTTPMethodGet,
+		HttpJsonPath: "status", HttpJsonValue: "ok",
+	}
+	result, err := (&HTTPHandler{}).Run(task, 1)
+	var assertionErr *HTTPAssertionError
+	if !errors.As(err, &assertionErr) {
+		t.Fatalf("expected assertion error, got %v", err)
+	}
+	if !strings.HasPrefix(result, "断言失败: JSON路径 status 的值为 fail, 期望 ok\n") {
+		t.Fatalf("unexpected result %s", result)
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	segments, err := parseJSONPath("$.a.b[1][2].c")
+	if err != nil || strings.Join(segments, "/") != "a/b/1/2/c" {
+		t.Fatalf("unexpected segments %v, %v", segments, err)
+	}
+	for _, path := range []string{"a..b", "a[1", "a[1]x"} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Fatalf("expected error for %s", path)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+// 异步HTTP任务: 请求时附带回调地址和令牌, 任务日志保持运行中, 由目标服务回调结束
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+)
+
+// 发送给目标服务的请求头
+const (
+	AsyncCallbackURLHeader   = "X-Gocron-Callback-Url"
+	AsyncCallbackTokenHeader = "X-Gocron-Callback-Token"
+	AsyncTaskLogIdHeader     = "X-Gocron-Task-Log-Id"
+)
+
+var (
+	ErrAsyncCallbackTokenInvalid = errors.New("回调令牌无效")
+	ErrAsyncTaskNotRunning       = errors.New("任务日志不存在或已结束")
+)
+
+var (
+	// 保存回调令牌摘要到任务日志
+	saveCallbackTokenFunc = func(taskLogId int64, tokenHash string) error {
+		_, err := new(models.TaskLog).Update(taskLogId, models.CommonMap{"callback_token": tokenHash})
+		return err
+	}
+	asyncTimeAfter = time.After
+
+	// 等待回调的异步任务, 任务日志ID作为Key
+	asyncWaiters sync.Map
+)
+
+type asyncWaiter struct {
+	mu     sync.Mutex
+	done   bool
+	result chan TaskResult
+}
+
+func newAsyncWaiter() *asyncWaiter {
+	return &asyncWaiter{result: make(chan TaskResult, 1)}
+}
+
+// 收到回调, 已超时或已回调过返回false
+func (w *asyncWaiter) complete(taskResult TaskResult) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return false
+	}
+	w.done = true
+	w.result <- taskResult
+
+	return true
+}
+
+// 等待超时, 已收到回调返回false
+func (w *asyncWaiter) expire() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return false
+	}
+	w.done = true
+
+	return true
+}
+
+func hashCallbackToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+// 生成本次请求的回调令牌并登记等待, 每次重试都会生成新的令牌
+func prepareAsyncCallback(taskLogId int64, headers http.Header) error {
+	if app.Setting == nil || app.Setting.CallbackUrl == "" {
+		return errors.New("未配置callback_url, 无法执行异步HTTP任务")
+	}
+	token := utils.RandAuthToken()
+	if err := saveCallbackTokenFunc(taskLogId, hashCallbackToken(token)); err != nil {
+		return fmt.Errorf("保存回调令牌失败-%s", err)
+	}
+	asyncWaiters.Store(taskLogId, newAsyncWaiter())
+	headers.Set(AsyncCallbackURLHeader, fmt.Sprintf("%s/api/task/callback?id=%d", app.Setting.CallbackUrl, taskLogId))
+	headers.Set(AsyncCallbackTokenHeader, token)
+	headers.Set(AsyncTaskLogIdHeader, strconv.FormatInt(taskLogId, 10))
+
+	return nil
+}
+
+// 请求发送成功后等待回调, 超时视为执行失败
+func waitAsyncCallback(taskModel models.Task, taskLogId int64, dispatchResult TaskResult) TaskResult {
+	value, ok := asyncWaiters.Load(taskLogId)
+	if !ok {
+		return dispatchResult
+	}
+	waiter := value.(*asyncWaiter)
+	timeout := taskModel.AsyncTimeout()
+	logger.Infof("异步任务请求已发送, 等待回调#ID-%d#taskLogId-%d#超时时间-%s", taskModel.Id, taskLogId, timeout)
+	select {
+	case taskResult := <-waiter.result:
+		return mergeAsyncResult(dispatchResult, taskResult)
+	case <-asyncTimeAfter(timeout):
+		if !waiter.expire() {
+			// 超时的同时收到了回调
+			return mergeAsyncResult(dispatchResult, <-waiter.result)
+		}
+	}
+	message := fmt.Sprintf("等待回调超时(%s)", timeout)
+	logger.Warnf("异步任务%s#ID-%d#taskLogId-%d", message, taskModel.Id, taskLogId)
+
+	return TaskResult{
+		Result:     fmt.Sprintf("%s\n%s", message, dispatchResult.Result),
+		Err:        errors.New(message),
+		RetryTimes: dispatchResult.RetryTimes,
+		Attempts:   dispatchResult.Attempts,
+	}
+}
+
+// 回调结果沿用请求阶段的重试记录
+func mergeAsyncResult(dispatchResult TaskResult, taskResult TaskResult) TaskResult {
+	taskResult.RetryTimes = dispatchResult.RetryTimes
+	taskResult.Attempts = dispatchResult.Attempts
+
+	return taskResult
+}
+
+func asyncCallbackResult(success bool, output string) TaskResult {
+	if success {
+		if output == "" {
+			output = "异步任务执行成功"
+		}
+		return TaskResult{Result: output}
+	}
+	if output == "" {
+		output = "异步任务执行失败"
+	}
+
+	return TaskResult{Result: output, Err: errors.New("异步任务回调执行失败")}
+}
+
+// CompleteAsync 处理异步HTTP任务的回调
+func (task Task) CompleteAsync(taskLogId int64, token string, success bool, output string) error {
+	taskLogModel := new(models.TaskLog)
+	taskLog, err := taskLogModel.Detail(taskLogId)
+	if err != nil || taskLog.Status != models.Running {
+		return ErrAsyncTaskNotRunning
+	}
+	if token == "" || taskLog.CallbackToken == "" ||
+		subtle.ConstantTimeCompare([]byte(taskLog.CallbackToken), []byte(hashCallbackToken(token))) != 1 {
+		return ErrAsyncCallbackTokenInvalid
+	}
+	taskResult := asyncCallbackResult(success, output)
+	if value, ok := asyncWaiters.Load(taskLogId); ok {
+		if !value.(*asyncWaiter).complete(taskResult) {
+			return ErrAsyncTaskNotRunning
+		}
+		return nil
+	}
+
+	// 服务重启后没有等待中的任务, 直接结束任务日志
+	status := models.Finish
+	if taskResult.Err != nil {
+		status = models.Failure
+	}
+	affected, err := taskLogModel.UpdateRunning(taskLogId, models.CommonMap{
+		"status":   status,
+		"result":   taskResult.Result,
+		"end_time": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAsyncTaskNotRunning
+	}
+	taskModel := new(models.Task)
+	detail, err := taskModel.Detail(taskLog.TaskId)
+	if err != nil {
+		logger.Errorf("异步任务回调#获取任务详情失败#ID-%d#%s", taskLog.TaskId, err)
+		return nil
+	}
+	go SendNotification(detail, taskResult)
+	go execDependencyTask(detail, taskResult)
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	"github.com/gocronx-team/gocron/internal/modules/httpclient"
+	"github.com/gocronx-team/gocron/internal/modules/setting"
+)
+
+func stubAsyncCallback(t *testing.T) map[int64]string {
+	t.Helper()
+	originalSave := saveCallbackTokenFunc
+	originalSetting := app.Setting
+	tokens := make(map[int64]string)
+	saveCallbackTokenFunc = func(taskLogId int64, tokenHash string) error {
+		tokens[taskLogId] = tokenHash
+		return nil
+	}
+	app.Setting = &setting.Setting{CallbackUrl: "http://gocron.local:5920"}
+	t.Cleanup(func() {
+		saveCallbackTokenFunc = originalSave
+		app.Setting = originalSetting
+	})
+
+	return tokens
+}
+
+func TestHTTPHandlerRunAsyncSendsCallback(t *testing.T) {
+	tokens := stubAsyncCallback(t)
+	originalRequest := httpRequestFunc
+	defer func() { httpRequestFunc = originalRequest }()
+
+	var capturedHeaders http.Header
+	httpRequestFunc = func(method, url string, body string, headers http.Header, timeout int, options httpclient.Options) httpclient.ResponseWrapper {
+		capturedHeaders = headers
+		return httpclient.ResponseWrapper{StatusCode: http.StatusOK, Body: "accepted"}
+	}
+
+	task := models.Task{Protocol: models.TaskHTTP, Command: "http://example.com/job", HttpAsync: 1}
+	result, err := (&HTTPHandler{}).Run(task, 42)
+	defer asyncWaiters.Delete(int64(42))
+	if err != nil || result != "accepted" {
+		t.Fatalf("unexpected result %q, %v", result, err)
+	}
+	if capturedHeaders.Get(AsyncCallbackURLHeader) != "http://gocron.local:5920/api/task/callback?id=42" {
+		t.Fatalf("unexpected callback url: %s", capturedHeaders.Get(AsyncCallbackURLHeader))
+	}
+	if capturedHeaders.Get(AsyncTaskLogIdHeader) != "42" {
+		t.Fatalf("unexpected task log id header: %s", capturedHeaders.Get(AsyncTaskLogIdHeader))
+	}
+	token := capturedHeaders.Get(AsyncCallbackTokenHeader)
+	if token == "" || tokens[42] != hashCallbackToken(token) {
+		t.Fatalf("expected stored hash of callback token, got %q for %q", tokens[42], token)
+	}
+	if _, ok := asyncWaiters.Load(int64(42)); !ok {
+		t.Fatalf("expected waiter to be registered")
+	}
+}
+
+func TestPrepareAsyncCallbackRequiresCallbackURL(t *testing.T) {
+	stubAsyncCallback(t)
+	app.Setting = &setting.Setting{}
+
+	err := prepareAsyncCallback(1, make(http.Header))
+	if err == nil || !strings.Contains(err.Error(), "callback_url") {
+		t.Fatalf("expected callback_url error, got %v", err)
+	}
+}
+
+func TestWaitAsyncCallbackReceivesResult(t *testing.T) {
+	stubAsyncCallback(t)
+	if err := prepareAsyncCallback(7, make(http.Header)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer asyncWaiters.Delete(int64(7))
+
+	value, _ := asyncWaiters.Load(int64(7))
+	waiter := value.(*asyncWaiter)
+	if !waiter.complete(asyncCallbackResult(false, "boom")) {
+		t.Fatalf("expected first callback to be accepted")
+	}
+	if waiter.complete(asyncCallbackResult(true, "again")) {
+		t.Fatalf("expected duplicate callback to be rejected")
+	}
+
+	attempts := []models.TaskLogAttempt{{Attempt: 1}, {Attempt: 2}}
+	dispatch := TaskResult{Result: "accepted", RetryTimes: 1, Attempts: attempts}
+	taskResult := waitAsyncCallback(models.Task{HttpAsync: 1}, 7, dispatch)
+	if taskResult.Err == nil || taskResult.Result != "boom" {
+		t.Fatalf("unexpected callback result: %+v", taskResult)
+	}
+	if taskResult.RetryTimes != 1 || len(taskResult.Attempts) != 2 {
+		t.Fatalf("expected dispatch attempts to be kept: %+v", taskResult)
+	}
+}
+
+func TestWaitAsyncCallbackTimeout(t *testing.T) {
+	stubAsyncCallback(t)
+	originalTimeAfter := asyncTimeAfter
+	defer func() { asyncTimeAfter = originalTimeAfter }()
+	var waited time.Duration
+	asyncTimeAfter = func(d time.Duration) <-chan time.Time {
+		waited = d
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+	if err := prepareAsyncCallback(8, make(http.Header)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer asyncWaiters.Delete(int64(8))
+
+	taskResult := waitAsyncCallback(models.Task{HttpAsync: 1, HttpAsyncTimeout: 90}, 8, TaskResult{Result: "accepted"})
+	if waited != 90*time.Second {
+		t.Fatalf("expected 90s timeout, got %s", waited)
+	}
+	if taskResult.Err == nil || !strings.Contains(taskResult.Result, "等待回调超时") || !strings.Contains(taskResult.Result, "accepted") {
+		t.Fatalf("unexpected timeout result: %+v", taskResult)
+	}
+
+	value, _ := asyncWaiters.Load(int64(8))
+	if value.(*asyncWaiter).complete(asyncCallbackResult(true, "late")) {
+		t.Fatalf("expected callback after timeout to be rejected")
+	}
+}
+
+func TestTaskAsyncTimeout(t *testing.T) {
+	tests := []struct {
+		timeout  int
+		expected time.Duration
+	}{
+		{0, models.TaskDefaultAsyncTimeout * time.Second},
+		{120, 120 * time.Second},
+		{models.TaskMaxAsyncTimeout + 1, models.TaskDefaultAsyncTimeout * time.Second},
+	}
+	for _, tt := range tests {
+		task := models.Task{HttpAsyncTimeout: tt.timeout}
+		if got := task.AsyncTimeout(); got != tt.expected {
+			t.Fatalf("timeout %d: expected %s, got %s", tt.timeout, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+// 运行中任务的实时输出, 供前端查看执行过程, 执行结束后完整输出仍写入任务日志
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+)
+
+const (
+	// 每个任务保留的最近输出大小, 超出时丢弃最早的片段
+	liveOutputMaxSize = 1 << 20
+	// 订阅者未及时读取时缓冲的片段数量, 超出后丢弃新片段
+	liveOutputSubscriberBuffer = 256
+)
+
+// 实时输出片段类型
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+var (
+	ErrTaskNotRunning = errors.New("任务未在执行")
+
+	liveOutputs = liveOutputRegistry{byId: make(map[int64]*liveOutput)}
+)
+
+// OutputChunk 实时输出片段
+type OutputChunk struct {
+	Host   string `json:"host,omitempty"` // 执行主机, 本机任务为空
+	Stream string `json:"stream"`         // stdout, stderr
+	Data   string `json:"data"`
+}
+
+type liveOutput struct {
+	chunks      []OutputChunk
+	size        int
+	subscribers map[chan OutputChunk]struct{}
+}
+
+// 运行中任务的输出, 任务日志ID作为Key
+type liveOutputRegistry struct {
+	sync.Mutex
+	byId map[int64]*liveOutput
+}
+
+// 任务开始执行, 重试时沿用已有的输出
+func (r *liveOutputRegistry) open(id int64) {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.byId[id]; !ok {
+		r.byId[id] = &liveOutput{subscribers: make(map[chan OutputChunk]struct{})}
+	}
+}
+
+// 任务执行结束, 关闭所有订阅
+func (r *liveOutputRegistry) close(id int64) {
+	r.Lock()
+	defer r.Unlock()
+	output, ok := r.byId[id]
+	if !ok {
+		return
+	}
+	for ch := range output.subscribers {
+		close(ch)
+	}
+	delete(r.byId, id)
+}
+
+func (r *liveOutputRegistry) append(id int64, chunk OutputChunk) {
+	if chunk.Data == "" {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	output, ok := r.byId[id]
+	if !ok {
+		return
+	}
+	output.chunks = append(output.chunks, chunk)
+	output.size += len(chunk.Data)
+	for output.size > liveOutputMaxSize && len(output.chunks) > 1 {
+		output.size -= len(output.chunks[0].Data)
+		output.chunks = output.chunks[1:]
+	}
+	for ch := range output.subscribers {
+		select {
+		case ch <- chunk:
+		default:
+		}
+	}
+}
+
+func (r *liveOutputRegistry) subscribe(id int64) ([]OutputChunk, <-chan OutputChunk, func(), error) {
+	r.Lock()
+	defer r.Unlock()
+	output, ok := r.byId[id]
+	if !ok {
+		return nil, nil, nil, ErrTaskNotRunning
+	}
+	history := make([]OutputChunk, len(output.chunks))
+	copy(history, output.chunks)
+	ch := make(chan OutputChunk, liveOutputSubscriberBuffer)
+	output.subscribers[ch] = struct{}{}
+	cancel := func() {
+		r.Lock()
+		defer r.Unlock()
+		if current, ok := r.byId[id]; ok && current == output {
+			if _, ok = output.subscribers[ch]; ok {
+				delete(output.subscribers, ch)
+				close(ch)
+			}
+		}
+	}
+
+	return history, ch, cancel, nil
+}
+
+// 将命令输出写入实时输出
+func (r *liveOutputRegistry) writer(id int64, host, stream string) io.Writer {
+	return liveOutputWriter{registry: r, id: id, chunk: OutputChunk{Host: host, Stream: stream}}
+}
+
+type liveOutputWriter struct {
+	registry *liveOutputRegistry
+	id       int64
+	chunk    OutputChunk
+}
+
+func (w liveOutputWriter) Write(p []byte) (int, error) {
+	chunk := w.chunk
+	chunk.Data = string(p)
+	w.registry.append(w.id, chunk)
+
+	return len(p), nil
+}
+
+// TailOutput 订阅运行中任务的实时输出, 返回已产生的输出及后续片段, 任务结束时通道关闭
+func (task Task) TailOutput(taskLogId int64) (history []OutputChunk, chunks <-chan OutputChunk, cancel func(), err error) {
+	return liveOutputs.subscribe(taskLogId)
+}
+
+// 通过流式RPC执行, 输出片段写入实时输出
+func rpcExecStream(ip string, port int, taskReq *pb.TaskRequest) (*pb.TaskResponse, error) {
+	host := fmt.Sprintf("%s:%d", ip, port)
+	return rpcClient.ExecStream(ip, port, taskReq, func(stdout, stderr string) {
+		liveOutputs.append(taskReq.Id, OutputChunk{Host: host, Stream: OutputStdout, Data: stdout})
+		liveOutputs.append(taskReq.Id, OutputChunk{Host: host, Stream: OutputStderr, Data: stderr})
+	})
+}
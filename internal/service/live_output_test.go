@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLiveOutputSubscribe(t *testing.T) {
+	if _, _, _, err := ServiceTask.TailOutput(101); !errors.Is(err, ErrTaskNotRunning) {
+		t.Fatalf("expected not running error, got %v", err)
+	}
+
+	liveOutputs.open(101)
+	liveOutputs.append(101, OutputChunk{Stream: OutputStdout, Data: "first\n"})
+	history, chunks, cancel, err := ServiceTask.TailOutput(101)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cancel()
+	if len(history) != 1 || history[0].Data != "first\n" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	liveOutputs.writer(101, "10.0.0.1:5921", OutputStderr).Write([]byte("second\n"))
+	chunk := <-chunks
+	if chunk.Host != "10.0.0.1:5921" || chunk.Stream != OutputStderr || chunk.Data != "second\n" {
+		t.Fatalf("unexpected chunk: %+v", chunk)
+	}
+
+	liveOutputs.close(101)
+	if _, ok := <-chunks; ok {
+		t.Fatal("expected subscription to be closed when task finished")
+	}
+	// 任务结束后取消订阅不能重复关闭通道
+	cancel()
+}
+
+func TestLiveOutputKeepsRecentChunks(t *testing.T) {
+	liveOutputs.open(102)
+	defer liveOutputs.close(102)
+	data := strings.Repeat("x", liveOutputMaxSize/2)
+	for i := 0; i < 3; i++ {
+		liveOutputs.append(102, OutputChunk{Stream: OutputStdout, Data: data})
+	}
+	history, _, cancel, err := ServiceTask.TailOutput(102)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cancel()
+	if len(history) != 2 {
+		t.Fatalf("expected oldest chunk to be dropped, got %d chunks", len(history))
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+// 本机Shell任务, 在gocron服务端进程中直接执行命令, 需在配置文件中开启local_shell.enable
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+)
+
+const (
+	// 本机任务最长执行时间, 与RPC任务保持一致
+	localExecMaxTimeout = 86400
+	// 本机任务默认保留的输出字节数, 与gocron-node默认值一致
+	localOutputLimit = 1024 * 1024
+)
+
+var (
+	ErrLocalShellDisabled = errors.New("本机Shell任务未启用, 请在配置文件中设置local_shell.enable=true")
+
+	execShellFunc  = utils.ExecShellDetail
+	execScriptFunc = utils.ExecScriptDetail
+
+	// 运行中的本机任务, 任务日志ID作为Key
+	localTasks sync.Map
+)
+
+type LocalHandler struct{}
+
+func (h *LocalHandler) Run(taskModel models.Task, taskUniqueId int64) (string, error) {
+	if app.Setting == nil || !app.Setting.EnableLocalShell {
+		return "", ErrLocalShellDisabled
+	}
+	timeout := taskModel.Timeout
+	if timeout <= 0 || timeout > localExecMaxTimeout {
+		timeout = localExecMaxTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	localTasks.Store(taskUniqueId, cancel)
+	defer localTasks.Delete(taskUniqueId)
+
+	logger.Infof("本机任务开始执行#任务ID-%d#taskLogId-%d", taskModel.Id, taskUniqueId)
+	// 本机任务以gocron服务端的运行用户执行, 不支持切换执行用户
+	env, _ := models.ParseTaskEnv(taskModel.Env)
+	options := utils.ExecOptions{
+		Env:    env,
+		Dir:    taskModel.WorkDir,
+		Stdout: liveOutputs.writer(taskUniqueId, "", OutputStdout),
+		Stderr: liveOutputs.writer(taskUniqueId, "", OutputStderr),
+		// 本机任务在gocron服务端执行, 未设置时也限制保留的输出
+		OutputLimit: localOutputLimit,
+		StopSignal:  taskModel.StopSignal,
+		GracePeriod: time.Duration(taskModel.StopGracePeriod) * time.Second,
+	}
+	if taskModel.OutputLimit > 0 {
+		options.OutputLimit = taskModel.OutputLimit * 1024
+	}
+	var result utils.ExecResult
+	var err error
+	if taskModel.IsScript() {
+		result, err = execScriptFunc(ctx, taskModel.ScriptInterpreter, taskModel.Script, taskModel.Command, options)
+	} else {
+		result, err = execShellFunc(ctx, taskModel.Command, options)
+	}
+	if detail, ok := detailFromResult(result); ok {
+		err = checkExecDetail(taskModel, detail, err)
+		execDetails.record(taskUniqueId, "", detail)
+	}
+	output := result.Output
+	// 超时或手动停止时使用与RPC任务相同的错误, 便于重试条件判断
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		err = rpcClient.ErrTimeout
+	case context.Canceled:
+		err = rpcClient.ErrCanceled
+	}
+	logger.Infof("本机任务执行完成#任务ID-%d#taskLogId-%d#输出长度-%d#错误-%v", taskModel.Id, taskUniqueId, len(output), err)
+
+	return output, err
+}
+
+// StopLocal 停止运行中的本机任务
+func (task Task) StopLocal(id int64) {
+	stopServerSideTask(&localTasks, id)
+}
+
+// 停止由gocron服务端直接执行的任务, 未找到时视为服务重启后丢失的任务, 直接更新日志状态
+func stopServerSideTask(tasks *sync.Map, id int64) {
+	cancel, ok := tasks.Load(id)
+	if ok {
+		logger.Infof("停止运行中的任务#taskLogId-%d", id)
+		cancel.(context.CancelFunc)()
+		return
+	}
+	logger.Warnf("未找到运行中的任务, 直接更新日志状态#taskLogId-%d", id)
+	taskLogModel := new(models.TaskLog)
+	_, err := taskLogModel.UpdateRunning(id, models.CommonMap{
+		"status":   models.Cancel,
+		"result":   "系统重启后手动停止",
+		"end_time": time.Now(),
+	})
+	if err != nil {
+		logger.Errorf("更新任务日志状态失败#taskLogId-%d#%s", id, err)
+	}
+}
+
+// 本机任务日志中记录的主机名
+func localHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "localhost"
+	}
+
+	return hostname
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	"github.com/gocronx-team/gocron/internal/modules/setting"
+)
+
+func enableLocalShell(t *testing.T, enabled bool) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell commands in this test require bash")
+	}
+	originalSetting := app.Setting
+	app.Setting = &setting.Setting{EnableLocalShell: enabled}
+	t.Cleanup(func() { app.Setting = originalSetting })
+}
+
+func TestLocalHandlerDisabledByDefault(t *testing.T) {
+	enableLocalShell(t, false)
+
+	_, err := (&LocalHandler{}).Run(models.Task{Protocol: models.TaskLocal, Command: "echo hi"}, 1)
+	if !errors.Is(err, ErrLocalShellDisabled) {
+		t.Fatalf("expected disabled error, got %v", err)
+	}
+}
+
+func TestLocalHandlerCapturesOutput(t *testing.T) {
+	enableLocalShell(t, true)
+
+	output, err := (&LocalHandler{}).Run(models.Task{Protocol: models.TaskLocal, Command: "echo out; echo err >&2"}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "out") || !strings.Contains(output, "err") {
+		t.Fatalf("expected combined output, got %q", output)
+	}
+
+	output, err = (&LocalHandler{}).Run(models.Task{Protocol: models.TaskLocal, Command: "echo failed; exit 3"}, 3)
+	if code, ok := exitCodeFromError(err); !ok || code != 3 || !strings.Contains(output, "failed") {
+		t.Fatalf("expected exit status 3 with output, got %q, %v", output, err)
+	}
+}
+
+func TestLocalHandlerTimeout(t *testing.T) {
+	enableLocalShell(t, true)
+
+	start := time.Now()
+	_, err := (&LocalHandler{}).Run(models.Task{Protocol: models.TaskLocal, Command: "sleep 10", Timeout: 1}, 4)
+	if !errors.Is(err, rpcClient.ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("expected command to be killed on timeout")
+	}
+}
+
+func TestLocalHandlerStop(t *testing.T) {
+	enableLocalShell(t, true)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := (&LocalHandler{}).Run(models.Task{Protocol: models.TaskLocal, Command: "sleep 10"}, 5)
+		done <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := localTasks.Load(int64(5)); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("local task was not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ServiceTask.StopLocal(5)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, rpcClient.ErrCanceled) {
+			t.Fatalf("expected canceled error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("local task was not stopped")
+	}
+	if _, ok := localTasks.Load(int64(5)); ok {
+		t.Fatal("expected stopped task to be removed")
+	}
+}
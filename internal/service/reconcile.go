@@ -0,0 +1,193 @@
+package service
+
+// gocron启动时核对重启前运行中的RPC任务, 通过节点查询任务状态, 任务结束后以节点返回的结果更新日志
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+)
+
+const (
+	// 节点上仍在运行时再次查询的间隔
+	reconcileInterval = 10 * time.Second
+	// 连续查询失败的次数上限, 约30分钟, 超过后任务日志标记为失败
+	reconcileMaxFailures = 180
+)
+
+// 节点版本过低, 无法查询重启前运行中的任务
+const reconcileUnsupportedMessage = "节点版本过低, 不支持查询任务状态, gocron重启后无法获取执行结果"
+
+var (
+	rpcListRunningFunc = rpcClient.ListRunning
+	rpcGetStatusFunc   = rpcClient.GetStatus
+)
+
+// 核对运行中的RPC任务日志, 等待节点上的任务全部结束
+func (task Task) reconcileRunningLogs() {
+	taskLogModel := new(models.TaskLog)
+	taskLogs, err := taskLogModel.RunningList(models.TaskRPC)
+	if err != nil {
+		logger.Error("核对运行中的任务#获取任务日志失败", err)
+		return
+	}
+	pending := make(map[int64]models.Task)
+	taskModel := new(models.Task)
+	for _, taskLog := range taskLogs {
+		item, err := taskModel.Detail(taskLog.TaskId)
+		if err == nil {
+			err = item.ResolveHosts()
+		}
+		// 只查询执行本次任务的主机, 旧日志未记录主机时使用任务当前的主机
+		if err == nil && taskLog.HostIds != "" {
+			item.Hosts, err = taskLog.ExecHosts()
+		}
+		if err != nil || item.Id == 0 || len(item.Hosts) == 0 {
+			logger.Warnf("核对运行中的任务#任务不存在或未关联主机#taskLogId-%d", taskLog.Id)
+			_, _ = updateTaskLog(taskLog.Id, TaskResult{Err: errors.New("任务不存在或未关联主机"), Result: "gocron重启后无法查询任务状态"})
+			continue
+		}
+		pending[taskLog.Id] = item
+	}
+	failures := make(map[int64]int)
+	for len(pending) > 0 {
+		for id, taskResult := range reconcileOnce(pending, failures) {
+			logger.Infof("核对运行中的任务#任务已结束#taskLogId-%d#错误-%v", id, taskResult.Err)
+			if _, err = updateTaskLog(id, taskResult); err != nil {
+				logger.Error("核对运行中的任务#更新任务日志失败", err)
+			}
+			delete(pending, id)
+		}
+		if len(pending) > 0 {
+			sleepFunc(reconcileInterval)
+		}
+	}
+}
+
+// 核对一次, 返回已结束的任务日志及结果
+// 查询失败的任务下次核对时重试, 连续失败达到上限后标记为失败
+func reconcileOnce(pending map[int64]models.Task, failures map[int64]int) map[int64]TaskResult {
+	finished := make(map[int64]TaskResult)
+	running, failed := runningJobs(pending)
+	for id, item := range pending {
+		err := failed[id]
+		if err == nil && !running[id] {
+			var taskResult TaskResult
+			var done bool
+			taskResult, done, err = collectNodeResults(item, id)
+			if done {
+				finished[id] = taskResult
+				continue
+			}
+		}
+		if err == nil {
+			delete(failures, id)
+			continue
+		}
+		failures[id]++
+		if failures[id] >= reconcileMaxFailures {
+			logger.Warnf("核对运行中的任务#连续%d次查询失败, 标记为失败#taskLogId-%d#%s", failures[id], id, err)
+			finished[id] = TaskResult{
+				Err:    err,
+				Result: fmt.Sprintf("gocron重启后连续%d次查询任务状态失败: %s", failures[id], err),
+			}
+			delete(failures, id)
+		}
+	}
+
+	return finished
+}
+
+// 各主机上运行中的任务, 每台主机只查询一次
+// 查询失败的主机上的任务无法确认是否结束, 返回查询错误, 下次核对时重试
+// 旧版本节点不支持查询, 其上的任务视为已结束, 由collectNodeResults记录
+func runningJobs(pending map[int64]models.Task) (map[int64]bool, map[int64]error) {
+	running := make(map[int64]bool)
+	hostErrors := make(map[string]error)
+	queried := make(map[string]bool)
+	for _, item := range pending {
+		for _, th := range item.Hosts {
+			addr := fmt.Sprintf("%s:%d", th.Name, th.Port)
+			if queried[addr] {
+				continue
+			}
+			queried[addr] = true
+			jobs, err := rpcListRunningFunc(th.Name, th.Port)
+			if errors.Is(err, rpcClient.ErrUnsupported) {
+				continue
+			}
+			if err != nil {
+				logger.Warnf("核对运行中的任务#查询节点失败, 稍后重试#主机-%s#%s", addr, err)
+				hostErrors[addr] = err
+				continue
+			}
+			for _, job := range jobs {
+				running[job.Id] = true
+			}
+		}
+	}
+	failed := make(map[int64]error)
+	for id, item := range pending {
+		for _, th := range item.Hosts {
+			if err := hostErrors[fmt.Sprintf("%s:%d", th.Name, th.Port)]; err != nil {
+				failed[id] = err
+			}
+		}
+	}
+
+	return running, failed
+}
+
+// 汇总各主机保留的执行结果, 单台主机执行时其他主机上未找到任务
+// 各主机均返回执行结果、未找到任务或不支持查询时done为true
+// 任务仍在运行时done为false, 查询失败时同时返回错误, 下次核对时重试
+func collectNodeResults(taskModel models.Task, id int64) (TaskResult, bool, error) {
+	type hostResult struct {
+		host models.TaskHostDetail
+		resp *pb.TaskResponse
+		err  error
+	}
+	var results []hostResult
+	for _, th := range taskModel.Hosts {
+		jobStatus, err := rpcGetStatusFunc(th.Name, th.Port, id)
+		if errors.Is(err, rpcClient.ErrJobNotFound) {
+			continue
+		}
+		if errors.Is(err, rpcClient.ErrUnsupported) {
+			results = append(results, hostResult{host: th, err: errors.New(reconcileUnsupportedMessage)})
+			continue
+		}
+		if err != nil {
+			logger.Warnf("核对运行中的任务#查询任务状态失败, 稍后重试#主机-%s:%d#taskLogId-%d#%s", th.Name, th.Port, id, err)
+			return TaskResult{}, false, err
+		}
+		if jobStatus.Running || jobStatus.Result == nil {
+			return TaskResult{}, false, nil
+		}
+		results = append(results, hostResult{host: th, resp: jobStatus.Result})
+	}
+	var taskResult TaskResult
+	for _, item := range results {
+		var output string
+		err := item.err
+		if err == nil {
+			output, err = recordHostResult(taskModel, item.host, id, item.resp, rpcClient.ResponseError(item.resp))
+		}
+		if err != nil {
+			taskResult.Err = err
+		}
+		taskResult.Result += hostOutputMessage(item.host, item.host.Port, output, err)
+	}
+	taskResult.Detail = execDetails.take(id)
+	if len(results) == 0 {
+		taskResult.Err = rpcClient.ErrJobNotFound
+		taskResult.Result = "gocron重启后节点上未找到任务, 可能节点已重启或执行结果已过期"
+	}
+
+	return taskResult, true, nil
+}
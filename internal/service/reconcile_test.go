@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	pb "github.com/gocronx-team/gocron/internal/modules/rpc/proto"
+)
+
+func TestCollectNodeResults(t *testing.T) {
+	originalStatus := rpcGetStatusFunc
+	defer func() {
+		rpcGetStatusFunc = originalStatus
+	}()
+	rpcGetStatusFunc = func(ip string, port int, id int64) (*pb.JobStatus, error) {
+		if ip == "10.0.0.1" {
+			return nil, rpcClient.ErrJobNotFound
+		}
+		return &pb.JobStatus{Id: id, Result: &pb.TaskResponse{
+			Output: "stopping\n", Error: "timeout killed", Exited: true, ExitCode: -1, Signal: "SIGTERM", Canceled: true,
+		}}, nil
+	}
+	task := models.Task{
+		Id: 1,
+		Hosts: []models.TaskHostDetail{
+			{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Port: 5921, Alias: "a"},
+			{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Port: 5921, Alias: "b"},
+		},
+	}
+	result, done, err := collectNodeResults(task, 31)
+	if !done || err != nil {
+		t.Fatalf("expected reconcile to finish, got %v", err)
+	}
+	if !errors.Is(result.Err, rpcClient.ErrCanceled) {
+		t.Fatalf("expected canceled error, got %v", result.Err)
+	}
+	if strings.Contains(result.Result, "10.0.0.1") || !strings.Contains(result.Result, "stopping") {
+		t.Fatalf("unexpected result: %q", result.Result)
+	}
+	if result.Detail == nil || result.Detail.Signal != "SIGTERM" {
+		t.Fatalf("expected exec detail from node, got %+v", result.Detail)
+	}
+
+	rpcGetStatusFunc = func(ip string, port int, id int64) (*pb.JobStatus, error) {
+		return nil, rpcClient.ErrJobNotFound
+	}
+	if result, done, _ = collectNodeResults(task, 32); !done || !errors.Is(result.Err, rpcClient.ErrJobNotFound) {
+		t.Fatalf("expected job not found, got %v", result.Err)
+	}
+
+	// 旧版本节点不支持查询, 直接结束核对
+	rpcGetStatusFunc = func(ip string, port int, id int64) (*pb.JobStatus, error) {
+		if ip == "10.0.0.1" {
+			return nil, rpcClient.ErrJobNotFound
+		}
+		return nil, rpcClient.ErrUnsupported
+	}
+	result, done, err = collectNodeResults(task, 33)
+	if !done || err != nil || result.Err == nil || !strings.Contains(result.Result, "不支持查询任务状态") {
+		t.Fatalf("expected unsupported node to finish reconcile, got %+v %v", result, err)
+	}
+
+	// 节点不可用时下次核对时重试
+	rpcGetStatusFunc = func(ip string, port int, id int64) (*pb.JobStatus, error) {
+		return nil, rpcClient.ErrUnavailable
+	}
+	if _, done, err = collectNodeResults(task, 34); done || !errors.Is(err, rpcClient.ErrUnavailable) {
+		t.Fatalf("expected reconcile to retry, got done=%v err=%v", done, err)
+	}
+	rpcGetStatusFunc = func(ip string, port int, id int64) (*pb.JobStatus, error) {
+		return &pb.JobStatus{Id: id, Running: true}, nil
+	}
+	if _, done, err = collectNodeResults(task, 35); done || err != nil {
+		t.Fatalf("expected reconcile to wait for running job, got done=%v err=%v", done, err)
+	}
+}
+
+func TestRunningJobsQueriesEachHostOnce(t *testing.T) {
+	originalList := rpcListRunningFunc
+	defer func() {
+		rpcListRunningFunc = originalList
+	}()
+	calls := make(map[string]int)
+	rpcListRunningFunc = func(ip string, port int) ([]*pb.JobStatus, error) {
+		calls[ip]++
+		switch ip {
+		case "10.0.0.2":
+			return nil, rpcClient.ErrUnavailable
+		case "10.0.0.3":
+			return nil, rpcClient.ErrUnsupported
+		}
+		return []*pb.JobStatus{{Id: 41, Running: true}}, nil
+	}
+	hosts := []models.TaskHostDetail{{Name: "10.0.0.1", Port: 5921}, {Name: "10.0.0.2", Port: 5921}, {Name: "10.0.0.3", Port: 5921}}
+	running, failed := runningJobs(map[int64]models.Task{
+		41: {Id: 1, Hosts: hosts},
+		42: {Id: 2, Hosts: hosts},
+		43: {Id: 3, Hosts: []models.TaskHostDetail{hosts[0], hosts[2]}},
+	})
+	// 42在查询失败的主机上可能仍在运行, 43所在的旧版本节点不支持查询
+	if !running[41] || running[42] || running[43] {
+		t.Fatalf("unexpected running jobs: %v", running)
+	}
+	if !errors.Is(failed[42], rpcClient.ErrUnavailable) || failed[43] != nil {
+		t.Fatalf("unexpected failed jobs: %v", failed)
+	}
+	if calls["10.0.0.1"] != 1 || calls["10.0.0.2"] != 1 || calls["10.0.0.3"] != 1 {
+		t.Fatalf("each host should be queried once: %v", calls)
+	}
+}
+
+func TestReconcileOnceGivesUpAfterMaxFailures(t *testing.T) {
+	originalList, originalStatus := rpcListRunningFunc, rpcGetStatusFunc
+	defer func() {
+		rpcListRunningFunc, rpcGetStatusFunc = originalList, originalStatus
+	}()
+	rpcListRunningFunc = func(ip string, port int) ([]*pb.JobStatus, error) {
+		return nil, rpcClient.ErrUnavailable
+	}
+	rpcGetStatusFunc = func(ip string, port int, id int64) (*pb.JobStatus, error) {
+		t.Fatal("status should not be queried on an unavailable host")
+		return nil, nil
+	}
+	pending := map[int64]models.Task{51: {Id: 1, Hosts: []models.TaskHostDetail{{Name: "10.0.0.1", Port: 5921}}}}
+	failures := make(map[int64]int)
+	for i := 1; i < reconcileMaxFailures; i++ {
+		if finished := reconcileOnce(pending, failures); len(finished) != 0 {
+			t.Fatalf("should keep retrying before the limit, finished after %d attempts", i)
+		}
+	}
+	finished := reconcileOnce(pending, failures)
+	if result, ok := finished[51]; !ok || !errors.Is(result.Err, rpcClient.ErrUnavailable) {
+		t.Fatalf("expected log marked failed after %d failures, got %+v", reconcileMaxFailures, finished)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+// 任务重试策略: 重试间隔计算、重试条件判断
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+)
+
+// 未设置最大重试间隔时的上限, 防止指数退避长时间占用任务实例
+const retryMaxDelay = 10 * time.Minute
+
+// 指数退避未设置间隔时的初始间隔
+const retryDefaultInterval = 60 * time.Second
+
+var exitStatusPattern = regexp.MustCompile(`exit status (-?\d+)`)
+
+// gocron服务退出时取消等待中的重试
+var errRetryShutdown = errors.New("gocron服务退出, 取消重试")
+
+var (
+	retryWaitFunc = waitRetry
+	// 等待重试中的任务, key为任务日志ID
+	retryWaits sync.Map
+)
+
+// HTTP状态码错误
+type HTTPStatusError struct {
+	StatusCode int
+	Err        error // 未得到响应时的底层错误
+}
+
+func (e *HTTPStatusError) Error() string {
+	return "HTTP状态码非200-->" + strconv.Itoa(e.StatusCode)
+}
+
+func (e *HTTPStatusError) Unwrap() error {
+	return e.Err
+}
+
+// 计算第n次重试(从1开始)前的等待时间
+func retryDelay(taskModel models.Task, n int) time.Duration {
+	maxInterval := retryMaxDelay
+	if taskModel.RetryMaxInterval > 0 {
+		maxInterval = time.Duration(taskModel.RetryMaxInterval) * time.Second
+	}
+	var delay time.Duration
+	if taskModel.RetryBackoff == models.TaskRetryBackoffExponential {
+		base := time.Duration(taskModel.RetryInterval) * time.Second
+		if base <= 0 {
+			base = retryDefaultInterval
+		}
+		multiplier := taskModel.RetryMultiplier
+		if multiplier < 1 {
+			multiplier = 1
+		}
+		factor := math.Pow(multiplier, float64(n-1))
+		if float64(base)*factor >= float64(maxInterval) {
+			delay = maxInterval
+		} else {
+			delay = time.Duration(float64(base) * factor)
+		}
+	} else if taskModel.RetryInterval > 0 {
+		delay = time.Duration(taskModel.RetryInterval) * time.Second
+	} else {
+		// 默认重试间隔时间，每次递增1分钟
+		delay = time.Duration(n) * time.Minute
+	}
+	if delay > maxInterval {
+		delay = maxInterval
+	}
+	if taskModel.RetryJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(taskModel.RetryJitter)*1000+1)) * time.Millisecond
+	}
+
+	return delay
+}
+
+// 等待重试, 期间释放并发队列, 手动停止时返回ErrCanceled, 服务退出时返回errRetryShutdown
+func waitRetry(taskUniqueId int64, delay time.Duration) error {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	retryWaits.Store(taskUniqueId, cancel)
+	defer retryWaits.Delete(taskUniqueId)
+	concurrencyQueue.Done()
+	defer concurrencyQueue.Add()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// 取消等待中的重试, 任务不在等待重试时返回false
+func cancelRetryWait(taskUniqueId int64, reason error) bool {
+	cancel, ok := retryWaits.Load(taskUniqueId)
+	if ok {
+		cancel.(context.CancelCauseFunc)(reason)
+	}
+
+	return ok
+}
+
+// 服务退出时取消所有等待中的重试
+func cancelAllRetryWaits() {
+	retryWaits.Range(func(key, value interface{}) bool {
+		value.(context.CancelCauseFunc)(errRetryShutdown)
+		return true
+	})
+}
+
+// 判断失败的执行是否满足重试条件
+func shouldRetry(taskModel models.Task, output string, err error) bool {
+	if err == nil {
+		return false
+	}
+	// 手动停止的任务不再重试
+	if errors.Is(err, rpcClient.ErrCanceled) {
+		return false
+	}
+	if !taskModel.HasRetryCondition() {
+		return true
+	}
+	if taskModel.RetryOn&models.TaskRetryOnConnectionError != 0 && isConnectionError(err) {
+		return true
+	}
+	if taskModel.RetryOn&models.TaskRetryOnTimeout != 0 && isTimeoutError(err) {
+		return true
+	}
+	if taskModel.RetryExitCodes != "" {
+		ranges, _ := models.ParseCodeRanges(taskModel.RetryExitCodes)
+		if code, ok := exitCodeFromError(err); ok && ranges.Contains(code) {
+			return true
+		}
+	}
+	if taskModel.RetryStatusCodes != "" {
+		ranges, _ := models.ParseCodeRanges(taskModel.RetryStatusCodes)
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode > 0 && ranges.Contains(statusErr.StatusCode) {
+			return true
+		}
+	}
+	if taskModel.RetryOutputRegex != "" {
+		pattern, compileErr := regexp.Compile(taskModel.RetryOutputRegex)
+		if compileErr == nil && pattern.MatchString(output) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, rpcClient.ErrUnavailable) || errors.Is(err, rpcClient.ErrConnectionLost) {
+		return true
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+
+	return (errors.As(err, &opErr) && !opErr.Timeout()) || errors.As(err, &dnsErr)
+}
+
+func isTimeoutError(err error) bool {
+	if errors.Is(err, rpcClient.ErrTimeout) {
+		return true
+	}
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// 获取失败执行的退出码, 旧版本节点未返回退出码时从错误信息中解析, 如 exit status 2
+func exitCodeFromError(err error) (int, bool) {
+	var exitErr *ExitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code, true
+	}
+	matches := exitStatusPattern.FindStringSubmatch(err.Error())
+	if len(matches) != 2 {
+		return 0, false
+	}
+	code, convErr := strconv.Atoi(matches[1])
+
+	return code, convErr == nil
+}
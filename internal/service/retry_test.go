@@ -0,0 +1,182 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+)
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		name     string
+		task     models.Task
+		n        int
+		expected time.Duration
+	}{
+		{"fixed", models.Task{RetryInterval: 10}, 3, 10 * time.Second},
+		{"linear default", models.Task{}, 3, 3 * time.Minute},
+		{"exponential", models.Task{RetryBackoff: models.TaskRetryBackoffExponential, RetryInterval: 5, RetryMultiplier: 2}, 4, 40 * time.Second},
+		{"exponential default interval", models.Task{RetryBackoff: models.TaskRetryBackoffExponential, RetryMultiplier: 3}, 2, 3 * time.Minute},
+		{"capped", models.Task{RetryBackoff: models.TaskRetryBackoffExponential, RetryInterval: 5, RetryMultiplier: 2, RetryMaxInterval: 30}, 10, 30 * time.Second},
+		{"overflow", models.Task{RetryBackoff: models.TaskRetryBackoffExponential, RetryInterval: 3600, RetryMultiplier: 10}, 10, retryMaxDelay},
+		{"default cap", models.Task{RetryBackoff: models.TaskRetryBackoffExponential, RetryMultiplier: 2}, 6, retryMaxDelay},
+		{"linear default cap", models.Task{}, 20, retryMaxDelay},
+		{"max interval above default cap", models.Task{RetryBackoff: models.TaskRetryBackoffExponential, RetryMultiplier: 2, RetryMaxInterval: 3600}, 6, 32 * time.Minute},
+	}
+	for _, c := range cases {
+		if delay := retryDelay(c.task, c.n); delay != c.expected {
+			t.Fatalf("%s: expected %s, got %s", c.name, c.expected, delay)
+		}
+	}
+
+	task := models.Task{RetryInterval: 10, RetryJitter: 2}
+	for i := 0; i < 100; i++ {
+		delay := retryDelay(task, 1)
+		if delay < 10*time.Second || delay > 12*time.Second {
+			t.Fatalf("jitter out of range: %s", delay)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	cases := []struct {
+		name     string
+		task     models.Task
+		output   string
+		err      error
+		expected bool
+	}{
+		{"no error", models.Task{}, "", nil, false},
+		{"any error", models.Task{}, "", errors.New("boom"), true},
+		{"canceled", models.Task{}, "", rpcClient.ErrCanceled, false},
+		{"rpc unavailable", models.Task{RetryOn: models.TaskRetryOnConnectionError}, "", rpcClient.ErrUnavailable, true},
+		{"http connection", models.Task{RetryOn: models.TaskRetryOnConnectionError}, "", &HTTPStatusError{Err: connErr}, true},
+		{"connection only", models.Task{RetryOn: models.TaskRetryOnConnectionError}, "", errors.New("exit status 1"), false},
+		{"timeout", models.Task{RetryOn: models.TaskRetryOnTimeout}, "", rpcClient.ErrTimeout, true},
+		{"exit code", models.Task{RetryExitCodes: "2,100-110"}, "", errors.New("exit status 105"), true},
+		{"exit code mismatch", models.Task{RetryExitCodes: "2"}, "", errors.New("exit status 1"), false},
+		{"status code", models.Task{RetryStatusCodes: "429,500-599"}, "", &HTTPStatusError{StatusCode: 503}, true},
+		{"status code mismatch", models.Task{RetryStatusCodes: "500-599"}, "", &HTTPStatusError{StatusCode: 404}, false},
+		{"output regex", models.Task{RetryOutputRegex: `(?i)deadlock`}, "Deadlock found", errors.New("exit status 1"), true},
+	}
+	for _, c := range cases {
+		if got := shouldRetry(c.task, c.output, c.err); got != c.expected {
+			t.Fatalf("%s: expected %v, got %v", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestExecJobStopsWhenRetryConditionNotMet(t *testing.T) {
+	originalWait := retryWaitFunc
+	defer func() { retryWaitFunc = originalWait }()
+	var delays []time.Duration
+	retryWaitFunc = func(id int64, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	handler := &fakeHandler{
+		results: []handlerResponse{
+			{result: "first", err: &HTTPStatusError{StatusCode: 503}},
+			{result: "second", err: &HTTPStatusError{StatusCode: 404}},
+			{result: "third", err: nil},
+		},
+	}
+	task := models.Task{
+		Id: 3, RetryTimes: 5, RetryInterval: 1, RetryStatusCodes: "500-599",
+		RetryBackoff: models.TaskRetryBackoffExponential, RetryMultiplier: 2,
+	}
+	result := execJob(handler, task, 7)
+	if result.Err == nil || result.Result != "second" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.RetryTimes != 1 || handler.callCount != 2 {
+		t.Fatalf("expected 1 retry, got retryTimes=%d calls=%d", result.RetryTimes, handler.callCount)
+	}
+	if len(delays) != 1 || delays[0] != time.Second {
+		t.Fatalf("unexpected delays: %v", delays)
+	}
+	if len(result.Attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(result.Attempts))
+	}
+	first, second := result.Attempts[0], result.Attempts[1]
+	if first.TaskLogId != 7 || first.Attempt != 1 || first.Status != models.Failure || first.Result != "first" || first.Delay != 0 {
+		t.Fatalf("unexpected first attempt: %+v", first)
+	}
+	if second.Attempt != 2 || second.Delay != 1000 || second.Error != "HTTP状态码非200-->404" {
+		t.Fatalf("unexpected second attempt: %+v", second)
+	}
+}
+
+func TestWaitRetryReleasesSlotAndCancels(t *testing.T) {
+	originalQueue := concurrencyQueue
+	defer func() { concurrencyQueue = originalQueue }()
+	concurrencyQueue = ConcurrencyQueue{queue: make(chan struct{}, 1)}
+	concurrencyQueue.Add()
+
+	wait := func(id int64) <-chan error {
+		done := make(chan error, 1)
+		go func() { done <- waitRetry(id, time.Hour) }()
+		for i := 0; ; i++ {
+			if _, ok := retryWaits.Load(id); ok {
+				break
+			}
+			if i > 100 {
+				t.Fatal("retry wait was not registered")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return done
+	}
+
+	done := wait(61)
+	// 等待期间其他任务可以占用并发队列
+	select {
+	case concurrencyQueue.queue <- struct{}{}:
+		concurrencyQueue.Done()
+	default:
+		t.Fatal("retry wait should release the concurrency slot")
+	}
+	if (Task{}).StopRetry(62) {
+		t.Fatal("task not waiting for retry should not be stopped")
+	}
+	if !(Task{}).StopRetry(61) {
+		t.Fatal("expected retry wait to be stopped")
+	}
+	if err := <-done; !errors.Is(err, rpcClient.ErrCanceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if len(concurrencyQueue.queue) != 1 {
+		t.Fatal("concurrency slot should be reacquired after the wait")
+	}
+
+	done = wait(63)
+	cancelAllRetryWaits()
+	if err := <-done; !errors.Is(err, errRetryShutdown) {
+		t.Fatalf("expected shutdown, got %v", err)
+	}
+}
+
+func TestExecJobStoppedWhileWaitingForRetry(t *testing.T) {
+	originalWait := retryWaitFunc
+	defer func() { retryWaitFunc = originalWait }()
+	retryWaitFunc = func(id int64, d time.Duration) error {
+		return rpcClient.ErrCanceled
+	}
+
+	handler := &fakeHandler{
+		results: []handlerResponse{
+			{result: "first", err: errors.New("fail1")},
+			{result: "second", err: nil},
+		},
+	}
+	result := execJob(handler, models.Task{Id: 4, RetryTimes: 3, RetryInterval: 1}, 8)
+	if !errors.Is(result.Err, rpcClient.ErrCanceled) || handler.callCount != 1 || result.RetryTimes != 0 {
+		t.Fatalf("unexpected result: %+v, calls=%d", result, handler.callCount)
+	}
+}
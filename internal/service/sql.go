@@ -0,0 +1,208 @@
+package service
+
+// SQL任务, 在系统设置中配置的数据库连接上执行SQL
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+)
+
+var (
+	ErrSQLNoRows = errors.New("返回或影响的行数为0")
+
+	openSQLFunc           = models.OpenSQLConnection
+	findSQLConnectionFunc = func(id int) (models.SQLConnection, error) {
+		return new(models.Setting).SQLConnection(id)
+	}
+
+	// 运行中的SQL任务, 任务日志ID作为Key
+	sqlTasks sync.Map
+)
+
+// 测试数据库连接的超时时间
+const sqlPingTimeout = 10 * time.Second
+
+// 返回结果集的语句, 其余语句记录影响行数
+var sqlQueryKeywords = []string{"select", "with", "show", "describe", "desc", "explain", "pragma", "values", "table"}
+
+type SQLHandler struct{}
+
+func (h *SQLHandler) Run(taskModel models.Task, taskUniqueId int64) (string, error) {
+	connection, err := findSQLConnectionFunc(taskModel.SqlConnectionId)
+	if err != nil {
+		return "", fmt.Errorf("获取数据库连接配置失败-%s", err)
+	}
+	dsn, err := decryptSecret(connection.Dsn)
+	if err != nil {
+		return "", fmt.Errorf("数据库连接信息解密失败-%s", err)
+	}
+	timeout := taskModel.Timeout
+	if timeout <= 0 || timeout > localExecMaxTimeout {
+		timeout = localExecMaxTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	sqlTasks.Store(taskUniqueId, cancel)
+	defer sqlTasks.Delete(taskUniqueId)
+
+	logger.Infof("SQL任务开始执行#任务ID-%d#连接-%s#taskLogId-%d", taskModel.Id, connection.Name, taskUniqueId)
+	db, err := openSQLFunc(connection.Engine, dsn)
+	if err != nil {
+		return "", fmt.Errorf("连接数据库失败-%s", err)
+	}
+	defer db.Close()
+
+	output, count, err := execSQL(ctx, db, taskModel.Command, taskModel.SQLMaxRows())
+	// 超时或手动停止时使用与RPC任务相同的错误, 便于重试条件判断
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		err = rpcClient.ErrTimeout
+	case context.Canceled:
+		err = rpcClient.ErrCanceled
+	}
+	if err == nil && count == 0 && taskModel.SqlFailOnZero > 0 {
+		err = ErrSQLNoRows
+	}
+	logger.Infof("SQL任务执行完成#任务ID-%d#taskLogId-%d#行数-%d#错误-%v", taskModel.Id, taskUniqueId, count, err)
+
+	return output, err
+}
+
+// 执行SQL, 查询语句记录前maxRows行结果, 其他语句记录影响行数
+func execSQL(ctx context.Context, db *sql.DB, statement string, maxRows int) (string, int64, error) {
+	if !isQueryStatement(statement) {
+		result, err := db.ExecContext(ctx, statement)
+		if err != nil {
+			return "", 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return "", 0, err
+		}
+		return fmt.Sprintf("影响行数: %d", affected), affected, nil
+	}
+
+	rows, err := db.QueryContext(ctx, statement)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", 0, err
+	}
+	var output strings.Builder
+	output.WriteString(strings.Join(columns, "\t"))
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	var count int64
+	truncated := false
+	for rows.Next() {
+		// 多读取一行用于判断结果是否被截断
+		if count >= int64(maxRows) {
+			truncated = true
+			break
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return output.String(), count, err
+		}
+		output.WriteString("\n")
+		output.WriteString(formatSQLRow(values))
+		count++
+	}
+	if err = rows.Err(); err != nil {
+		return output.String(), count, err
+	}
+	if truncated {
+		output.WriteString(fmt.Sprintf("\n仅记录前%d行结果", maxRows))
+	} else {
+		output.WriteString(fmt.Sprintf("\n返回行数: %d", count))
+	}
+
+	return output.String(), count, nil
+}
+
+func formatSQLRow(values []interface{}) string {
+	fields := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+			fields[i] = "NULL"
+		case []byte:
+			fields[i] = string(v)
+		case time.Time:
+			fields[i] = v.Format(models.DefaultTimeFormat)
+		default:
+			fields[i] = fmt.Sprint(v)
+		}
+	}
+
+	return strings.Join(fields, "\t")
+}
+
+// 根据首个关键字判断是否为返回结果集的语句, 忽略开头的注释和括号
+func isQueryStatement(statement string) bool {
+	s := strings.TrimSpace(statement)
+	for {
+		switch {
+		case strings.HasPrefix(s, "--") || strings.HasPrefix(s, "#"):
+			index := strings.IndexByte(s, '\n')
+			if index < 0 {
+				return false
+			}
+			s = strings.TrimSpace(s[index+1:])
+		case strings.HasPrefix(s, "/*"):
+			index := strings.Index(s, "*/")
+			if index < 0 {
+				return false
+			}
+			s = strings.TrimSpace(s[index+2:])
+		case strings.HasPrefix(s, "("):
+			s = strings.TrimSpace(s[1:])
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			})
+			if end < 0 {
+				end = len(s)
+			}
+			keyword := strings.ToLower(s[:end])
+			for _, item := range sqlQueryKeywords {
+				if keyword == item {
+					return true
+				}
+			}
+			return false
+		}
+	}
+}
+
+// StopSQL 停止运行中的SQL任务, 取消正在执行的语句
+func (task Task) StopSQL(id int64) {
+	stopServerSideTask(&sqlTasks, id)
+}
+
+// TestSQLConnection 测试数据库连接是否可用
+func (task Task) TestSQLConnection(engine, dsn string) error {
+	db, err := openSQLFunc(engine, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), sqlPingTimeout)
+	defer cancel()
+
+	return db.PingContext(ctx)
+}
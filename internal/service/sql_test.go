@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	"github.com/gocronx-team/gocron/internal/modules/setting"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+)
+
+// 使用临时SQLite数据库作为SQL任务的连接
+func stubSQLConnection(t *testing.T) {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "sql_task.db")
+	db, err := models.OpenSQLConnection("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	_, err = db.Exec("CREATE TABLE job (id INTEGER PRIMARY KEY, name TEXT, done INTEGER); " +
+		"INSERT INTO job (name, done) VALUES ('a', 0), ('b', 0), ('c', 1)")
+	db.Close()
+	if err != nil {
+		t.Fatalf("prepare sqlite: %v", err)
+	}
+
+	originalSetting := app.Setting
+	originalFind := findSQLConnectionFunc
+	app.Setting = &setting.Setting{AuthSecret: "test-secret"}
+	encrypted, err := utils.AesEncrypt("test-secret", dsn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	findSQLConnectionFunc = func(id int) (models.SQLConnection, error) {
+		if id != 1 {
+			return models.SQLConnection{}, errors.New("record not found")
+		}
+		return models.SQLConnection{Id: 1, Name: "local", Engine: "sqlite", Dsn: encrypted}, nil
+	}
+	t.Cleanup(func() {
+		app.Setting = originalSetting
+		findSQLConnectionFunc = originalFind
+	})
+}
+
+func TestSQLHandlerQueryCapturesFirstRows(t *testing.T) {
+	stubSQLConnection(t)
+
+	task := models.Task{Protocol: models.TaskSQL, SqlConnectionId: 1, SqlMaxRows: 2, Command: "SELECT id, name FROM job ORDER BY id"}
+	output, err := (&SQLHandler{}).Run(task, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "id\tname\n1\ta\n2\tb\n仅记录前2行结果"
+	if output != expected {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+func TestSQLHandlerExecRowsAffected(t *testing.T) {
+	stubSQLConnection(t)
+
+	task := models.Task{Protocol: models.TaskSQL, SqlConnectionId: 1, Command: "UPDATE job SET done = 1 WHERE done = 0"}
+	output, err := (&SQLHandler{}).Run(task, 2)
+	if err != nil || output != "影响行数: 2" {
+		t.Fatalf("unexpected result: %q, %v", output, err)
+	}
+
+	task.SqlFailOnZero = 1
+	output, err = (&SQLHandler{}).Run(task, 3)
+	if !errors.Is(err, ErrSQLNoRows) || output != "影响行数: 0" {
+		t.Fatalf("expected zero rows failure, got %q, %v", output, err)
+	}
+}
+
+func TestSQLHandlerFailOnEmptyResult(t *testing.T) {
+	stubSQLConnection(t)
+
+	task := models.Task{Protocol: models.TaskSQL, SqlConnectionId: 1, SqlFailOnZero: 1, Command: "SELECT id FROM job WHERE name = 'x'"}
+	output, err := (&SQLHandler{}).Run(task, 4)
+	if !errors.Is(err, ErrSQLNoRows) || !strings.HasSuffix(output, "返回行数: 0") {
+		t.Fatalf("expected zero rows failure, got %q, %v", output, err)
+	}
+}
+
+func TestSQLHandlerMissingConnection(t *testing.T) {
+	stubSQLConnection(t)
+
+	_, err := (&SQLHandler{}).Run(models.Task{Protocol: models.TaskSQL, SqlConnectionId: 2, Command: "SELECT 1"}, 5)
+	if err == nil || !strings.Contains(err.Error(), "获取数据库连接配置失败") {
+		t.Fatalf("expected missing connection error, got %v", err)
+	}
+}
+
+const endlessSQL = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+
+func TestSQLHandlerTimeout(t *testing.T) {
+	stubSQLConnection(t)
+
+	task := models.Task{Protocol: models.TaskSQL, SqlConnectionId: 1, Timeout: 1, Command: endlessSQL}
+	_, err := (&SQLHandler{}).Run(task, 6)
+	if !errors.Is(err, rpcClient.ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestSQLHandlerStop(t *testing.T) {
+	stubSQLConnection(t)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := (&SQLHandler{}).Run(models.Task{Protocol: models.TaskSQL, SqlConnectionId: 1, Command: endlessSQL}, 7)
+		done <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := sqlTasks.Load(int64(7)); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sql task did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ServiceTask.StopSQL(7)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, rpcClient.ErrCanceled) {
+			t.Fatalf("expected canceled error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sql task was not stopped")
+	}
+}
+
+func TestIsQueryStatement(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT 1":                        true,
+		"  -- comment\nselect * from job": true,
+		"/* hint */ WITH t AS (SELECT 1) SELECT * FROM t": true,
+		"(SELECT 1) UNION (SELECT 2)":                     true,
+		"SHOW TABLES":                                     true,
+		"DELETE FROM job":                                 false,
+		"CALL cleanup()":                                  false,
+		"":                                                false,
+		"123":                                             false,
+	}
+	for statement, expected := range cases {
+		if isQueryStatement(statement) != expected {
+			t.Errorf("isQueryStatement(%q) expected %v", statement, expected)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+// SSH任务, 通过SSH在未部署gocron-node的主机上执行命令
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	"github.com/gocronx-team/gocron/internal/modules/logger"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	"github.com/gocronx-team/gocron/internal/modules/sshclient"
+)
+
+var (
+	sshExecFunc  = sshclient.Exec
+	findHostFunc = func(id int16) (models.Host, error) {
+		host := models.Host{}
+		err := host.Find(int(id))
+		return host, err
+	}
+
+	// 运行中的SSH任务, 任务日志ID作为Key
+	sshTasks sync.Map
+)
+
+type SSHHandler struct{}
+
+func (h *SSHHandler) Run(taskModel models.Task, taskUniqueId int64) (string, error) {
+	logger.Infof("SSH任务开始执行#任务ID-%d#主机数量-%d", taskModel.Id, len(taskModel.Hosts))
+	if len(taskModel.Hosts) == 0 {
+		return "", fmt.Errorf("任务未关联任何主机")
+	}
+	timeout := taskModel.Timeout
+	if timeout <= 0 || timeout > localExecMaxTimeout {
+		timeout = localExecMaxTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	sshTasks.Store(taskUniqueId, cancel)
+	defer sshTasks.Delete(taskUniqueId)
+
+	resultChan := make(chan TaskResult, len(taskModel.Hosts))
+	for _, taskHost := range taskModel.Hosts {
+		go func(th models.TaskHostDetail) {
+			host, output, err := h.runOnHost(ctx, th, taskModel.Command)
+			logger.Infof("SSH执行完成#主机-%s:%d#输出长度-%d#错误-%v", th.Name, host.SshPort, len(output), err)
+			resultChan <- TaskResult{Err: err, Result: hostOutputMessage(th, host.SshPort, output, err)}
+		}(taskHost)
+	}
+
+	return aggregateHostResults(resultChan, len(taskModel.Hosts))
+}
+
+func (h *SSHHandler) runOnHost(ctx context.Context, th models.TaskHostDetail, command string) (models.Host, string, error) {
+	host, err := findHostFunc(th.HostId)
+	if err != nil {
+		return host, "", fmt.Errorf("获取主机信息失败-%s", err)
+	}
+	config, err := sshConfig(host)
+	if err != nil {
+		return host, "", err
+	}
+	logger.Infof("准备执行SSH命令#主机-%s:%d#命令-%s", host.Name, host.SshPort, command)
+	output, err := sshExecFunc(ctx, config, command)
+	// 超时或手动停止时使用与RPC任务相同的错误, 便于重试条件判断
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		err = rpcClient.ErrTimeout
+	case errors.Is(err, context.Canceled):
+		err = rpcClient.ErrCanceled
+	}
+
+	return host, output, err
+}
+
+// 按主机配置生成SSH连接参数, 解密存储的密码或私钥
+func sshConfig(host models.Host) (sshclient.Config, error) {
+	config := sshclient.Config{
+		Host:    host.Name,
+		Port:    host.SshPort,
+		User:    host.SshUser,
+		HostKey: host.SshHostKey,
+	}
+	if !host.HasSSH() {
+		return config, errors.New("主机未配置SSH连接信息")
+	}
+	if app.Setting != nil {
+		config.KnownHostsFile = app.Setting.SSHKnownHosts
+	}
+	var err error
+	switch host.SshAuthType {
+	case models.HostSSHAuthPassword:
+		config.Password, err = decryptSecret(host.SshPassword)
+	case models.HostSSHAuthPrivateKey:
+		config.PrivateKey, err = decryptSecret(host.SshPrivateKey)
+	}
+	if err != nil {
+		return config, fmt.Errorf("SSH认证信息解密失败-%s", err)
+	}
+
+	return config, nil
+}
+
+// StopSSH 停止运行中的SSH任务, 关闭会话并结束远程进程组
+func (task Task) StopSSH(id int64) {
+	stopServerSideTask(&sshTasks, id)
+}
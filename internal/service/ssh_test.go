@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
+	rpcClient "github.com/gocronx-team/gocron/internal/modules/rpc/client"
+	"github.com/gocronx-team/gocron/internal/modules/setting"
+	"github.com/gocronx-team/gocron/internal/modules/sshclient"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
+)
+
+func stubSSH(t *testing.T, hosts map[int16]models.Host, exec func(ctx context.Context, config sshclient.Config, command string) (string, error)) {
+	t.Helper()
+	originalExec := sshExecFunc
+	originalFind := findHostFunc
+	originalSetting := app.Setting
+	app.Setting = &setting.Setting{AuthSecret: "test-secret", SSHKnownHosts: "/etc/ssh/known_hosts"}
+	sshExecFunc = exec
+	findHostFunc = func(id int16) (models.Host, error) {
+		host, ok := hosts[id]
+		if !ok {
+			return host, errors.New("record not found")
+		}
+		return host, nil
+	}
+	t.Cleanup(func() {
+		sshExecFunc = originalExec
+		findHostFunc = originalFind
+		app.Setting = originalSetting
+	})
+}
+
+func TestSSHHandlerRunFansOutToHosts(t *testing.T) {
+	password, err := utils.AesEncrypt("test-secret", "p@ss")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hosts := map[int16]models.Host{
+		1: {Id: 1, Name: "10.0.0.1", SshPort: 22, SshUser: "root", SshAuthType: models.HostSSHAuthPassword, SshPassword: password},
+		2: {Id: 2, Name: "10.0.0.2", SshPort: 2222, SshUser: "deploy", SshAuthType: models.HostSSHAuthPassword, SshPassword: password},
+	}
+	configs := make(chan sshclient.Config, 2)
+	stubSSH(t, hosts, func(ctx context.Context, config sshclient.Config, command string) (string, error) {
+		configs <- config
+		if config.Host == "10.0.0.2" {
+			return "partial", errors.New("exit status 1")
+		}
+		return "ok", nil
+	})
+
+	task := models.Task{
+		Protocol: models.TaskSSH,
+		Command:  "uptime",
+		Hosts: []models.TaskHostDetail{
+			{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1", Alias: "a"},
+			{TaskHost: models.TaskHost{HostId: 2}, Name: "10.0.0.2", Alias: "b"},
+		},
+	}
+	result, err := (&SSHHandler{}).Run(task, 1)
+	if err == nil || err.Error() != "exit status 1" {
+		t.Fatalf("expected error from failed host, got %v", err)
+	}
+	if !strings.Contains(result, "主机: [a-10.0.0.1:22]\nok") || !strings.Contains(result, "主机: [b-10.0.0.2:2222]\nexit status 1\npartial") {
+		t.Fatalf("unexpected aggregated result: %q", result)
+	}
+	close(configs)
+	for config := range configs {
+		if config.Password != "p@ss" || config.KnownHostsFile != "/etc/ssh/known_hosts" {
+			t.Fatalf("unexpected ssh config: %+v", config)
+		}
+	}
+}
+
+func TestSSHHandlerRequiresSSHConfig(t *testing.T) {
+	hosts := map[int16]models.Host{1: {Id: 1, Name: "10.0.0.1"}}
+	stubSSH(t, hosts, func(ctx context.Context, config sshclient.Config, command string) (string, error) {
+		t.Fatal("ssh should not be executed without config")
+		return "", nil
+	})
+
+	task := models.Task{Protocol: models.TaskSSH, Hosts: []models.TaskHostDetail{{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1"}}}
+	_, err := (&SSHHandler{}).Run(task, 2)
+	if err == nil || !strings.Contains(err.Error(), "未配置SSH") {
+		t.Fatalf("expected missing ssh config error, got %v", err)
+	}
+}
+
+func TestSSHHandlerStop(t *testing.T) {
+	hosts := map[int16]models.Host{1: {Id: 1, Name: "10.0.0.1", SshUser: "root", SshAuthType: models.HostSSHAuthPrivateKey}}
+	started := make(chan struct{})
+	stubSSH(t, hosts, func(ctx context.Context, config sshclient.Config, command string) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		task := models.Task{Protocol: models.TaskSSH, Hosts: []models.TaskHostDetail{{TaskHost: models.TaskHost{HostId: 1}, Name: "10.0.0.1"}}}
+		_, err := (&SSHHandler{}).Run(task, 3)
+		done <- err
+	}()
+	<-started
+	ServiceTask.StopSSH(3)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, rpcClient.ErrCanceled) {
+			t.Fatalf("expected canceled error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ssh task was not stopped")
+	}
+}
@@ -1,8 +1,13 @@
 package service
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -27,8 +32,17 @@ var (
 var (
 	httpGetFunc        = httpclient.Get
 	httpPostParamsFunc = httpclient.PostParams
+	httpRequestFunc    = httpclient.Request
 	notifyPushFunc     = notify.Push
 	sleepFunc          = time.Sleep
+	rpcExecFunc        = rpcExecStream
+	rpcFetchOutputFunc = rpcClient.FetchOutput
+	taskEnabledFunc    = taskEnabled
+	incrRunCountFunc   = new(models.Task).IncrRunCount
+	disableTaskFunc    = new(models.Task).Disable
+	runJobFunc         = runJob
+	rpcStopFunc        = rpcClient.Stop
+	orphanTaskLogFunc  = rpcClient.UpdateOrphanedTaskLog
 
 	// 定时任务调度管理器
 	serviceCron *cron.Cron
@@ -39,6 +53,9 @@ var (
 	// 任务计数-正在运行的任务
 	taskCount TaskCount
 
+	// 延迟启动中的任务
+	delayedRuns DelayedRuns
+
 	// 并发队列, 限制同时运行的任务数量
 	concurrencyQueue ConcurrencyQueue
 )
@@ -101,12 +118,64 @@ func (i *Instance) done(key int) {
 	i.m.Delete(key)
 }
 
+// 延迟启动中的任务, 任务ID作为Key, 从调度器移除任务时取消等待
+type DelayedRuns struct {
+	mu   sync.Mutex
+	runs map[int]*delayedRun
+}
+
+type delayedRun struct {
+	cancel chan struct{}
+	count  int
+}
+
+// 登记延迟启动, single为true(单实例任务)且已有等待中的实例时返回false
+func (d *DelayedRuns) add(key int, single bool) (*delayedRun, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.runs == nil {
+		d.runs = make(map[int]*delayedRun)
+	}
+	run, ok := d.runs[key]
+	if !ok {
+		run = &delayedRun{cancel: make(chan struct{})}
+		d.runs[key] = run
+	}
+	if single && run.count > 0 {
+		return nil, false
+	}
+	run.count++
+
+	return run, true
+}
+
+func (d *DelayedRuns) done(key int, run *delayedRun) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	run.count--
+	if run.count == 0 && d.runs[key] == run {
+		delete(d.runs, key)
+	}
+}
+
+// 取消任务所有等待中的延迟启动
+func (d *DelayedRuns) cancel(key int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if run, ok := d.runs[key]; ok {
+		close(run.cancel)
+		delete(d.runs, key)
+	}
+}
+
 type Task struct{}
 
 type TaskResult struct {
 	Result     string
 	Err        error
 	RetryTimes int8
+	Attempts   []models.TaskLogAttempt // 开启重试时每次执行的记录
+	Detail     *ExecDetail             // 最后一次执行的命令执行详情, 未返回时为nil
 }
 
 // 初始化任务, 从数据库取出所有任务, 添加到定时任务并运行
@@ -117,6 +186,9 @@ func (task Task) Initialize() {
 	taskCount = TaskCount{sync.WaitGroup{}, make(chan struct{})}
 	go taskCount.Wait()
 
+	LoadProcessExecutors(app.Setting.ExecutorDir)
+	task.restoreOfflineHosts()
+
 	logger.Info("开始初始化定时任务")
 	taskModel := new(models.Task)
 	taskNum := 0
@@ -142,6 +214,10 @@ func (task Task) Initialize() {
 
 	// 添加日志自动清理任务
 	task.initLogCleanupTask()
+	// 添加主机心跳检测任务
+	task.initHeartbeatTask()
+	// 核对重启前运行中的RPC任务
+	go task.reconcileRunningLogs()
 }
 
 // 初始化日志清理任务
@@ -201,49 +277,116 @@ func (task Task) Add(taskModel models.Task) {
 		logger.Errorf("添加任务失败#不允许添加子任务到调度器#任务Id-%d", taskModel.Id)
 		return
 	}
-	taskFunc := createJob(taskModel)
-	if taskFunc == nil {
-		logger.Error("创建任务处理Job失败,不支持的任务协议#", taskModel.Protocol)
+	if taskModel.Expired(time.Now()) {
+		logger.Infof("任务有效期已结束, 不添加到调度器#任务Id-%d", taskModel.Id)
 		return
 	}
+	if taskModel.RunCountExhausted() {
+		// 执行次数已用完的任务被重新启用, 重新开始计数
+		_, _ = taskModel.Update(taskModel.Id, models.CommonMap{"run_count": 0})
+		taskModel.RunCount = 0
+	}
 
-	cronName := strconv.Itoa(taskModel.Id)
-	err := utils.PanicToError(func() {
-		serviceCron.AddFunc(taskModel.Spec, taskFunc, cronName)
-	})
-	if err != nil {
-		logger.Error("添加任务到调度器失败#", err)
+	for i, spec := range taskModel.Specs() {
+		item := taskModel
+		item.Spec = spec
+		taskFunc := createScheduledJob(item)
+		if taskFunc == nil {
+			logger.Error("创建任务处理Job失败,不支持的任务协议#", taskModel.Protocol)
+			return
+		}
+
+		cronName := cronEntryName(taskModel.Id, i)
+		err := utils.PanicToError(func() {
+			serviceCron.AddFunc(spec, taskFunc, cronName)
+		})
+		if err != nil {
+			logger.Error("添加任务到调度器失败#", err)
+		}
 	}
 }
 
+// 调度器中的任务名称, 第一个cron表达式使用任务ID, 其余追加序号
+func cronEntryName(taskId int, index int) string {
+	if index == 0 {
+		return strconv.Itoa(taskId)
+	}
+
+	return fmt.Sprintf("%d#%d", taskId, index)
+}
+
+// 判断调度器中的任务名称是否属于指定任务
+func isTaskCronEntry(name string, taskId int) bool {
+	taskName := strconv.Itoa(taskId)
+
+	return name == taskName || strings.HasPrefix(name, taskName+"#")
+}
+
+// 下次执行时间, 多个cron表达式取最早的一个
 func (task Task) NextRunTime(taskModel models.Task) time.Time {
 	if taskModel.Level != models.TaskLevelParent ||
 		taskModel.Status != models.Enabled {
 		return time.Time{}
 	}
-	entries := serviceCron.Entries()
-	taskName := strconv.Itoa(taskModel.Id)
-	for _, item := range entries {
-		if item.Name == taskName {
-			return item.Next
+	var next time.Time
+	for _, item := range serviceCron.Entries() {
+		if !isTaskCronEntry(item.Name, taskModel.Id) {
+			continue
+		}
+		itemNext := item.Next
+		// 有效期尚未开始, 从开始时间起计算
+		if taskModel.ValidFrom != nil && itemNext.Before(time.Time(*taskModel.ValidFrom)) {
+			itemNext = item.Schedule.Next(time.Time(*taskModel.ValidFrom).Add(-time.Second))
+		}
+		if itemNext.IsZero() || taskModel.Expired(itemNext) {
+			continue
+		}
+		if next.IsZero() || itemNext.Before(next) {
+			next = itemNext
 		}
 	}
 
-	return time.Time{}
+	return next
 }
 
-// 停止运行中的任务
-func (task Task) Stop(ip string, port int, id int64) {
-	rpcClient.Stop(ip, port, id)
+// Stop 停止各主机上运行中的任务, signal为空时使用任务指定的停止信号
+// 单主机执行或按标签选择的任务只在部分主机上运行, 所有主机上均未找到时才视为重启后丢失的任务
+func (task Task) Stop(hosts []models.TaskHostDetail, id int64, signal string) {
+	found := false
+	for _, host := range hosts {
+		if rpcStopFunc(host.Name, host.Port, id, signal) {
+			found = true
+		}
+	}
+	if !found {
+		orphanTaskLogFunc(id)
+	}
 }
 
+// StopRetry 停止等待重试中的任务, 任务不在等待重试时返回false
+func (task Task) StopRetry(id int64) bool {
+	return cancelRetryWait(id, rpcClient.ErrCanceled)
+}
+
+// Remove 从调度器移除任务, 并取消延迟启动中的执行
 func (task Task) Remove(id int) {
-	serviceCron.RemoveJob(strconv.Itoa(id))
+	removeCronEntries(id)
+	delayedRuns.cancel(id)
+}
+
+// 仅从调度器移除任务, 已调度的执行(包括延迟启动中的)继续进行
+func removeCronEntries(id int) {
+	for _, item := range serviceCron.Entries() {
+		if isTaskCronEntry(item.Name, id) {
+			serviceCron.RemoveJob(item.Name)
+		}
+	}
 }
 
 // 等待所有任务结束后退出
 func (task Task) WaitAndExit() {
 	serviceCron.Stop()
+	cancelAllRetryWaits()
 	taskCount.Exit()
 }
 
@@ -262,12 +405,19 @@ type HTTPHandler struct{}
 // http任务执行时间不超过300秒
 const HttpExecTimeout = 300
 
+const httpSkipVerifyWarning = "警告: 已跳过TLS证书校验"
+
 func (h *HTTPHandler) Run(taskModel models.Task, taskUniqueId int64) (result string, err error) {
 	if taskModel.Timeout <= 0 || taskModel.Timeout > HttpExecTimeout {
 		taskModel.Timeout = HttpExecTimeout
 	}
 	var resp httpclient.ResponseWrapper
-	if taskModel.HttpMethod == models.TaskHTTPMethodGet {
+	if taskModel.HasHTTPOptions() {
+		resp, err = h.request(taskModel, taskUniqueId)
+		if err != nil {
+			return "", err
+		}
+	} else if taskModel.HttpMethod == models.TaskHTTPMethodGet {
 		resp = httpGetFunc(taskModel.Command, taskModel.Timeout)
 	} else {
 		urlFields := strings.Split(taskModel.Command, "?")
@@ -278,14 +428,106 @@ func (h *HTTPHandler) Run(taskModel models.Task, taskUniqueId int64) (result str
 		}
 		resp = httpPostParamsFunc(taskModel.Command, params, taskModel.Timeout)
 	}
-	// 返回状态码非200，均为失败
-	if resp.StatusCode != http.StatusOK {
-		return resp.Body, fmt.Errorf("HTTP状态码非200-->%d", resp.StatusCode)
+	result = resp.Body
+	err = checkHTTPResponse(taskModel, resp)
+	if err != nil && taskModel.HasHTTPAssertions() {
+		// 配置了成功条件时, 在结果中展示未通过的断言
+		result = fmt.Sprintf("%s\n%s", err, result)
+	}
+	if taskModel.HttpSkipVerify > 0 {
+		result = httpSkipVerifyWarning + "\n" + result
+	}
+
+	return result, err
+}
+
+// 按任务配置的请求头、查询参数、请求体和认证方式发送请求
+func (h *HTTPHandler) request(taskModel models.Task, taskUniqueId int64) (httpclient.ResponseWrapper, error) {
+	var resp httpclient.ResponseWrapper
+	requestURL, err := url.Parse(taskModel.Command)
+	if err != nil {
+		return resp, fmt.Errorf("URL格式错误-%s", err)
+	}
+	query, err := models.ParseHTTPParams(taskModel.HttpQuery)
+	if err != nil {
+		return resp, fmt.Errorf("查询参数格式错误-%s", err)
+	}
+	if len(query) > 0 {
+		values := requestURL.Query()
+		for _, param := range query {
+			values.Set(param.Name, param.Value)
+		}
+		requestURL.RawQuery = values.Encode()
+	}
+
+	headers := make(http.Header)
+	params, err := models.ParseHTTPParams(taskModel.HttpHeaders)
+	if err != nil {
+		return resp, fmt.Errorf("请求头格式错误-%s", err)
+	}
+	for _, param := range params {
+		headers.Add(param.Name, param.Value)
+	}
+	body := taskModel.HttpBody
+	if taskModel.HttpMethod == models.TaskHTTPMethodHead || taskModel.HttpMethod == models.TaskHTTPMethodGet {
+		body = ""
+	}
+	if body != "" && taskModel.HttpContentType != "" {
+		headers.Set("Content-Type", taskModel.HttpContentType)
+	}
+	if taskModel.HttpAuthType != models.TaskHTTPAuthNone {
+		secret, err := decryptSecret(taskModel.HttpAuthSecret)
+		if err != nil {
+			return resp, fmt.Errorf("HTTP认证信息解密失败-%s", err)
+		}
+		switch taskModel.HttpAuthType {
+		case models.TaskHTTPAuthBasic:
+			credentials := base64.StdEncoding.EncodeToString([]byte(taskModel.HttpAuthUser + ":" + secret))
+			headers.Set("Authorization", "Basic "+credentials)
+		case models.TaskHTTPAuthBearer:
+			headers.Set("Authorization", "Bearer "+secret)
+		}
+	}
+
+	clientKey, err := decryptSecret(taskModel.HttpClientKey)
+	if err != nil {
+		return resp, fmt.Errorf("客户端私钥解密失败-%s", err)
+	}
+	options := httpclient.Options{
+		CACert:             taskModel.HttpCaCert,
+		ClientCert:         taskModel.HttpClientCert,
+		ClientKey:          clientKey,
+		InsecureSkipVerify: taskModel.HttpSkipVerify > 0,
+		DisableRedirect:    taskModel.HttpNoRedirect > 0,
+		Proxy:              taskModel.HttpProxy,
+	}
+	if options.InsecureSkipVerify {
+		logger.Warnf("HTTP任务已跳过TLS证书校验, 存在安全风险#任务ID-%d#URL-%s", taskModel.Id, taskModel.Command)
+	}
+	if taskModel.HttpAsync > 0 {
+		if err = prepareAsyncCallback(taskUniqueId, headers); err != nil {
+			return resp, err
+		}
+	}
+
+	return httpRequestFunc(taskModel.HttpMethod.String(), requestURL.String(), body, headers, taskModel.Timeout, options), nil
+}
+
+// 解密任务中加密存储的凭据
+func decryptSecret(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
 	}
 
-	return resp.Body, err
+	return utils.AesDecrypt(app.Setting.AuthSecret, secret)
 }
 
+// 不支持脚本任务的gocron-node执行的命令
+const scriptUnsupportedCommand = "echo gocron-node版本过低, 不支持脚本任务, 请升级 1>&2 && exit 1"
+
+// 不支持执行用户、工作目录及环境变量的gocron-node执行的命令
+const execOptionsUnsupportedCommand = "echo gocron-node版本过低, 不支持执行用户、工作目录及环境变量, 请升级 1>&2 && exit 1"
+
 // RPC调用执行任务
 type RPCHandler struct{}
 
@@ -298,30 +540,81 @@ func (h *RPCHandler) Run(taskModel models.Task, taskUniqueId int64) (result stri
 	taskRequest.Timeout = int32(taskModel.Timeout)
 	taskRequest.Command = taskModel.Command
 	taskRequest.Id = taskUniqueId
+	taskRequest.Env, _ = models.ParseTaskEnv(taskModel.Env)
+	taskRequest.WorkDir = taskModel.WorkDir
+	taskRequest.User = taskModel.RunAsUser
+	taskRequest.Group = taskModel.RunAsGroup
+	taskRequest.OutputLimit = int64(taskModel.OutputLimit) * 1024
+	taskRequest.SaveOutput = taskModel.SaveOutput > 0
+	taskRequest.StopSignal = taskModel.StopSignal
+	taskRequest.GracePeriod = int32(taskModel.StopGracePeriod)
+	taskRequest.Detached = taskModel.Detached > 0
+	if taskModel.IsScript() {
+		// 旧版本gocron-node忽略脚本字段, 执行command时返回错误
+		taskRequest.Command = scriptUnsupportedCommand
+		taskRequest.Script = taskModel.Script
+		taskRequest.Interpreter = taskModel.ScriptInterpreter
+		taskRequest.Args = taskModel.Command
+	}
+	if hasExecOptions(taskRequest) {
+		// 旧版本gocron-node忽略执行用户等选项, 会以节点自身的用户执行, 实际的命令和脚本放在其不读取的字段中
+		if taskRequest.Script != "" {
+			taskRequest.ExecScript, taskRequest.Script = taskRequest.Script, ""
+		} else {
+			taskRequest.ExecCommand = taskRequest.Command
+		}
+		taskRequest.Command = execOptionsUnsupportedCommand
+	}
+	if taskModel.DispatchMode == models.TaskDispatchSingle {
+		return h.runOnSingleHost(taskModel, taskRequest)
+	}
 	resultChan := make(chan TaskResult, len(taskModel.Hosts))
 	for _, taskHost := range taskModel.Hosts {
 		logger.Infof("准备执行RPC调用#主机-%s:%d#命令-%s", taskHost.Name, taskHost.Port, taskModel.Command)
 		go func(th models.TaskHostDetail) {
-			output, err := rpcClient.Exec(th.Name, th.Port, taskRequest)
-			errorMessage := ""
-			if err != nil {
-				errorMessage = err.Error()
-			}
-			output = strings.TrimSpace(output)
-			if errorMessage != "" {
-				errorMessage = strings.TrimSpace(errorMessage) + "\n"
-			}
-			outputMessage := fmt.Sprintf("主机: [%s-%s:%d]\n%s%s",
-				th.Alias, th.Name, th.Port, errorMessage, output,
-			)
+			output, err := h.exec(taskModel, th, taskRequest)
 			logger.Infof("RPC调用完成#主机-%s:%d#输出长度-%d#错误-%v", th.Name, th.Port, len(output), err)
-			resultChan <- TaskResult{Err: err, Result: outputMessage}
+			resultChan <- TaskResult{Err: err, Result: hostOutputMessage(th, th.Port, output, err)}
 		}(taskHost)
 	}
 
+	return aggregateHostResults(resultChan, len(taskModel.Hosts))
+}
+
+// 是否设置了执行用户、用户组、工作目录或环境变量
+func hasExecOptions(taskRequest *pb.TaskRequest) bool {
+	return taskRequest.User != "" || taskRequest.Group != "" || taskRequest.WorkDir != "" || len(taskRequest.Env) > 0
+}
+
+// 在一台主机上执行, 记录执行详情并按成功规则判断结果, 离线主机直接返回错误
+func (h *RPCHandler) exec(taskModel models.Task, th models.TaskHostDetail, taskRequest *pb.TaskRequest) (string, error) {
+	if hostHealthStatus.isOffline(th.HostId) {
+		return "", ErrHostOffline
+	}
+	resp, err := rpcExecFunc(th.Name, th.Port, taskRequest)
+
+	return recordHostResult(taskModel, th, taskRequest.Id, resp, err)
+}
+
+// 记录一台主机返回的执行详情, 按任务的成功规则判断执行结果
+func recordHostResult(taskModel models.Task, th models.TaskHostDetail, id int64, resp *pb.TaskResponse, err error) (string, error) {
+	if detail, ok := detailFromResponse(resp); ok {
+		host := fmt.Sprintf("%s-%s:%d", th.Alias, th.Name, th.Port)
+		if resp.OutputFile != "" {
+			detail.OutputFiles = []models.TaskLogOutputFile{{HostId: th.HostId, Host: host, File: resp.OutputFile}}
+		}
+		err = checkExecDetail(taskModel, detail, err)
+		execDetails.record(id, host, detail)
+	}
+
+	return resp.GetOutput(), err
+}
+
+// 汇总多台主机的执行结果, 任一主机失败则任务失败
+func aggregateHostResults(resultChan <-chan TaskResult, count int) (string, error) {
 	var aggregationErr error = nil
 	aggregationResult := ""
-	for i := 0; i < len(taskModel.Hosts); i++ {
+	for i := 0; i < count; i++ {
 		taskResult := <-resultChan
 		aggregationResult += taskResult.Result
 		if taskResult.Err != nil {
@@ -332,8 +625,51 @@ func (h *RPCHandler) Run(taskModel models.Task, taskUniqueId int64) (result stri
 	return aggregationResult, aggregationErr
 }
 
+// 单台主机的输出, 以主机信息开头
+func hostOutputMessage(th models.TaskHostDetail, port int, output string, err error) string {
+	errorMessage := ""
+	if err != nil {
+		errorMessage = strings.TrimSpace(err.Error()) + "\n"
+	}
+
+	return fmt.Sprintf("主机: [%s-%s:%d]\n%s%s", th.Alias, th.Name, port, errorMessage, strings.TrimSpace(output))
+}
+
+// 选择一台健康主机执行, 主机不可达时标记为不健康并立即切换到下一台
+func (h *RPCHandler) runOnSingleHost(taskModel models.Task, taskRequest *pb.TaskRequest) (string, error) {
+	var output string
+	var err error
+	failover := ""
+	for _, th := range hostHealthStatus.orderHosts(taskModel.Hosts) {
+		logger.Infof("准备执行RPC调用#主机-%s:%d#命令-%s", th.Name, th.Port, taskModel.Command)
+		output, err = h.exec(taskModel, th, taskRequest)
+		if errors.Is(err, rpcClient.ErrUnavailable) {
+			logger.Warnf("主机不可达, 切换主机#任务ID-%d#主机-%s:%d", taskModel.Id, th.Name, th.Port)
+			hostHealthStatus.markUnhealthy(th.HostId)
+			failover += fmt.Sprintf("主机: [%s-%s:%d] %s, 切换主机\n", th.Alias, th.Name, th.Port, err.Error())
+			continue
+		}
+		// 任务开始后连接中断时可能已部分执行, 不切换主机重新执行
+		if errors.Is(err, rpcClient.ErrConnectionLost) {
+			hostHealthStatus.markUnhealthy(th.HostId)
+		} else {
+			hostHealthStatus.markHealthy(th.HostId)
+		}
+		errorMessage := ""
+		if err != nil {
+			errorMessage = strings.TrimSpace(err.Error()) + "\n"
+		}
+		outputMessage := fmt.Sprintf("%s主机: [%s-%s:%d]\n%s%s",
+			failover, th.Alias, th.Name, th.Port, errorMessage, strings.TrimSpace(output),
+		)
+		return outputMessage, err
+	}
+
+	return strings.TrimSpace(failover), err
+}
+
 // 创建任务日志
-func createTaskLog(taskModel models.Task, status models.Status) (int64, error) {
+func createTaskLog(taskModel models.Task, status models.Status, startOffset time.Duration) (int64, error) {
 	taskLogModel := new(models.TaskLog)
 	taskLogModel.TaskId = taskModel.Id
 	taskLogModel.Name = taskModel.Name
@@ -341,14 +677,21 @@ func createTaskLog(taskModel models.Task, status models.Status) (int64, error) {
 	taskLogModel.Protocol = taskModel.Protocol
 	taskLogModel.Command = taskModel.Command
 	taskLogModel.Timeout = taskModel.Timeout
-	if taskModel.Protocol == models.TaskRPC {
+	if taskModel.IsScript() {
+		taskLogModel.ScriptVersion = taskModel.ScriptVersion
+	}
+	if taskModel.UseHosts() {
 		aggregationHost := ""
 		for _, host := range taskModel.Hosts {
 			aggregationHost += fmt.Sprintf("%s - %s<br>", host.Alias, host.Name)
 		}
 		taskLogModel.Hostname = aggregationHost
+		taskLogModel.HostIds = models.FormatHostIds(taskModel.Hosts)
+	} else if taskModel.Protocol == models.TaskLocal {
+		taskLogModel.Hostname = localHostname()
 	}
 	taskLogModel.StartTime = models.LocalTime(time.Now())
+	taskLogModel.StartOffset = int(startOffset / time.Millisecond)
 	taskLogModel.Status = status
 	insertId, err := taskLogModel.Create()
 
@@ -365,12 +708,32 @@ func updateTaskLog(taskLogId int64, taskResult TaskResult) (int64, error) {
 	} else {
 		status = models.Finish
 	}
-	return taskLogModel.Update(taskLogId, models.CommonMap{
+	data := models.CommonMap{
 		"retry_times": taskResult.RetryTimes,
 		"status":      status,
 		"result":      result,
 		"end_time":    time.Now(),
-	})
+	}
+	if detail := taskResult.Detail; detail != nil {
+		data["exit_code"] = detail.ExitCode
+		data["stdout"] = detail.Stdout
+		data["stderr"] = detail.Stderr
+		data["wall_time"] = detail.WallTime
+		data["user_time"] = detail.UserTime
+		data["system_time"] = detail.SystemTime
+		data["max_rss"] = detail.MaxRss
+		if detail.Truncated {
+			data["truncated"] = 1
+		}
+		if detail.Signal != "" {
+			data["end_signal"] = detail.Signal
+		}
+		if len(detail.OutputFiles) > 0 {
+			outputFiles, _ := json.Marshal(detail.OutputFiles)
+			data["output_files"] = string(outputFiles)
+		}
+	}
+	return taskLogModel.Update(taskLogId, data)
 
 }
 
@@ -381,52 +744,228 @@ func createJob(taskModel models.Task) cron.FuncJob {
 		return nil
 	}
 	taskFunc := func() {
-		logger.Infof("任务闭包执行#ID-%d#名称-%s#主机数量-%d", taskModel.Id, taskModel.Name, len(taskModel.Hosts))
-		taskCount.Add()
-		defer taskCount.Done()
+		runJob(handler, taskModel, 0)
+	}
 
-		taskLogId := beforeExecJob(taskModel)
-		if taskLogId <= 0 {
-			return
+	return taskFunc
+}
+
+// 执行任务, startOffset为执行前已等待的启动偏移, 记录到任务日志
+func runJob(handler Handler, taskModel models.Task, startOffset time.Duration) {
+	// 按标签选择器匹配执行时的主机
+	if err := taskModel.ResolveHosts(); err != nil {
+		logger.Errorf("按标签选择器匹配主机失败#任务ID-%d#选择器-%s#%s", taskModel.Id, taskModel.HostSelector, err)
+	}
+	logger.Infof("任务闭包执行#ID-%d#名称-%s#主机数量-%d", taskModel.Id, taskModel.Name, len(taskModel.Hosts))
+	taskCount.Add()
+	counting := true
+	defer func() {
+		if counting {
+			taskCount.Done()
 		}
+	}()
 
-		if taskModel.Multi == 0 {
-			runInstance.add(taskModel.Id)
-			defer runInstance.done(taskModel.Id)
+	taskLogId := beforeExecJob(taskModel, startOffset)
+	if taskLogId <= 0 {
+		return
+	}
+
+	if taskModel.Multi == 0 {
+		runInstance.add(taskModel.Id)
+		defer runInstance.done(taskModel.Id)
+	}
+
+	concurrencyQueue.Add()
+	defer concurrencyQueue.Done()
+
+	logger.Infof("开始执行任务#%s#命令-%s", taskModel.Name, taskModel.Command)
+	taskResult := execJob(handler, taskModel, taskLogId)
+	if taskModel.IsHTTPAsync() {
+		defer asyncWaiters.Delete(taskLogId)
+		if taskResult.Err == nil {
+			// 等待回调期间不阻塞服务退出, 退出后到达的回调直接完成任务日志
+			taskCount.Done()
+			counting = false
+			taskResult = waitAsyncCallback(taskModel, taskLogId, taskResult)
 		}
+	}
+	logger.Infof("任务完成#%s#命令-%s", taskModel.Name, taskModel.Command)
+	afterExecJob(taskModel, taskResult, taskLogId)
+}
 
-		concurrencyQueue.Add()
-		defer concurrencyQueue.Done()
+// 创建定时调度的任务Job, 执行前检查有效期、日历和最大执行次数, 并按配置延迟启动
+func createScheduledJob(taskModel models.Task) cron.FuncJob {
+	logger.Infof("创建任务Job#ID-%d#名称-%s#主机数量-%d", taskModel.Id, taskModel.Name, len(taskModel.Hosts))
+	handler := createHandler(taskModel)
+	if handler == nil {
+		return nil
+	}
 
-		logger.Infof("开始执行任务#%s#命令-%s", taskModel.Name, taskModel.Command)
-		taskResult := execJob(handler, taskModel, taskLogId)
-		logger.Infof("任务完成#%s#命令-%s", taskModel.Name, taskModel.Command)
-		afterExecJob(taskModel, taskResult, taskLogId)
+	return func() {
+		now := time.Now()
+		if taskModel.Expired(now) {
+			logger.Infof("任务有效期已结束, 从调度器移除#ID-%d", taskModel.Id)
+			removeCronEntries(taskModel.Id)
+			return
+		}
+		if !taskModel.InValidPeriod(now) {
+			return
+		}
+		reason, err := calendarSkipReason(taskModel, now)
+		if err != nil {
+			// 无法确认是否为排除日期时不执行, 避免在节假日误执行
+			logger.Errorf("检查任务日历失败, 跳过本次执行#ID-%d#%s", taskModel.Id, err)
+			createSkippedTaskLog(taskModel, models.Failure, "检查任务日历失败, 跳过本次执行: "+err.Error())
+			return
+		}
+		if reason != "" {
+			logger.Infof("%s, 跳过本次执行#ID-%d", reason, taskModel.Id)
+			createSkippedTaskLog(taskModel, models.Cancel, reason)
+			return
+		}
+		final := false
+		if taskModel.MaxRunCount > 0 {
+			runCount, err := incrRunCountFunc(taskModel.Id)
+			if err != nil {
+				logger.Errorf("更新任务执行次数失败#ID-%d#%s", taskModel.Id, err)
+			} else if runCount >= taskModel.MaxRunCount {
+				logger.Infof("任务已达到最大执行次数%d, 自动禁用#ID-%d", taskModel.MaxRunCount, taskModel.Id)
+				removeCronEntries(taskModel.Id)
+				_, _ = disableTaskFunc(taskModel.Id)
+				if runCount > taskModel.MaxRunCount {
+					return
+				}
+				final = true
+			}
+		}
+		offset := startOffset(taskModel)
+		if offset > 0 {
+			runDelayedJob(handler, taskModel, offset, final)
+			return
+		}
+		runJobFunc(handler, taskModel, 0)
 	}
+}
 
-	return taskFunc
+// 延迟启动任务, 等待期间计入运行中的任务, 服务退出时等待其完成
+// 任务被移除(删除、禁用、修改)时取消等待, 等待结束后重新检查任务是否仍启用
+// final为true时为最后一次允许的执行, 任务已被自动禁用, 等待结束后不再检查
+func runDelayedJob(handler Handler, taskModel models.Task, offset time.Duration, final bool) {
+	single := taskModel.Multi == 0
+	if single && runInstance.has(taskModel.Id) {
+		logger.Infof("任务已在运行中，取消本次执行#ID-%d", taskModel.Id)
+		_, _ = createTaskLog(taskModel, models.Cancel, offset)
+		return
+	}
+	run, ok := delayedRuns.add(taskModel.Id, single)
+	if !ok {
+		logger.Infof("任务已有延迟启动中的实例，取消本次执行#ID-%d", taskModel.Id)
+		_, _ = createTaskLog(taskModel, models.Cancel, offset)
+		return
+	}
+	taskCount.Add()
+	defer taskCount.Done()
+
+	logger.Infof("任务延迟%s启动#ID-%d", offset, taskModel.Id)
+	timer := time.NewTimer(offset)
+	select {
+	case <-timer.C:
+		delayedRuns.done(taskModel.Id, run)
+	case <-run.cancel:
+		timer.Stop()
+		delayedRuns.done(taskModel.Id, run)
+		logger.Infof("任务已从调度器移除，取消延迟启动#ID-%d", taskModel.Id)
+		return
+	}
+	if !final && !taskEnabledFunc(taskModel.Id) {
+		logger.Infof("任务已禁用或删除，取消延迟启动#ID-%d", taskModel.Id)
+		return
+	}
+	runJobFunc(handler, taskModel, offset)
+}
+
+// 任务是否仍处于启用状态
+func taskEnabled(id int) bool {
+	taskModel := new(models.Task)
+	status, err := taskModel.GetStatus(id)
+	if err != nil {
+		logger.Errorf("获取任务状态失败#ID-%d#%s", id, err)
+		return false
+	}
+
+	return status == models.Enabled
+}
+
+// 计算本次调度的启动偏移: 固定延迟加上[0, 抖动窗口]内的随机时间, 精确到毫秒
+func startOffset(taskModel models.Task) time.Duration {
+	offset := time.Duration(taskModel.DelaySeconds) * time.Second
+	if taskModel.JitterSeconds > 0 {
+		jitter := rand.Int63n(int64(taskModel.JitterSeconds)*1000 + 1)
+		offset += time.Duration(jitter) * time.Millisecond
+	}
+
+	return offset
+}
+
+// 按任务关联的日历判断是否跳过本次调度, 返回跳过原因, 空字符串表示允许执行
+// 日历读取或解析失败时返回错误, 调用方应跳过本次调度
+func calendarSkipReason(taskModel models.Task, t time.Time) (string, error) {
+	if taskModel.CalendarId <= 0 {
+		return "", nil
+	}
+	calendarModel := new(models.Calendar)
+	err := calendarModel.Find(taskModel.CalendarId)
+	if err != nil {
+		return "", fmt.Errorf("获取日历失败#日历ID-%d#%w", taskModel.CalendarId, err)
+	}
+	allowed, err := calendarModel.Allow(taskModel.CalendarMode, t)
+	if err != nil {
+		return "", fmt.Errorf("日历[%s]日期解析失败#%w", calendarModel.Name, err)
+	}
+	if allowed {
+		return "", nil
+	}
+	if taskModel.CalendarMode == models.TaskCalendarModeInclude {
+		return fmt.Sprintf("%s不在日历[%s]中", t.Format(models.CalendarDateFormat), calendarModel.Name), nil
+	}
+
+	return fmt.Sprintf("%s为日历[%s]排除日期", t.Format(models.CalendarDateFormat), calendarModel.Name), nil
+}
+
+// 记录被跳过的调度, status为取消(日历排除)或失败(无法判断日历)
+func createSkippedTaskLog(taskModel models.Task, status models.Status, reason string) {
+	taskLogId, err := createTaskLog(taskModel, status, 0)
+	if err != nil {
+		logger.Error("任务跳过#写入任务日志失败-", err)
+		return
+	}
+	taskLogModel := new(models.TaskLog)
+	_, err = taskLogModel.Update(taskLogId, models.CommonMap{
+		"result":   reason,
+		"end_time": time.Now(),
+	})
+	if err != nil {
+		logger.Error("任务跳过#更新任务日志失败-", err)
+	}
 }
 
 func createHandler(taskModel models.Task) Handler {
-	var handler Handler = nil
-	switch taskModel.Protocol {
-	case models.TaskHTTP:
-		handler = new(HTTPHandler)
-	case models.TaskRPC:
-		handler = new(RPCHandler)
+	executor, ok := lookupExecutor(taskModel)
+	if !ok {
+		return nil
 	}
 
-	return handler
+	return executor.Handler
 }
 
 // 任务前置操作
-func beforeExecJob(taskModel models.Task) (taskLogId int64) {
+func beforeExecJob(taskModel models.Task, startOffset time.Duration) (taskLogId int64) {
 	if taskModel.Multi == 0 && runInstance.has(taskModel.Id) {
 		logger.Infof("任务已在运行中，取消本次执行#ID-%d", taskModel.Id)
-		taskLogId, _ = createTaskLog(taskModel, models.Cancel)
+		taskLogId, _ = createTaskLog(taskModel, models.Cancel, startOffset)
 		return
 	}
-	taskLogId, err := createTaskLog(taskModel, models.Running)
+	taskLogId, err := createTaskLog(taskModel, models.Running, startOffset)
 	if err != nil {
 		logger.Error("任务开始执行#写入任务日志失败-", err)
 		return
@@ -443,6 +982,12 @@ func afterExecJob(taskModel models.Task, taskResult TaskResult, taskLogId int64)
 	if err != nil {
 		logger.Error("任务结束#更新任务日志失败-", err)
 	}
+	if len(taskResult.Attempts) > 0 {
+		attemptModel := new(models.TaskLogAttempt)
+		if err = attemptModel.BatchCreate(taskResult.Attempts); err != nil {
+			logger.Error("任务结束#写入执行尝试记录失败-", err)
+		}
+	}
 
 	// 发送邮件
 	go SendNotification(taskModel, taskResult)
@@ -502,6 +1047,13 @@ func SendNotification(taskModel models.Task, taskResult TaskResult) {
 		// 执行失败才发送通知
 		return
 	}
+	if taskModel.NotifyStatus == 4 {
+		// 退出码匹配通知
+		ranges, _ := models.ParseCodeRanges(taskModel.NotifyExitCodes)
+		if taskResult.Detail == nil || !ranges.Contains(taskResult.Detail.ExitCode) {
+			return
+		}
+	}
 	if taskModel.NotifyType != 3 && taskModel.NotifyReceiverId == "" {
 		return
 	}
@@ -519,6 +1071,14 @@ func SendNotification(taskModel models.Task, taskResult TaskResult) {
 		"status":           statusName,
 		"task_id":          taskModel.Id,
 		"remark":           taskModel.Remark,
+		"exit_code":        "",
+		"stdout":           "",
+		"stderr":           "",
+	}
+	if detail := taskResult.Detail; detail != nil {
+		msg["exit_code"] = strconv.Itoa(detail.ExitCode)
+		msg["stdout"] = detail.Stdout
+		msg["stderr"] = detail.Stderr
 	}
 	notifyPushFunc(msg)
 }
@@ -530,6 +1090,8 @@ func execJob(handler Handler, taskModel models.Task, taskUniqueId int64) TaskRes
 			logger.Error("panic#service/task.go:execJob#", err)
 		}
 	}()
+	liveOutputs.open(taskUniqueId)
+	defer liveOutputs.close(taskUniqueId)
 	// 默认只运行任务一次
 	var execTimes int8 = 1
 	if taskModel.RetryTimes > 0 {
@@ -538,24 +1100,63 @@ func execJob(handler Handler, taskModel models.Task, taskUniqueId int64) TaskRes
 	var i int8 = 0
 	var output string
 	var err error
+	var delay time.Duration
+	var detail *ExecDetail
+	attempts := make([]models.TaskLogAttempt, 0)
 	for i < execTimes {
+		startTime := time.Now()
 		output, err = handler.Run(taskModel, taskUniqueId)
+		detail = execDetails.take(taskUniqueId)
+		if taskModel.RetryTimes > 0 {
+			attempts = append(attempts, newTaskLogAttempt(taskUniqueId, int(i)+1, delay, startTime, output, err))
+		}
 		if err == nil {
-			return TaskResult{Result: output, Err: err, RetryTimes: i}
+			return TaskResult{Result: output, Err: err, RetryTimes: i, Attempts: attempts, Detail: detail}
 		}
 		i++
-		if i < execTimes {
-			logger.Warnf("任务执行失败#任务id-%d#重试第%d次#输出-%s#错误-%s", taskModel.Id, i, output, err.Error())
-			if taskModel.RetryInterval > 0 {
-				sleepFunc(time.Duration(taskModel.RetryInterval) * time.Second)
-			} else {
-				// 默认重试间隔时间，每次递增1分钟
-				sleepFunc(time.Duration(i) * time.Minute)
+		if i >= execTimes {
+			break
+		}
+		if !shouldRetry(taskModel, output, err) {
+			logger.Infof("任务执行失败, 不满足重试条件#任务id-%d#错误-%s", taskModel.Id, err.Error())
+			return TaskResult{Result: output, Err: err, RetryTimes: i - 1, Attempts: attempts, Detail: detail}
+		}
+		delay = retryDelay(taskModel, int(i))
+		logger.Warnf("任务执行失败#任务id-%d#%s后重试第%d次#输出-%s#错误-%s", taskModel.Id, delay, i, output, err.Error())
+		if waitErr := retryWaitFunc(taskUniqueId, delay); waitErr != nil {
+			logger.Infof("任务取消重试#任务id-%d#%s", taskModel.Id, waitErr)
+			if errors.Is(waitErr, rpcClient.ErrCanceled) {
+				err = waitErr
 			}
+			output = fmt.Sprintf("%s\n%s", output, waitErr)
+			return TaskResult{Result: output, Err: err, RetryTimes: i - 1, Attempts: attempts, Detail: detail}
+		}
+	}
+
+	return TaskResult{Result: output, Err: err, RetryTimes: taskModel.RetryTimes, Attempts: attempts, Detail: detail}
+}
+
+// 单次执行尝试记录
+func newTaskLogAttempt(taskLogId int64, attempt int, delay time.Duration, startTime time.Time, output string, err error) models.TaskLogAttempt {
+	taskLogAttempt := models.TaskLogAttempt{
+		TaskLogId: taskLogId,
+		Attempt:   attempt,
+		Delay:     int(delay / time.Millisecond),
+		StartTime: models.LocalTime(startTime),
+		EndTime:   models.LocalTime(time.Now()),
+		Status:    models.Finish,
+		Result:    output,
+	}
+	if err != nil {
+		taskLogAttempt.Status = models.Failure
+		errorMessage := []rune(err.Error())
+		if len(errorMessage) > 512 {
+			errorMessage = errorMessage[:512]
 		}
+		taskLogAttempt.Error = string(errorMessage)
 	}
 
-	return TaskResult{Result: output, Err: err, RetryTimes: taskModel.RetryTimes}
+	return taskLogAttempt
 }
 
 // 清理日志文件
@@ -4,13 +4,18 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gocronx-team/cron"
 	"github.com/gocronx-team/gocron/internal/models"
+	"github.com/gocronx-team/gocron/internal/modules/app"
 	"github.com/gocronx-team/gocron/internal/modules/httpclient"
 	"github.com/gocronx-team/gocron/internal/modules/logger"
 	"github.com/gocronx-team/gocron/internal/modules/notify"
+	"github.com/gocronx-team/gocron/internal/modules/setting"
+	"github.com/gocronx-team/gocron/internal/modules/utils"
 )
 
 func TestMain(m *testing.M) {
@@ -119,12 +124,13 @@ func (f *fakeHandler) Run(taskModel models.Task, taskUniqueId int64) (string, er
 }
 
 func TestExecJobRetriesUntilSuccess(t *testing.T) {
-	originalSleep := sleepFunc
-	defer func() { sleepFunc = originalSleep }()
+	originalWait := retryWaitFunc
+	defer func() { retryWaitFunc = originalWait }()
 
-	sleepCalls := 0
-	sleepFunc = func(d time.Duration) {
-		sleepCalls++
+	waitCalls := 0
+	retryWaitFunc = func(id int64, d time.Duration) error {
+		waitCalls++
+		return nil
 	}
 
 	handler := &fakeHandler{
@@ -144,17 +150,18 @@ func TestExecJobRetriesUntilSuccess(t *testing.T) {
 	if handler.callCount != 2 {
 		t.Fatalf("expected 2 handler calls, got %d", handler.callCount)
 	}
-	if sleepCalls != 1 {
-		t.Fatalf("expected 1 sleep call, got %d", sleepCalls)
+	if waitCalls != 1 {
+		t.Fatalf("expected 1 retry wait, got %d", waitCalls)
 	}
 }
 
 func TestExecJobReturnsErrorAfterRetriesExhausted(t *testing.T) {
-	originalSleep := sleepFunc
-	defer func() { sleepFunc = originalSleep }()
-	sleepCount := 0
-	sleepFunc = func(d time.Duration) {
-		sleepCount++
+	originalWait := retryWaitFunc
+	defer func() { retryWaitFunc = originalWait }()
+	waitCount := 0
+	retryWaitFunc = func(id int64, d time.Duration) error {
+		waitCount++
+		return nil
 	}
 
 	handler := &fakeHandler{
@@ -175,8 +182,8 @@ func TestExecJobReturnsErrorAfterRetriesExhausted(t *testing.T) {
 	if handler.callCount != 3 {
 		t.Fatalf("expected 3 handler calls, got %d", handler.callCount)
 	}
-	if sleepCount != 2 {
-		t.Fatalf("expected 2 sleep calls, got %d", sleepCount)
+	if waitCount != 2 {
+		t.Fatalf("expected 2 sleep calls, got %d", waitCount)
 	}
 }
 
@@ -259,3 +266,229 @@ func stubNotifyPush(t *testing.T) *[]notify.Message {
 	t.Cleanup(func() { notifyPushFunc = original })
 	return &captured
 }
+
+func TestCronEntryNames(t *testing.T) {
+	if name := cronEntryName(12, 0); name != "12" {
+		t.Fatalf("first schedule should keep task id as name, got %s", name)
+	}
+	if name := cronEntryName(12, 2); name != "12#2" {
+		t.Fatalf("unexpected schedule name %s", name)
+	}
+	if !isTaskCronEntry("12", 12) || !isTaskCronEntry("12#1", 12) {
+		t.Fatal("expected schedule names to belong to task 12")
+	}
+	if isTaskCronEntry("123", 12) || isTaskCronEntry("1#12", 12) || isTaskCronEntry("log-cleanup", 12) {
+		t.Fatal("unexpected schedule name matched task 12")
+	}
+}
+
+func TestStartOffset(t *testing.T) {
+	if offset := startOffset(models.Task{}); offset != 0 {
+		t.Fatalf("expected no offset, got %s", offset)
+	}
+	if offset := startOffset(models.Task{DelaySeconds: 30}); offset != 30*time.Second {
+		t.Fatalf("expected 30s delay, got %s", offset)
+	}
+	taskModel := models.Task{DelaySeconds: 5, JitterSeconds: 2}
+	for i := 0; i < 100; i++ {
+		offset := startOffset(taskModel)
+		if offset < 5*time.Second || offset > 7*time.Second {
+			t.Fatalf("offset out of range: %s", offset)
+		}
+	}
+}
+
+func TestDelayedRunsSingleInstance(t *testing.T) {
+	var runs DelayedRuns
+	first, ok := runs.add(1, true)
+	if !ok {
+		t.Fatal("first delayed run should be accepted")
+	}
+	if _, ok = runs.add(1, true); ok {
+		t.Fatal("single-instance task should not queue a second delayed run")
+	}
+	second, ok := runs.add(1, false)
+	if !ok || second != first {
+		t.Fatal("multi-instance task should share the pending entry")
+	}
+	runs.cancel(1)
+	select {
+	case <-first.cancel:
+	default:
+		t.Fatal("cancel should close the pending channel")
+	}
+	runs.done(1, first)
+	runs.done(1, second)
+	if _, ok = runs.add(1, true); !ok {
+		t.Fatal("new delayed run should be accepted after cancel")
+	}
+}
+
+func TestRunDelayedJobCanceledByRemove(t *testing.T) {
+	originalEnabled := taskEnabledFunc
+	defer func() { taskEnabledFunc = originalEnabled }()
+	taskEnabledFunc = func(id int) bool { return true }
+
+	handler := &fakeHandler{}
+	taskModel := models.Task{Id: 9001, Multi: 1}
+	done := make(chan struct{})
+	go func() {
+		runDelayedJob(handler, taskModel, time.Hour, false)
+		close(done)
+	}()
+	for i := 0; ; i++ {
+		delayedRuns.mu.Lock()
+		_, pending := delayedRuns.runs[taskModel.Id]
+		delayedRuns.mu.Unlock()
+		if pending {
+			break
+		}
+		if i > 100 {
+			t.Fatal("delayed run was not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	delayedRuns.cancel(taskModel.Id)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("removed task should stop waiting")
+	}
+	if handler.callCount != 0 {
+		t.Fatalf("removed task should not run, got %d calls", handler.callCount)
+	}
+}
+
+func TestRunDelayedJobSkipsDisabledTask(t *testing.T) {
+	originalEnabled := taskEnabledFunc
+	defer func() { taskEnabledFunc = originalEnabled }()
+	checked := 0
+	taskEnabledFunc = func(id int) bool {
+		checked++
+		return false
+	}
+
+	handler := &fakeHandler{}
+	runDelayedJob(handler, models.Task{Id: 9002, Multi: 1}, time.Millisecond, false)
+	if checked != 1 || handler.callCount != 0 {
+		t.Fatalf("disabled task should be rechecked and skipped, checked=%d calls=%d", checked, handler.callCount)
+	}
+}
+
+func TestScheduledJobRunsFinalDelayedRun(t *testing.T) {
+	originalIncr, originalDisable := incrRunCountFunc, disableTaskFunc
+	originalEnabled, originalRunJob := taskEnabledFunc, runJobFunc
+	originalCron := serviceCron
+	defer func() {
+		incrRunCountFunc, disableTaskFunc = originalIncr, originalDisable
+		taskEnabledFunc, runJobFunc = originalEnabled, originalRunJob
+		serviceCron = originalCron
+	}()
+	serviceCron = cron.New()
+	incrRunCountFunc = func(id int) (int, error) { return 1, nil }
+	disabled := false
+	disableTaskFunc = func(id int) (int64, error) {
+		disabled = true
+		return 1, nil
+	}
+	// 达到最大执行次数后任务已被禁用
+	taskEnabledFunc = func(id int) bool { return !disabled }
+	var offset time.Duration
+	runs := 0
+	runJobFunc = func(handler Handler, taskModel models.Task, startOffset time.Duration) {
+		runs++
+		offset = startOffset
+	}
+
+	job := createScheduledJob(models.Task{Id: 9003, Protocol: models.TaskHTTP, Multi: 1, MaxRunCount: 1, DelaySeconds: 1})
+	job()
+	if !disabled || runs != 1 || offset != time.Second {
+		t.Fatalf("final delayed run should still execute, disabled=%v runs=%d offset=%s", disabled, runs, offset)
+	}
+}
+
+func TestHTTPHandlerRunWithOptions(t *testing.T) {
+	originalRequest := httpRequestFunc
+	originalSetting := app.Setting
+	defer func() {
+		httpRequestFunc = originalRequest
+		app.Setting = originalSetting
+	}()
+	app.Setting = &setting.Setting{AuthSecret: "test-secret"}
+	secret, err := utils.AesEncrypt(app.Setting.AuthSecret, "p@ss")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var capturedMethod, capturedURL, capturedBody string
+	var capturedHeaders http.Header
+	var capturedOptions httpclient.Options
+	httpRequestFunc = func(method, url string, body string, headers http.Header, timeout int, options httpclient.Options) httpclient.ResponseWrapper {
+		capturedMethod, capturedURL, capturedBody, capturedHeaders = method, url, body, headers
+		capturedOptions = options
+		return httpclient.ResponseWrapper{StatusCode: http.StatusOK, Body: "ok"}
+	}
+
+	task := models.Task{
+		Command:         "http://example.com/api?a=1",
+		HttpMethod:      models.TaskHTTPMethodPut,
+		HttpHeaders:     `[{"name":"X-Trace","value":"abc"}]`,
+		HttpQuery:       `[{"name":"b","value":"2 3"}]`,
+		HttpBody:        `{"k":"v"}`,
+		HttpContentType: "application/json",
+		HttpAuthType:    models.TaskHTTPAuthBasic,
+		HttpAuthUser:    "admin",
+		HttpAuthSecret:  secret,
+	}
+	result, err := (&HTTPHandler{}).Run(task, 1)
+	if err != nil || result != "ok" {
+		t.Fatalf("unexpected result %q, %v", result, err)
+	}
+	if capturedMethod != http.MethodPut || capturedURL != "http://example.com/api?a=1&b=2+3" || capturedBody != `{"k":"v"}` {
+		t.Fatalf("unexpected request: %s %s %s", capturedMethod, capturedURL, capturedBody)
+	}
+	if capturedHeaders.Get("X-Trace") != "abc" || capturedHeaders.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers: %v", capturedHeaders)
+	}
+	if capturedHeaders.Get("Authorization") != "Basic YWRtaW46cEBzcw==" {
+		t.Fatalf("unexpected authorization: %s", capturedHeaders.Get("Authorization"))
+	}
+
+	task.HttpMethod = models.TaskHTTPMethodHead
+	task.HttpAuthType = models.TaskHTTPAuthBearer
+	if _, err = (&HTTPHandler{}).Run(task, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if capturedMethod != http.MethodHead || capturedBody != "" || capturedHeaders.Get("Authorization") != "Bearer p@ss" {
+		t.Fatalf("unexpected head request: %s %q %v", capturedMethod, capturedBody, capturedHeaders)
+	}
+	if capturedOptions != (httpclient.Options{}) {
+		t.Fatalf("expected default transport options, got %+v", capturedOptions)
+	}
+
+	clientKey, err := utils.AesEncrypt(app.Setting.AuthSecret, "KEY")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task.HttpCaCert = "CA"
+	task.HttpClientCert = "CERT"
+	task.HttpClientKey = clientKey
+	task.HttpSkipVerify = 1
+	task.HttpNoRedirect = 1
+	task.HttpProxy = "http://127.0.0.1:3128"
+	result, err = (&HTTPHandler{}).Run(task, 1)
+	if err != nil || !strings.HasPrefix(result, httpSkipVerifyWarning) {
+		t.Fatalf("expected skip verify warning, got %q, %v", result, err)
+	}
+	expected := httpclient.Options{
+		CACert:             "CA",
+		ClientCert:         "CERT",
+		ClientKey:          "KEY",
+		InsecureSkipVerify: true,
+		DisableRedirect:    true,
+		Proxy:              "http://127.0.0.1:3128",
+	}
+	if capturedOptions != expected {
+		t.Fatalf("unexpected transport options: %+v", capturedOptions)
+	}
+}
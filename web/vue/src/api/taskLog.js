@@ -11,5 +11,10 @@ export default {
 
   stop (id, taskId, callback) {
     httpClient.post('/task/log/stop', {id, task_id: taskId}, callback)
+  },
+
+  // EventSource无法设置请求头, 先获取票据, 再连接 /api/task/log/tail?id=&ticket=
+  tailTicket (id, callback) {
+    httpClient.get('/task/log/tail-ticket', {id}, callback)
   }
 }